@@ -0,0 +1,78 @@
+// Package debug logs the HTTP requests made by the API clients, for users to
+// attach to bug reports.
+package debug
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
+)
+
+// Transport logs the method, URL and headers of every request, then the status,
+// duration and headers of its response. Secret headers are redacted.
+type Transport struct {
+	// Base sends the requests, http.DefaultTransport if nil.
+	Base   http.RoundTripper
+	Logger *log.Logger
+	// Redactor hides secret headers, redact.Default() if nil.
+	Redactor *redact.Redactor
+}
+
+// Client returns a copy of client whose requests are logged to w.
+func Client(client *http.Client, w io.Writer, redactor *redact.Redactor) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := *client
+	c.Transport = &Transport{Base: client.Transport, Logger: log.New(w, "", 0), Redactor: redactor}
+	return &c
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	redactor := t.Redactor
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+
+	// Each request and response is logged in one go, so that those of
+	// concurrent requests don't interleave.
+	var b strings.Builder
+	fmt.Fprintf(&b, ">> %s %s\n", req.Method, req.URL)
+	writeHeader(&b, ">>", redactor.Header(req.Header))
+	t.Logger.Print(b.String())
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.Logger.Printf("<< error after %s: %s", took, err)
+		return resp, err
+	}
+
+	b.Reset()
+	fmt.Fprintf(&b, "<< result status: %s in %s\n", resp.Status, took)
+	writeHeader(&b, "<<", redactor.Header(resp.Header))
+	t.Logger.Print(b.String())
+	return resp, nil
+}
+
+func writeHeader(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %s: %s\n", prefix, name, strings.Join(header[name], ", "))
+	}
+}
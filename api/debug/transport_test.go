@@ -0,0 +1,60 @@
+package debug
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "the-request-id")
+		w.Header().Set("Set-Token", "secret-response-token")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	redactor, err := redact.New([]string{"X-Internal-Auth"}, nil)
+	assert.NilError(t, err)
+	out := new(bytes.Buffer)
+	base := &http.Client{}
+	client := Client(base, out, redactor)
+	assert.Check(t, base.Transport == nil, "the client given is left as it is")
+
+	req, err := http.NewRequest("GET", server.URL+"/path", nil)
+	assert.NilError(t, err)
+	req.Header.Set("Circle-Token", "secret-circle-token")
+	req.Header.Set("X-Internal-Auth", "hunter2")
+	req.Header.Set("X-Trace", "trace-id")
+	resp, err := client.Do(req)
+	assert.NilError(t, err)
+	_ = resp.Body.Close()
+
+	log := out.String()
+	assert.Check(t, cmp.Contains(log, ">> GET "+server.URL+"/path\n"))
+	assert.Check(t, cmp.Contains(log, ">> Circle-Token: [REDACTED]\n"))
+	assert.Check(t, cmp.Contains(log, ">> X-Internal-Auth: [REDACTED]\n"))
+	assert.Check(t, cmp.Contains(log, ">> X-Trace: trace-id\n"))
+	assert.Check(t, cmp.Contains(log, "<< result status: 404 Not Found in "))
+	assert.Check(t, cmp.Contains(log, "<< X-Request-Id: the-request-id\n"))
+	assert.Check(t, cmp.Contains(log, "<< Set-Token: [REDACTED]\n"))
+	assert.Check(t, !strings.Contains(log, "secret-"))
+	assert.Check(t, !strings.Contains(log, "hunter2"))
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	out := new(bytes.Buffer)
+	_, err := Client(nil, out, nil).Get(server.URL)
+	assert.Check(t, err != nil)
+	assert.Check(t, cmp.Contains(out.String(), "<< error after "))
+}
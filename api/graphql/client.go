@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/api/header"
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
 	"github.com/CircleCI-Public/circleci-cli/version"
 	"github.com/pkg/errors"
 )
@@ -26,10 +27,14 @@ type Client struct {
 	httpClient *http.Client
 }
 
-// NewClient returns a reference to a Client.
+// NewClient returns a reference to a Client that sends requests with
+// httpClient, or http.DefaultClient if nil.
 func NewClient(httpClient *http.Client, host, endpoint, token string, debug bool) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
 	return &Client{
-		httpClient: http.DefaultClient,
+		httpClient: httpClient,
 		Endpoint:   endpoint,
 		Host:       host,
 		Token:      token,
@@ -225,7 +230,7 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 	}
 
 	if cl.Debug {
-		l.Printf(">> variables: %v", request.Variables)
+		l.Printf(">> variables: %v", redact.Default().Values(request.Variables))
 		l.Printf(">> query: %s", request.Query)
 	}
 
@@ -241,11 +246,6 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 		}
 	}()
 
-	if cl.Debug {
-		l.Printf("<< request id: %s", res.Header.Get("X-Request-Id"))
-		l.Printf("<< result status: %s", res.Status)
-	}
-
 	if res.StatusCode != http.StatusOK {
 		return fmt.Errorf("failure calling GraphQL API: %s", res.Status)
 	}
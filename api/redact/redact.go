@@ -0,0 +1,133 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Mask replaces the value of anything considered secret.
+const Mask = "[REDACTED]"
+
+// Headers and name patterns that are always redacted, on top of any configured.
+var (
+	defaultHeaders  = []string{"Authorization"}
+	defaultPatterns = []string{`(?i)token`, `(?i)secret`, `(?i)key`}
+)
+
+// A Redactor decides which named values, such as HTTP headers or settings,
+// are secret and hides them before they are logged or printed.
+type Redactor struct {
+	headers  map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// New returns a Redactor for the given header names and regular expressions,
+// in addition to the defaults. Names are compared case-insensitively.
+func New(headers, patterns []string) (*Redactor, error) {
+	r := &Redactor{headers: map[string]bool{}}
+
+	for _, h := range append(defaultHeaders, headers...) {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+
+	for _, p := range append(defaultPatterns, patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %s", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// When the CLI is initialized, we replace this with a Redactor built from the
+// user's settings, so everything that logs redacts the same things.
+var defaultRedactor, _ = New(nil, nil)
+
+func SetDefault(r *Redactor) {
+	defaultRedactor = r
+}
+
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// IsSecret reports whether values with the given name should be hidden.
+func (r *Redactor) IsSecret(name string) bool {
+	if r.headers[http.CanonicalHeaderKey(name)] {
+		return true
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns value, or Mask if name is secret.
+func (r *Redactor) Value(name, value string) string {
+	if value != "" && r.IsSecret(name) {
+		return Mask
+	}
+	return value
+}
+
+// Header returns a copy of h with the values of secret headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range h {
+		for _, v := range values {
+			redacted.Add(name, r.Value(name, v))
+		}
+	}
+	return redacted
+}
+
+// Values returns a copy of m with the values of secret keys masked.
+func (r *Redactor) Values(m map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r.IsSecret(k) {
+			v = Mask
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// JSON returns body with the values of secret keys masked at any depth, or
+// body unchanged if it isn't JSON.
+func (r *Redactor) JSON(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(r.jsonValue(v))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func (r *Redactor) jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if r.IsSecret(k) {
+				v[k] = Mask
+			} else {
+				v[k] = r.jsonValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.jsonValue(child)
+		}
+	}
+	return v
+}
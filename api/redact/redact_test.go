@@ -0,0 +1,59 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestRedactor_Defaults(t *testing.T) {
+	r, err := New(nil, nil)
+	assert.NilError(t, err)
+
+	assert.Check(t, r.IsSecret("authorization"))
+	assert.Check(t, r.IsSecret("Circle-Token"))
+	assert.Check(t, r.IsSecret("X-Client-Secret"))
+	assert.Check(t, r.IsSecret("api_key"))
+	assert.Check(t, !r.IsSecret("User-Agent"))
+	assert.Check(t, cmp.Equal(r.Value("User-Agent", "circleci-cli"), "circleci-cli"))
+}
+
+func TestRedactor_Configured(t *testing.T) {
+	r, err := New([]string{"x-internal-auth"}, []string{`(?i)^x-corp-`})
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.DeepEqual(r.Header(http.Header{
+		"X-Internal-Auth": {"hunter2"},
+		"X-Corp-Tenant":   {"acme"},
+		"Accept-Type":     {"application/json"},
+	}), http.Header{
+		"X-Internal-Auth": {Mask},
+		"X-Corp-Tenant":   {Mask},
+		"Accept-Type":     {"application/json"},
+	}))
+
+	assert.Check(t, cmp.DeepEqual(r.Values(map[string]interface{}{
+		"orgSecret": "hunter2",
+		"name":      "my-orb",
+	}), map[string]interface{}{
+		"orgSecret": Mask,
+		"name":      "my-orb",
+	}))
+}
+
+func TestRedactor_InvalidPattern(t *testing.T) {
+	_, err := New(nil, []string{"("})
+	assert.ErrorContains(t, err, `invalid redaction pattern "("`)
+}
+
+func TestRedactor_JSON(t *testing.T) {
+	r, err := New(nil, nil)
+	assert.NilError(t, err)
+
+	body := r.JSON([]byte(`{"id": "the-id", "token": "the-token", "items": [{"nickname": "n", "api_key": "k"}]}`))
+	assert.Check(t, cmp.Equal(string(body), `{"id":"the-id","items":[{"api_key":"[REDACTED]","nickname":"n"}],"token":"[REDACTED]"}`))
+
+	assert.Check(t, cmp.Equal(string(r.JSON([]byte("not json"))), "not json"))
+}
@@ -2,23 +2,57 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/CircleCI-Public/circleci-cli/api/debug"
 	"github.com/CircleCI-Public/circleci-cli/api/header"
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
+	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/version"
 )
 
 type Client struct {
-	baseURL     *url.URL
-	circleToken string
-	client      *http.Client
+	baseURL      *url.URL
+	circleToken  string
+	extraHeaders map[string]string
+	client       *http.Client
+	logger       *log.Logger
+	trace        io.Writer
+	redactor     *redact.Redactor
+	retry        RetryOptions
+	// traceFile is the trace file opened by NewFromConfig, closed by Close.
+	traceFile *os.File
+	// sleep waits between retries, and is replaced in tests.
+	sleep func(time.Duration)
+	// stderr is told when the client pauses for the rate limit to reset.
+	stderr io.Writer
+
+	// mu guards rateLimit, as requests can be sent concurrently.
+	mu        sync.Mutex
+	rateLimit *rateLimit
+}
+
+// reservedHeaders are set by the client itself, extra headers may only replace
+// them when explicitly allowed.
+var reservedHeaders = []string{
+	"Accept-Type",
+	"Authorization",
+	"Circle-Token",
+	"Circleci-Cli-Command",
+	"Content-Type",
+	"User-Agent",
 }
 
 func New(host, endpoint, circleToken string) *Client {
@@ -34,10 +68,89 @@ func New(host, endpoint, circleToken string) *Client {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		retry:  DefaultRetryOptions,
+		sleep:  time.Sleep,
+		stderr: os.Stderr,
 	}
 }
 
+// NewFromConfig returns a client for the REST API described by the given config.
+func NewFromConfig(config *settings.Config) (*Client, error) {
+	c := New(config.Host, config.RestEndpoint, config.Token)
+	if err := c.SetExtraHeaders(config.ExtraHeaders, config.AllowReservedHeaders); err != nil {
+		return nil, err
+	}
+
+	redactor, err := config.Redactor()
+	if err != nil {
+		return nil, err
+	}
+	c.redactor = redactor
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.client.Transport = transport
+	timeout, err := config.HTTPTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		c.client.Timeout = timeout
+	}
+	if config.Retries != nil {
+		if *config.Retries < 0 {
+			return nil, fmt.Errorf("invalid retries %d, expected zero or more", *config.Retries)
+		}
+		c.retry.MaxRetries = *config.Retries
+	}
+	if config.Debug {
+		c.SetDebugOutput(os.Stderr)
+	}
+	if config.TraceFile != "" {
+		f, err := os.OpenFile(config.TraceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		c.SetTraceOutput(f)
+		c.traceFile = f
+	}
+	return c, nil
+}
+
+// SetDebugOutput enables logging of requests and responses to w, with secret
+// headers redacted.
+func (c *Client) SetDebugOutput(w io.Writer) {
+	c.logger = log.New(w, "", 0)
+	c.client = debug.Client(c.client, w, c.redactor)
+}
+
+// SetExtraHeaders configures headers that are attached to every request. Unless
+// allowReserved is set, headers the client manages itself can't be overridden.
+func (c *Client) SetExtraHeaders(headers map[string]string, allowReserved bool) error {
+	if !allowReserved {
+		for name := range headers {
+			for _, reserved := range reservedHeaders {
+				if http.CanonicalHeaderKey(name) == reserved {
+					return fmt.Errorf("header %q is reserved and cannot be overridden", name)
+				}
+			}
+		}
+	}
+
+	c.extraHeaders = headers
+	return nil
+}
+
 func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req *http.Request, err error) {
+	return c.NewRequestWithContext(context.Background(), method, u, payload)
+}
+
+// NewRequestWithContext is like NewRequest, but the request is cancelled,
+// rather than left running, once ctx is done.
+func (c *Client) NewRequestWithContext(ctx context.Context, method string, u *url.URL, payload interface{}) (req *http.Request, err error) {
 	var r io.Reader
 	if payload != nil {
 		buf := &bytes.Buffer{}
@@ -48,7 +161,7 @@ func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req
 		}
 	}
 
-	req, err = http.NewRequest(method, c.baseURL.ResolveReference(u).String(), r)
+	req, err = http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(u).String(), r)
 	if err != nil {
 		return nil, err
 	}
@@ -63,26 +176,98 @@ func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req
 	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for name, value := range c.extraHeaders {
+		req.Header.Set(name, value)
+	}
 
 	return req, nil
 }
 
+// DoRequest sends a request and decodes its JSON response into resp. Requests
+// that fail with a transient error are retried as set by SetRetryOptions. A
+// Retry-After sent by the server is waited for in place of the backoff, unless
+// it is longer than MaxBackoff, or five minutes for a 429, when the error is
+// returned instead. Once the API reports that its rate limit is exhausted,
+// requests wait for it to reset.
 func (c *Client) DoRequest(req *http.Request, resp interface{}) (statusCode int, err error) {
+	for attempt := 0; ; attempt++ {
+		if err = c.waitForRateLimit(); err != nil {
+			return 0, err
+		}
+		statusCode, err = c.doRequest(req, resp)
+		if err == nil || attempt >= c.retry.MaxRetries || !retryable(req, statusCode, err) {
+			return statusCode, err
+		}
+
+		wait := c.retry.backoff(attempt)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+			// A rate limit takes longer to reset than a server to recover.
+			maxWait := c.retry.MaxBackoff
+			if statusCode == http.StatusTooManyRequests {
+				maxWait = maxRateLimitWait
+			}
+			// Rather than retry sooner than asked, give up.
+			if httpErr.RetryAfter > maxWait {
+				return statusCode, err
+			}
+			wait = httpErr.RetryAfter
+			if statusCode == http.StatusTooManyRequests {
+				fmt.Fprintf(c.stderr, "Rate limit reached, waiting %s to retry\n", wait)
+			}
+		}
+		if c.logger != nil {
+			c.logger.Printf("<< retrying in %s: %s", wait.Round(time.Millisecond), err)
+		}
+		c.sleep(wait)
+
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return statusCode, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func (c *Client) doRequest(req *http.Request, resp interface{}) (statusCode int, err error) {
+	var reqBody []byte
+	if c.trace != nil {
+		reqBody = requestBody(req)
+	}
+
+	start := time.Now()
 	httpResp, err := c.client.Do(req)
 	if err != nil {
+		if c.trace != nil {
+			c.writeTrace(start, req, reqBody, nil, nil, err)
+		}
 		return 0, err
 	}
 	defer httpResp.Body.Close()
+	c.updateRateLimit(httpResp.Header)
+
+	if c.trace != nil {
+		respBody := traceResponseBody(httpResp)
+		c.writeTrace(start, req, reqBody, httpResp, respBody, nil)
+	}
 
 	if httpResp.StatusCode >= 300 {
 		httpError := struct {
 			Message string `json:"message"`
 		}{}
 		err = json.NewDecoder(httpResp.Body).Decode(&httpError)
-		if err != nil {
+		// Gateways in front of the API can answer with an HTML error page,
+		// which is better reported by its status than as a decoding error.
+		if err != nil && httpResp.StatusCode < 500 && httpResp.StatusCode != http.StatusTooManyRequests {
 			return httpResp.StatusCode, err
 		}
-		return httpResp.StatusCode, &HTTPError{Code: httpResp.StatusCode, Message: httpError.Message}
+		return httpResp.StatusCode, &HTTPError{
+			Code:       httpResp.StatusCode,
+			Message:    httpError.Message,
+			RetryAfter: parseRetryAfter(httpResp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	if resp != nil {
@@ -101,6 +286,28 @@ func (c *Client) DoRequest(req *http.Request, resp interface{}) (statusCode int,
 type HTTPError struct {
 	Code int
 	Message  string
+	// RetryAfter is how long the server asked to wait before trying again,
+	// zero if it didn't say.
+	RetryAfter time.Duration
+}
+
+// parseRetryAfter parses a Retry-After header, given either as seconds or as
+// the time to retry at.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	at, err := http.ParseTime(value)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
 }
 
 func (e *HTTPError) Error() string {
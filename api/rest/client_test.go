@@ -2,16 +2,26 @@ package rest
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/assert/cmp"
 
+	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/version"
 )
 
@@ -117,6 +127,432 @@ func TestClient_DoRequest(t *testing.T) {
 	})
 }
 
+func TestClient_ServerErrors(t *testing.T) {
+	t.Run("HTML error page", func(t *testing.T) {
+		fix := &fixture{}
+		c, cleanup := fix.Run(http.StatusServiceUnavailable, `<html>Service Unavailable</html>`)
+		defer cleanup()
+
+		c.SetRetryOptions(RetryOptions{})
+
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		statusCode, err := c.DoRequest(r, nil)
+		assert.Equal(t, statusCode, http.StatusServiceUnavailable)
+		assert.Error(t, err, "response 503 (Service Unavailable)")
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		now := time.Date(2020, 10, 1, 9, 55, 0, 0, time.UTC)
+		assert.Check(t, cmp.Equal(parseRetryAfter("", now), time.Duration(0)))
+		assert.Check(t, cmp.Equal(parseRetryAfter("120", now), 2*time.Minute))
+		assert.Check(t, cmp.Equal(parseRetryAfter("-1", now), time.Duration(0)))
+		assert.Check(t, cmp.Equal(parseRetryAfter("Thu, 01 Oct 2020 09:55:30 GMT", now), 30*time.Second))
+		assert.Check(t, cmp.Equal(parseRetryAfter("Thu, 01 Oct 2020 09:54:00 GMT", now), time.Duration(0)))
+		assert.Check(t, cmp.Equal(parseRetryAfter("soon", now), time.Duration(0)))
+	})
+}
+
+func TestClient_Retry(t *testing.T) {
+	// serve answers with each status in turn, then with 200.
+	serve := func(t *testing.T, statuses []int, header http.Header) (*Client, *[]string, *[]time.Duration) {
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			if len(bodies) <= len(statuses) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(statuses[len(bodies)-1])
+				_, _ = io.WriteString(w, "<html>Service Unavailable</html>")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"id": "the-id"}`)
+		}))
+		t.Cleanup(server.Close)
+
+		var waits []time.Duration
+		c := New(server.URL, "api/v2", "fake-token")
+		c.sleep = func(d time.Duration) { waits = append(waits, d) }
+		c.stderr = io.Discard
+		return c, &bodies, &waits
+	}
+	do := func(c *Client, method string, payload interface{}) (map[string]interface{}, error) {
+		r, err := c.NewRequest(method, &url.URL{Path: "path"}, payload)
+		assert.NilError(t, err)
+		resp := map[string]interface{}{}
+		_, err = c.DoRequest(r, &resp)
+		return resp, err
+	}
+
+	t.Run("Check transient errors are retried with backoff", func(t *testing.T) {
+		c, bodies, waits := serve(t, []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, nil)
+
+		resp, err := do(c, "POST", map[string]string{"name": "the-name"})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(resp["id"], "the-id"))
+		assert.Assert(t, cmp.Len(*bodies, 3))
+		assert.Check(t, cmp.Equal((*bodies)[2], (*bodies)[0]), "the body is sent again")
+		assert.Assert(t, cmp.Len(*waits, 2))
+		assert.Check(t, (*waits)[0] >= 500*time.Millisecond && (*waits)[0] <= 750*time.Millisecond, "first wait %s", (*waits)[0])
+		assert.Check(t, (*waits)[1] >= time.Second && (*waits)[1] <= 1500*time.Millisecond, "second wait %s", (*waits)[1])
+	})
+
+	t.Run("Check retries give up", func(t *testing.T) {
+		c, bodies, _ := serve(t, []int{503, 503, 503, 503, 503}, nil)
+
+		_, err := do(c, "GET", nil)
+		assert.Error(t, err, "response 503 (Service Unavailable)")
+		assert.Check(t, cmp.Len(*bodies, 4))
+	})
+
+	t.Run("Check Retry-After is respected", func(t *testing.T) {
+		c, _, waits := serve(t, []int{http.StatusTooManyRequests}, http.Header{"Retry-After": {"2"}})
+
+		_, err := do(c, "GET", nil)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(*waits, []time.Duration{2 * time.Second}))
+	})
+
+	t.Run("Check a long Retry-After gives up", func(t *testing.T) {
+		c, bodies, _ := serve(t, []int{http.StatusServiceUnavailable}, http.Header{"Retry-After": {"3600"}})
+
+		_, err := do(c, "GET", nil)
+		assert.Error(t, err, "response 503 (Service Unavailable)")
+		assert.Check(t, cmp.Len(*bodies, 1))
+	})
+
+	t.Run("Check other server errors are only retried for idempotent requests", func(t *testing.T) {
+		for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout} {
+			c, bodies, _ := serve(t, []int{status}, nil)
+			_, err := do(c, "POST", map[string]string{"name": "the-name"})
+			assert.Check(t, err != nil)
+			assert.Check(t, cmp.Len(*bodies, 1))
+
+			c, bodies, _ = serve(t, []int{status}, nil)
+			_, err = do(c, "PUT", map[string]string{"name": "the-name"})
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Len(*bodies, 2))
+		}
+	})
+
+	t.Run("Check client errors are not retried", func(t *testing.T) {
+		c, bodies, _ := serve(t, []int{http.StatusNotFound, http.StatusNotImplemented}, nil)
+		_, err := do(c, "GET", nil)
+		assert.Check(t, err != nil)
+		assert.Check(t, cmp.Len(*bodies, 1))
+	})
+
+	t.Run("Check network errors are only retried for idempotent requests", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		var waits []time.Duration
+		c := New(server.URL, "api/v2", "fake-token")
+		c.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+		_, err := do(c, "GET", nil)
+		assert.Check(t, err != nil)
+		assert.Check(t, cmp.Len(waits, 3))
+
+		waits = nil
+		_, err = do(c, "POST", nil)
+		assert.Check(t, err != nil)
+		assert.Check(t, cmp.Len(waits, 0))
+	})
+
+	t.Run("Check retries can be turned off", func(t *testing.T) {
+		c, bodies, _ := serve(t, []int{http.StatusServiceUnavailable}, nil)
+		c.SetRetryOptions(RetryOptions{})
+
+		_, err := do(c, "GET", nil)
+		assert.Error(t, err, "response 503 (Service Unavailable)")
+		assert.Check(t, cmp.Len(*bodies, 1))
+	})
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	// serve answers with the given rate limit headers.
+	serve := func(t *testing.T, status int, header http.Header) (*Client, *bytes.Buffer, *[]time.Duration) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, `{}`)
+		}))
+		t.Cleanup(server.Close)
+
+		var waits []time.Duration
+		stderr := new(bytes.Buffer)
+		c := New(server.URL, "api/v2", "fake-token")
+		c.sleep = func(d time.Duration) { waits = append(waits, d) }
+		c.stderr = stderr
+		return c, stderr, &waits
+	}
+	get := func(c *Client) error {
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		_, err = c.DoRequest(r, nil)
+		return err
+	}
+
+	t.Run("Check requests wait for an exhausted limit to reset", func(t *testing.T) {
+		c, stderr, waits := serve(t, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"30"},
+		})
+
+		assert.NilError(t, get(c))
+		assert.Check(t, cmp.Len(*waits, 0))
+		assert.NilError(t, get(c))
+		assert.Check(t, cmp.DeepEqual(*waits, []time.Duration{30 * time.Second}))
+		assert.Check(t, cmp.Equal(stderr.String(), "Rate limit of 100 requests reached, waiting 30s for it to reset\n"))
+	})
+
+	t.Run("Check requests don't wait while the limit has requests left", func(t *testing.T) {
+		c, _, waits := serve(t, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"1"},
+			"X-Ratelimit-Reset":     {"30"},
+		})
+
+		assert.NilError(t, get(c))
+		assert.NilError(t, get(c))
+		assert.Check(t, cmp.Len(*waits, 0))
+	})
+
+	t.Run("Check a long reset fails rather than wait", func(t *testing.T) {
+		c, _, waits := serve(t, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"3600"},
+		})
+
+		assert.NilError(t, get(c))
+		assert.Error(t, get(c), "rate limit of 100 requests reached, it resets in 1h0m0s")
+		assert.Check(t, cmp.Len(*waits, 0))
+	})
+
+	t.Run("Check a 429 waits for Retry-After", func(t *testing.T) {
+		c, stderr, waits := serve(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+		c.SetRetryOptions(RetryOptions{MaxRetries: 1, MaxBackoff: time.Second})
+
+		assert.Check(t, get(c) != nil)
+		assert.Check(t, cmp.DeepEqual(*waits, []time.Duration{time.Minute}))
+		assert.Check(t, cmp.Equal(stderr.String(), "Rate limit reached, waiting 1m0s to retry\n"))
+	})
+
+	t.Run("Check the remaining quota is logged", func(t *testing.T) {
+		c, _, _ := serve(t, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"42"},
+		})
+		debug := new(bytes.Buffer)
+		c.SetDebugOutput(debug)
+
+		assert.NilError(t, get(c))
+		assert.Check(t, cmp.Contains(debug.String(), "<< rate limit: 42 of 100 remaining\n"))
+	})
+
+	t.Run("Check the reset can be a Unix time", func(t *testing.T) {
+		now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+		rl, ok := parseRateLimit(http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)},
+		}, now)
+		assert.Check(t, ok)
+		assert.Check(t, cmp.Equal(rl.reset.Sub(now), time.Minute))
+
+		_, ok = parseRateLimit(http.Header{}, now)
+		assert.Check(t, !ok)
+	})
+}
+
+func TestNewFromConfig_Retries(t *testing.T) {
+	retries := 1
+	c, err := NewFromConfig(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Retries: &retries})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(c.retry.MaxRetries, 1))
+
+	retries = -1
+	_, err = NewFromConfig(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Retries: &retries})
+	assert.Error(t, err, "invalid retries -1, expected zero or more")
+}
+
+func TestNewFromConfig_Timeout(t *testing.T) {
+	c, err := NewFromConfig(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(c.client.Timeout, 10*time.Second))
+
+	c, err = NewFromConfig(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Timeout: "2m"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(c.client.Timeout, 2*time.Minute))
+
+	_, err = NewFromConfig(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Timeout: "soon"})
+	assert.Error(t, err, `invalid timeout "soon", expected a positive duration such as 30s`)
+}
+
+func TestNewFromConfig_TraceFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	traceFile := filepath.Join(t.TempDir(), "trace.ndjson")
+	c, err := NewFromConfig(&settings.Config{Host: server.URL, RestEndpoint: "api/v2", TraceFile: traceFile})
+	assert.NilError(t, err)
+
+	get := func() {
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		_, err = c.DoRequest(r, nil)
+		assert.NilError(t, err)
+	}
+	get()
+	assert.NilError(t, c.Close())
+	assert.NilError(t, c.Close())
+	get()
+
+	trace, err := ioutil.ReadFile(traceFile)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(strings.Count(string(trace), "\n"), 1))
+}
+
+func TestNewFromConfig_ClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The client presents the server's own certificate, and trusts it. The
+	// files can't be in a world-writable directory such as /tmp.
+	dir, err := ioutil.TempDir(".", "tls")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	key, err := x509.MarshalPKCS8PrivateKey(server.TLS.Certificates[0].PrivateKey)
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	assert.NilError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600))
+
+	get := func(config *settings.Config) error {
+		c, err := NewFromConfig(config)
+		assert.NilError(t, err)
+		c.SetRetryOptions(RetryOptions{})
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		_, err = c.DoRequest(r, nil)
+		return err
+	}
+
+	t.Run("Without a client certificate", func(t *testing.T) {
+		err := get(&settings.Config{Host: server.URL, RestEndpoint: "api/v2", TLSCert: certPath})
+		assert.Check(t, err != nil)
+	})
+
+	t.Run("With a client certificate", func(t *testing.T) {
+		err := get(&settings.Config{Host: server.URL, RestEndpoint: "api/v2", TLSCert: certPath,
+			TLSClientCert: certPath, TLSClientKey: keyPath})
+		assert.NilError(t, err)
+	})
+
+	t.Run("Without its key", func(t *testing.T) {
+		_, err := NewFromConfig(&settings.Config{Host: server.URL, RestEndpoint: "api/v2", TLSClientCert: certPath})
+		assert.Error(t, err, "tls_client_cert and tls_client_key must be set together")
+	})
+}
+
+func TestClient_ExtraHeaders(t *testing.T) {
+	t.Run("Extra headers are sent", func(t *testing.T) {
+		fix := &fixture{}
+		c, cleanup := fix.Run(http.StatusOK, `{}`)
+		defer cleanup()
+
+		err := c.SetExtraHeaders(map[string]string{"X-Org-Id": "the-org", "x-proxy-token": "the-proxy-token"}, false)
+		assert.NilError(t, err)
+
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		_, err = c.DoRequest(r, nil)
+		assert.NilError(t, err)
+
+		assert.Check(t, cmp.DeepEqual(fix.Header(), http.Header{
+			"Accept-Encoding": {"gzip"},
+			"Accept-Type":     {"application/json"},
+			"Circle-Token":    {"fake-token"},
+			"User-Agent":      {version.UserAgent()},
+			"X-Org-Id":        {"the-org"},
+			"X-Proxy-Token":   {"the-proxy-token"},
+		}))
+	})
+
+	t.Run("Reserved headers are protected", func(t *testing.T) {
+		c := New("https://circleci.com", "api/v2", "fake-token")
+		err := c.SetExtraHeaders(map[string]string{"circle-token": "other-token"}, false)
+		assert.Error(t, err, `header "circle-token" is reserved and cannot be overridden`)
+
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(r.Header.Get("Circle-Token"), "fake-token"))
+	})
+
+	t.Run("Reserved headers can be explicitly overridden", func(t *testing.T) {
+		c := New("https://circleci.com", "api/v2", "fake-token")
+		err := c.SetExtraHeaders(map[string]string{"Authorization": "Bearer proxy"}, true)
+		assert.NilError(t, err)
+
+		r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(r.Header.Get("Authorization"), "Bearer proxy"))
+	})
+}
+
+func TestClient_DebugOutputRedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c, err := NewFromConfig(&settings.Config{
+		Host:           server.URL,
+		RestEndpoint:   "api/v2",
+		Token:          "fake-token",
+		ExtraHeaders:   map[string]string{"X-Internal-Auth": "hunter2", "X-Corp-Tenant": "acme", "X-Trace": "trace-id"},
+		RedactHeaders:  []string{"x-internal-auth"},
+		RedactPatterns: []string{`(?i)^x-corp-`},
+	})
+	assert.NilError(t, err)
+
+	out := new(bytes.Buffer)
+	c.SetDebugOutput(out)
+
+	r, err := c.NewRequest("GET", &url.URL{Path: "path"}, nil)
+	assert.NilError(t, err)
+	_, err = c.DoRequest(r, nil)
+	assert.NilError(t, err)
+
+	log := out.String()
+	assert.Check(t, cmp.Contains(log, ">> GET "+server.URL+"/api/v2/path"))
+	assert.Check(t, cmp.Contains(log, ">> X-Internal-Auth: [REDACTED]"))
+	assert.Check(t, cmp.Contains(log, ">> X-Corp-Tenant: [REDACTED]"))
+	assert.Check(t, cmp.Contains(log, ">> Circle-Token: [REDACTED]"))
+	assert.Check(t, cmp.Contains(log, ">> X-Trace: trace-id"))
+	assert.Check(t, cmp.Contains(log, "<< result status: 200 OK"))
+	assert.Check(t, !strings.Contains(log, "hunter2"))
+	assert.Check(t, !strings.Contains(log, "fake-token"))
+}
+
 type fixture struct {
 	mu     sync.Mutex
 	url    url.URL
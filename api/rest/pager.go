@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Pager fetches the items of a list endpoint a page at a time. Each page is
+// requested with the page-token query parameter set to the next_page_token of
+// the page before.
+type Pager struct {
+	// PageSize, if set, is sent as the page-size query parameter. It shrinks to
+	// what is left of Limit, so that the last page ends at the limit.
+	PageSize int
+	// Limit stops paging once this many items have been fetched. Zero fetches
+	// every item.
+	Limit int
+
+	c          *Client
+	ctx        context.Context
+	path       string
+	query      url.Values
+	pageToken  string
+	fetched    int
+	done       bool
+	statusCode int
+}
+
+// NewPager returns a Pager for the endpoint at path, starting from the page
+// with the given token, or from the first page if it is empty.
+func (c *Client) NewPager(path string, query url.Values, pageToken string) *Pager {
+	return c.NewPagerWithContext(context.Background(), path, query, pageToken)
+}
+
+// NewPagerWithContext is like NewPager, but the pages are requested with ctx.
+func (c *Client) NewPagerWithContext(ctx context.Context, path string, query url.Values, pageToken string) *Pager {
+	if query == nil {
+		query = url.Values{}
+	}
+	return &Pager{c: c, ctx: ctx, path: path, query: query, pageToken: pageToken}
+}
+
+// More reports whether there are pages left to fetch.
+func (p *Pager) More() bool {
+	return !p.done && (p.Limit <= 0 || p.fetched < p.Limit)
+}
+
+// Next fetches the next page, replacing the contents of items, which must be a
+// pointer to a slice of the endpoint's item type.
+func (p *Pager) Next(items interface{}) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("pager items must be a pointer to a slice, not %T", items)
+	}
+	v = v.Elem()
+	v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	if !p.More() {
+		return nil
+	}
+
+	query := url.Values{}
+	for k, vs := range p.query {
+		query[k] = vs
+	}
+	if size := p.pageSize(); size > 0 {
+		query.Set("page-size", strconv.Itoa(size))
+	}
+	if p.pageToken != "" {
+		query.Set("page-token", p.pageToken)
+	}
+	req, err := p.c.NewRequestWithContext(p.ctx, "GET", &url.URL{Path: p.path, RawQuery: query.Encode()}, nil)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Items         json.RawMessage `json:"items"`
+		NextPageToken string          `json:"next_page_token"`
+	}{}
+	p.statusCode, err = p.c.DoRequest(req, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Items) > 0 {
+		if err = json.Unmarshal(resp.Items, items); err != nil {
+			return err
+		}
+	}
+
+	p.fetched += v.Len()
+	if p.Limit > 0 && p.fetched > p.Limit {
+		// Keep the token of this page, so that carrying on from it fetches
+		// the items cut off again rather than skipping them.
+		v.Set(v.Slice(0, v.Len()-(p.fetched-p.Limit)))
+		p.fetched = p.Limit
+		return nil
+	}
+	// Stop on a repeated token as well as a missing one, so a misbehaving
+	// API can't keep us paging forever.
+	if resp.NextPageToken == "" || resp.NextPageToken == p.pageToken {
+		p.done = true
+		p.pageToken = ""
+		return nil
+	}
+	p.pageToken = resp.NextPageToken
+	return nil
+}
+
+func (p *Pager) pageSize() int {
+	size := p.PageSize
+	if left := p.Limit - p.fetched; size > 0 && p.Limit > 0 && left < size {
+		size = left
+	}
+	return size
+}
+
+// All fetches every page left, appending their items to items, which must be
+// a pointer to a slice of the endpoint's item type. The items fetched before
+// any error are kept.
+func (p *Pager) All(items interface{}) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("pager items must be a pointer to a slice, not %T", items)
+	}
+	v = v.Elem()
+	for p.More() {
+		page := reflect.New(v.Type())
+		err := p.Next(page.Interface())
+		v.Set(reflect.AppendSlice(v, page.Elem()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextPageToken is the token of the page after those fetched, to carry on
+// from later with NewPager. It is empty once the last page has been fetched.
+// If the API returned more items than the page size asked for, the last page
+// is cut at Limit and its own token is returned, so that no item is skipped:
+// carrying on from it fetches that page again. A cut first page has no token.
+func (p *Pager) NextPageToken() string {
+	return p.pageToken
+}
+
+// StatusCode is the status of the last page's response, zero before the first.
+func (p *Pager) StatusCode() int {
+	return p.statusCode
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestPager(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page-token") {
+		case "", "page-1":
+			_, _ = io.WriteString(w, `{"items": [{"name": "a"}, {"name": "b"}], "next_page_token": "page-2"}`)
+		case "page-2":
+			_, _ = io.WriteString(w, `{"items": [{"name": "c"}], "next_page_token": "page-3"}`)
+		case "page-3":
+			_, _ = io.WriteString(w, `{"items": [], "next_page_token": "page-3"}`)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, "api/v2", "fake-token")
+
+	t.Run("Check every page is fetched", func(t *testing.T) {
+		queries = nil
+		p := c.NewPager("items", url.Values{"owner": {"the-owner"}}, "")
+		var items []item
+		assert.NilError(t, p.All(&items))
+		assert.Check(t, cmp.DeepEqual(items, []item{{"a"}, {"b"}, {"c"}}))
+		assert.Check(t, cmp.Equal(p.NextPageToken(), ""), "a repeated token ends paging")
+		assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+			{"owner": {"the-owner"}},
+			{"owner": {"the-owner"}, "page-token": {"page-2"}},
+			{"owner": {"the-owner"}, "page-token": {"page-3"}},
+		}))
+	})
+
+	t.Run("Check paging a page at a time", func(t *testing.T) {
+		p := c.NewPager("items", nil, "")
+		var pages [][]item
+		for p.More() {
+			var page []item
+			assert.NilError(t, p.Next(&page))
+			pages = append(pages, page)
+		}
+		assert.Check(t, cmp.DeepEqual(pages, [][]item{{{"a"}, {"b"}}, {{"c"}}, {}}))
+	})
+
+	t.Run("Check the limit shrinks the last page", func(t *testing.T) {
+		queries = nil
+		p := c.NewPager("items", nil, "")
+		p.PageSize = 2
+		p.Limit = 3
+		var items []item
+		assert.NilError(t, p.All(&items))
+		assert.Check(t, cmp.DeepEqual(items, []item{{"a"}, {"b"}, {"c"}}))
+		assert.Check(t, cmp.Equal(p.NextPageToken(), "page-3"))
+		assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+			{"page-size": {"2"}},
+			{"page-size": {"1"}, "page-token": {"page-2"}},
+		}))
+	})
+
+	t.Run("Check the limit drops extra items", func(t *testing.T) {
+		p := c.NewPager("items", nil, "")
+		p.Limit = 1
+		var items []item
+		assert.NilError(t, p.All(&items))
+		assert.Check(t, cmp.DeepEqual(items, []item{{"a"}}))
+		assert.Check(t, !p.More())
+		assert.Check(t, cmp.Equal(p.NextPageToken(), ""))
+	})
+
+	t.Run("Check the limit keeps the token of a cut page", func(t *testing.T) {
+		p := c.NewPager("items", nil, "page-1")
+		p.Limit = 1
+		var items []item
+		assert.NilError(t, p.All(&items))
+		assert.Check(t, cmp.DeepEqual(items, []item{{"a"}}))
+		assert.Check(t, cmp.Equal(p.NextPageToken(), "page-1"))
+	})
+
+	t.Run("Check paging carries on from a token", func(t *testing.T) {
+		p := c.NewPager("items", nil, "page-2")
+		var items []item
+		assert.NilError(t, p.All(&items))
+		assert.Check(t, cmp.DeepEqual(items, []item{{"c"}}))
+	})
+
+	t.Run("Check items must be a slice pointer", func(t *testing.T) {
+		var items []item
+		assert.Error(t, c.NewPager("items", nil, "").Next(items), "pager items must be a pointer to a slice, not []rest.item")
+	})
+}
+
+func TestPager_Error(t *testing.T) {
+	fix := &fixture{}
+	c, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+	defer cleanup()
+
+	p := c.NewPager("items", nil, "")
+	var items []string
+	assert.Error(t, p.All(&items), "Not Found")
+	assert.Check(t, cmp.Equal(p.StatusCode(), http.StatusNotFound))
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRateLimitWait is the longest the client pauses for an exhausted rate
+// limit to reset. Past that, the request fails rather than appear to hang.
+const maxRateLimitWait = 5 * time.Minute
+
+// rateLimit is the rate limit last reported by the API.
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// parseRateLimit reads the X-RateLimit headers of a response. The reset is
+// given either as seconds from now or as a Unix time. ok is false if the
+// response has no rate limit headers.
+func parseRateLimit(header http.Header, now time.Time) (rl rateLimit, ok bool) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return rl, false
+	}
+	rl.remaining = remaining
+	rl.limit, _ = strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset >= 0 {
+		// No rate limit window is as long as the Unix time of any recent date.
+		if reset > 1e9 {
+			rl.reset = time.Unix(reset, 0)
+		} else {
+			rl.reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl, true
+}
+
+func (c *Client) updateRateLimit(header http.Header) {
+	rl, ok := parseRateLimit(header, time.Now())
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.rateLimit = &rl
+	c.mu.Unlock()
+
+	if c.logger != nil {
+		msg := fmt.Sprintf("<< rate limit: %d of %d remaining", rl.remaining, rl.limit)
+		if !rl.reset.IsZero() {
+			msg += fmt.Sprintf(", resets in %s", time.Until(rl.reset).Round(time.Second))
+		}
+		c.logger.Print(msg)
+	}
+}
+
+// waitForRateLimit pauses until the rate limit resets if the last response
+// said it was exhausted.
+func (c *Client) waitForRateLimit() error {
+	c.mu.Lock()
+	rl := c.rateLimit
+	c.mu.Unlock()
+	if rl == nil || rl.remaining > 0 || rl.reset.IsZero() {
+		return nil
+	}
+
+	wait := time.Until(rl.reset).Round(time.Second)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > maxRateLimitWait {
+		return fmt.Errorf("rate limit of %d requests reached, it resets in %s", rl.limit, wait)
+	}
+	fmt.Fprintf(c.stderr, "Rate limit of %d requests reached, waiting %s for it to reset\n", rl.limit, wait)
+	c.sleep(wait)
+
+	c.mu.Lock()
+	if c.rateLimit == rl {
+		c.rateLimit = nil
+	}
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryOptions configures how requests that fail with a transient error, such
+// as a 503 from a gateway in front of the API or a dropped connection, are
+// retried.
+type RetryOptions struct {
+	// MaxRetries is how many times a request is retried, zero to never retry.
+	MaxRetries int
+	// MinBackoff is the wait before the first retry. It doubles for every
+	// retry after that, up to MaxBackoff, with jitter added.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryOptions are the retry options of a Client made by New.
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// SetRetryOptions changes how requests are retried.
+func (c *Client) SetRetryOptions(opts RetryOptions) {
+	c.retry = opts
+}
+
+// retryable reports whether a request that got the given status, or failed
+// with err before getting one, can be sent again. A 429 or 503 means the
+// request wasn't handled, so any request can be retried. After another 5xx or
+// a network error it may have been, so only requests that are safe to repeat
+// are retried.
+func retryable(req *http.Request, statusCode int, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable:
+		return true
+	case statusCode >= 500 && statusCode != http.StatusNotImplemented:
+		return idempotent(req.Method)
+	case statusCode == 0 && err != nil:
+		// A cancelled request is not a transient error.
+		if errors.Is(err, context.Canceled) || req.Context().Err() != nil {
+			return false
+		}
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// backoff is how long to wait before the given retry, counting from zero:
+// MinBackoff doubled for each earlier retry, capped at MaxBackoff, plus up to
+// half as much again at random so clients don't retry in step.
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	d := o.MinBackoff
+	for i := 0; i < attempt && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1)) // #nosec
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
+)
+
+// traceEntry is a single request and its response, written as one line of
+// JSON to the trace output.
+type traceEntry struct {
+	Time       time.Time      `json:"time"`
+	DurationMS int64          `json:"duration_ms"`
+	Request    traceMessage   `json:"request"`
+	Response   *traceResponse `json:"response,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type traceMessage struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type traceResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// SetTraceOutput enables writing every request and response, with secrets
+// redacted, to w as newline delimited JSON.
+func (c *Client) SetTraceOutput(w io.Writer) {
+	c.trace = w
+}
+
+// Close closes the trace file opened by NewFromConfig, if there is one.
+// Requests sent afterwards are no longer traced.
+func (c *Client) Close() error {
+	if c.traceFile == nil {
+		return nil
+	}
+	err := c.traceFile.Close()
+	c.trace, c.traceFile = nil, nil
+	return err
+}
+
+// requestBody returns a copy of the body of req without consuming it.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	b, _ := ioutil.ReadAll(body)
+	return b
+}
+
+// traceResponseBody reads the body of resp for the trace, leaving an
+// unread copy in its place.
+func traceResponseBody(resp *http.Response) []byte {
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b
+}
+
+func (c *Client) writeTrace(start time.Time, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, err error) {
+	redactor := c.redactor
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+
+	entry := traceEntry{
+		Time:       start.UTC(),
+		DurationMS: time.Since(start).Milliseconds(),
+		Request: traceMessage{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactor.Header(req.Header),
+			Body:   string(redactor.JSON(reqBody)),
+		},
+	}
+	if resp != nil {
+		entry.Response = &traceResponse{
+			Status: resp.StatusCode,
+			Header: redactor.Header(resp.Header),
+			Body:   string(redactor.JSON(respBody)),
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, merr := json.Marshal(entry)
+	if merr != nil {
+		return
+	}
+	// Tracing is best effort and should never fail the request itself.
+	_, _ = c.trace.Write(append(line, '\n'))
+}
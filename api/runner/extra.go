@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// decodeWithExtra decodes data into v, a pointer to a struct, and stores any
+// top-level fields that v doesn't know about in extra. This keeps fields added
+// by newer versions of the API available without breaking decoding.
+func decodeWithExtra(data []byte, v interface{}, extra *map[string]json.RawMessage) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		for key := range fields {
+			// encoding/json matches field names case-insensitively
+			if strings.EqualFold(key, name) {
+				delete(fields, key)
+			}
+		}
+	}
+
+	*extra = nil
+	if len(fields) > 0 {
+		*extra = fields
+	}
+	return nil
+}
+
+func (rc *ResourceClass) UnmarshalJSON(data []byte) error {
+	type plain ResourceClass
+	return decodeWithExtra(data, (*plain)(rc), &rc.RawExtra)
+}
+
+func (q *Quotas) UnmarshalJSON(data []byte) error {
+	type plain Quotas
+	return decodeWithExtra(data, (*plain)(q), &q.RawExtra)
+}
+
+func (q *ResourceClassQuota) UnmarshalJSON(data []byte) error {
+	type plain ResourceClassQuota
+	return decodeWithExtra(data, (*plain)(q), &q.RawExtra)
+}
+
+func (t *Token) UnmarshalJSON(data []byte) error {
+	type plain Token
+	return decodeWithExtra(data, (*plain)(t), &t.RawExtra)
+}
+
+func (u *TokenUsage) UnmarshalJSON(data []byte) error {
+	type plain TokenUsage
+	return decodeWithExtra(data, (*plain)(u), &u.RawExtra)
+}
+
+func (ri *RunnerInstance) UnmarshalJSON(data []byte) error {
+	type plain RunnerInstance
+	return decodeWithExtra(data, (*plain)(ri), &ri.RawExtra)
+}
+
+func (l *TaskLogLine) UnmarshalJSON(data []byte) error {
+	type plain TaskLogLine
+	return decodeWithExtra(data, (*plain)(l), &l.RawExtra)
+}
+
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plain Task
+	return decodeWithExtra(data, (*plain)(t), &t.RawExtra)
+}
@@ -1,7 +1,11 @@
 package runner
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -10,26 +14,67 @@ import (
 )
 
 type Runner struct {
-	rc *rest.Client
+	rc  *rest.Client
+	ctx context.Context
 }
 
 func New(rc *rest.Client) *Runner {
-	return &Runner{rc: rc}
+	return &Runner{rc: rc, ctx: context.Background()}
 }
 
+// WithContext returns a Runner sharing r's client whose API calls are
+// cancelled once ctx is done. r itself is left as it was.
+func (r *Runner) WithContext(ctx context.Context) *Runner {
+	return &Runner{rc: r.rc, ctx: ctx}
+}
+
+// ErrNotFound is returned, wrapped, when a resource looked up by name doesn't
+// exist, or the API reports that there is no resource with the ID given.
+var ErrNotFound = errors.New("not found")
+
 type ResourceClass struct {
 	ID            string `json:"id"`
 	ResourceClass string `json:"resource_class"`
 	Description   string `json:"description"`
+	// DefaultTokenTTLSeconds is how long new tokens for the resource-class are
+	// valid for when no expiry is given, zero if they don't expire by default.
+	DefaultTokenTTLSeconds int64 `json:"default_token_ttl_seconds,omitempty"`
+	// Type is the kind of runner the resource-class is for, machine or
+	// container, if the API reports it.
+	Type string `json:"type,omitempty"`
+	// Labels are key=value pairs attached to the resource-class, such as the
+	// team that owns it.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+// ResourceClassOptions holds the optional settings of a resource-class being created.
+type ResourceClassOptions struct {
+	// Labels are attached to the resource-class. Not every API supports labels.
+	Labels map[string]string
 }
 
+// ErrResourceClassLabelsNotSupported is returned when the API doesn't support labels on resource-classes.
+var ErrResourceClassLabelsNotSupported = errors.New("resource-class labels are not supported by this API")
+
 func (r *Runner) CreateResourceClass(resourceClass, desc string) (rc *ResourceClass, err error) {
-	req, err := r.rc.NewRequest("POST", &url.URL{Path: "runner/resource"}, struct {
-		ResourceClass string `json:"resource_class"`
-		Description   string `json:"description"`
+	return r.CreateResourceClassWithOptions(resourceClass, desc, ResourceClassOptions{})
+}
+
+// CreateResourceClassWithOptions creates a resource-class like
+// CreateResourceClass. If labels were requested but the API ignored them, the
+// created resource-class is returned along with ErrResourceClassLabelsNotSupported.
+func (r *Runner) CreateResourceClassWithOptions(resourceClass, desc string, opts ResourceClassOptions) (rc *ResourceClass, err error) {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "POST", &url.URL{Path: "runner/resource"}, struct {
+		ResourceClass string            `json:"resource_class"`
+		Description   string            `json:"description"`
+		Labels        map[string]string `json:"labels,omitempty"`
 	}{
 		ResourceClass: resourceClass,
 		Description:   desc,
+		Labels:        opts.Labels,
 	})
 	if err != nil {
 		return nil, err
@@ -37,6 +82,9 @@ func (r *Runner) CreateResourceClass(resourceClass, desc string) (rc *ResourceCl
 
 	rc = &ResourceClass{}
 	_, err = r.rc.DoRequest(req, rc)
+	if err == nil && len(opts.Labels) > 0 && rc.Labels == nil {
+		err = ErrResourceClassLabelsNotSupported
+	}
 	return rc, err
 }
 
@@ -56,7 +104,7 @@ func (r *Runner) GetResourceClassByName(resourceClass string) (rc *ResourceClass
 		}
 	}
 
-	return nil, fmt.Errorf("resource class %q not found", resourceClass)
+	return nil, fmt.Errorf("resource class %q %w", resourceClass, ErrNotFound)
 }
 
 func (r *Runner) GetNamespaceByResourceClass(resourceClass string) (ns string, err error) {
@@ -70,7 +118,7 @@ func (r *Runner) GetNamespaceByResourceClass(resourceClass string) (ns string, e
 func (r *Runner) GetResourceClassesByNamespace(namespace string) ([]ResourceClass, error) {
 	query := url.Values{}
 	query.Set("namespace", namespace)
-	req, err := r.rc.NewRequest("GET", &url.URL{Path: "runner/resource", RawQuery: query.Encode()}, nil)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: "runner/resource", RawQuery: query.Encode()}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +131,7 @@ func (r *Runner) GetResourceClassesByNamespace(namespace string) ([]ResourceClas
 }
 
 func (r *Runner) DeleteResourceClass(id string) error {
-	req, err := r.rc.NewRequest("DELETE", &url.URL{Path: "runner/resource/" + url.PathEscape(id)}, nil)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "DELETE", &url.URL{Path: "runner/resource/" + url.PathEscape(id)}, nil)
 	if err != nil {
 		return err
 	}
@@ -92,37 +140,175 @@ func (r *Runner) DeleteResourceClass(id string) error {
 	return err
 }
 
+// ErrResourceClassUpdateNotSupported is returned by UpdateResourceClass when the API doesn't support updating resource-classes.
+var ErrResourceClassUpdateNotSupported = errors.New("updating resource-classes is not supported by this API")
+
+// UpdateResourceClass changes the description of a resource-class, keeping its tokens.
+func (r *Runner) UpdateResourceClass(id, desc string) (rc *ResourceClass, err error) {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "PATCH", &url.URL{Path: "runner/resource/" + url.PathEscape(id)}, struct {
+		Description string `json:"description"`
+	}{
+		Description: desc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rc = &ResourceClass{}
+	statusCode, err := r.rc.DoRequest(req, rc)
+	if unsupported(statusCode, err) {
+		return nil, ErrResourceClassUpdateNotSupported
+	}
+	return rc, notFound(statusCode, err)
+}
+
+// ErrTokenTTLNotSupported is returned by SetResourceClassTokenTTL when the API doesn't support default token TTLs.
+var ErrTokenTTLNotSupported = errors.New("default token TTLs are not supported by this API")
+
+// SetResourceClassTokenTTL sets how long new tokens for the resource-class are
+// valid for by default. A zero ttl removes the default.
+func (r *Runner) SetResourceClassTokenTTL(id string, ttl time.Duration) error {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "PUT", &url.URL{Path: "runner/resource/" + url.PathEscape(id) + "/token-ttl"}, struct {
+		TTLSeconds int64 `json:"ttl_seconds"`
+	}{
+		TTLSeconds: int64(ttl / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrTokenTTLNotSupported
+	}
+	return notFound(statusCode, err)
+}
+
+// SetResourceClassLabels replaces the labels of a resource-class.
+func (r *Runner) SetResourceClassLabels(id string, labels map[string]string) error {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	req, err := r.rc.NewRequestWithContext(r.ctx, "PUT", &url.URL{Path: "runner/resource/" + url.PathEscape(id) + "/labels"}, struct {
+		Labels map[string]string `json:"labels"`
+	}{
+		Labels: labels,
+	})
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrResourceClassLabelsNotSupported
+	}
+	return notFound(statusCode, err)
+}
+
+// ErrQuotasNotSupported is returned by GetQuotas when the API doesn't report concurrency quotas.
+var ErrQuotasNotSupported = errors.New("concurrency quotas are not supported by this API")
+
+// Quotas are the concurrency limits on the runner tasks of a namespace.
+type Quotas struct {
+	// PlanConcurrency is how many tasks the plan lets the namespace run at
+	// once across all its resource-classes, zero if the plan doesn't limit it.
+	PlanConcurrency int `json:"plan_concurrency"`
+	// RunningTasks is how many tasks the namespace is running now.
+	RunningTasks    int                  `json:"running_tasks"`
+	ResourceClasses []ResourceClassQuota `json:"resource_classes"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+// ResourceClassQuota is the concurrency limit of a resource-class.
+type ResourceClassQuota struct {
+	ResourceClass string `json:"resource_class"`
+	// MaxConcurrency is how many tasks the resource-class can run at once,
+	// zero if only the plan limits it.
+	MaxConcurrency int `json:"max_concurrency"`
+	RunningTasks   int `json:"running_tasks"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+func (r *Runner) GetQuotas(namespace string) (*Quotas, error) {
+	query := url.Values{}
+	query.Set("namespace", namespace)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: "runner/quota", RawQuery: query.Encode()}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := &Quotas{}
+	statusCode, err := r.rc.DoRequest(req, quotas)
+	if unsupported(statusCode, err) {
+		return nil, ErrQuotasNotSupported
+	}
+	if err != nil {
+		return nil, notFound(statusCode, err)
+	}
+	return quotas, nil
+}
+
 type Token struct {
 	ID            string    `json:"id"`
 	Token         string    `json:"token"`
 	ResourceClass string    `json:"resource_class"`
 	Nickname      string    `json:"nickname"`
 	CreatedAt     time.Time `json:"created_at"`
+	// ExpiresAt is when the token stops being accepted, nil if it never expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
 }
 
+// TokenOptions holds the optional settings of a token being created.
+type TokenOptions struct {
+	// ExpiresAt makes the token expire at the given time. Not every API supports expiring tokens.
+	ExpiresAt *time.Time
+}
+
+// ErrTokenExpiryNotSupported is returned by CreateTokenWithOptions when the API doesn't support expiring tokens.
+var ErrTokenExpiryNotSupported = errors.New("token expiry is not supported by this API")
+
 func (r *Runner) CreateToken(resourceClass, nickname string) (token *Token, err error) {
+	return r.CreateTokenWithOptions(resourceClass, nickname, TokenOptions{})
+}
+
+// CreateTokenWithOptions creates a token like CreateToken. If an expiry was
+// requested but the API ignored it, the created token is returned along with
+// ErrTokenExpiryNotSupported so the caller can clean it up.
+func (r *Runner) CreateTokenWithOptions(resourceClass, nickname string, opts TokenOptions) (token *Token, err error) {
 	t := struct {
-		ResourceClass string `json:"resource_class"`
-		Nickname      string `json:"nickname"`
+		ResourceClass string     `json:"resource_class"`
+		Nickname      string     `json:"nickname"`
+		ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 	}{
 		ResourceClass: resourceClass,
 		Nickname:      nickname,
+		ExpiresAt:     opts.ExpiresAt,
 	}
 
-	req, err := r.rc.NewRequest("POST", &url.URL{Path: "runner/token"}, t)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "POST", &url.URL{Path: "runner/token"}, t)
 	if err != nil {
 		return nil, err
 	}
 
 	token = &Token{}
 	_, err = r.rc.DoRequest(req, token)
+	if err == nil && opts.ExpiresAt != nil && token.ExpiresAt == nil {
+		err = ErrTokenExpiryNotSupported
+	}
 	return token, err
 }
 
 func (r *Runner) GetRunnerTokensByResourceClass(resourceClass string) ([]Token, error) {
 	query := url.Values{}
 	query.Set("resource-class", resourceClass)
-	req, err := r.rc.NewRequest("GET", &url.URL{Path: "runner/token", RawQuery: query.Encode()}, nil)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: "runner/token", RawQuery: query.Encode()}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +321,7 @@ func (r *Runner) GetRunnerTokensByResourceClass(resourceClass string) ([]Token,
 }
 
 func (r *Runner) DeleteToken(id string) error {
-	req, err := r.rc.NewRequest("DELETE", &url.URL{Path: "runner/token/" + url.PathEscape(id)}, nil)
+	req, err := r.rc.NewRequestWithContext(r.ctx, "DELETE", &url.URL{Path: "runner/token/" + url.PathEscape(id)}, nil)
 	if err != nil {
 		return err
 	}
@@ -144,6 +330,62 @@ func (r *Runner) DeleteToken(id string) error {
 	return err
 }
 
+// ErrTokenUsageNotSupported is returned by GetTokenUsage when the API doesn't report token usage.
+var ErrTokenUsageNotSupported = errors.New("token usage is not supported by this API")
+
+// TokenUsage records a host that recently authenticated with a token.
+type TokenUsage struct {
+	Hostname string    `json:"hostname"`
+	IP       string    `json:"ip,omitempty"`
+	LastUsed time.Time `json:"last_used"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+func (r *Runner) GetTokenUsage(id string) ([]TokenUsage, error) {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: "runner/token/" + url.PathEscape(id) + "/usage"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := struct {
+		Items []TokenUsage `json:"items"`
+	}{}
+	statusCode, err := r.rc.DoRequest(req, &resp)
+	if unsupported(statusCode, err) {
+		return nil, ErrTokenUsageNotSupported
+	}
+	return resp.Items, notFound(statusCode, err)
+}
+
+// notFound wraps ErrNotFound around err if it is a 404 about the resource
+// asked for, rather than one meaning the endpoint is unsupported.
+func notFound(statusCode int, err error) error {
+	var httpErr *rest.HTTPError
+	if statusCode == http.StatusNotFound && errors.As(err, &httpErr) {
+		return fmt.Errorf("%s: %w", httpErr.Message, ErrNotFound)
+	}
+	return err
+}
+
+// unsupported reports whether a status code means the API doesn't offer an
+// endpoint at all. A 404 that comes with its own message is about the
+// resource asked for rather than the endpoint, and is left to notFound.
+func unsupported(statusCode int, err error) bool {
+	switch statusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusNotFound:
+		var httpErr *rest.HTTPError
+		if !errors.As(err, &httpErr) {
+			return true
+		}
+		return httpErr.Message == "" || strings.EqualFold(httpErr.Message, http.StatusText(http.StatusNotFound))
+	}
+	return false
+}
+
 type RunnerInstance struct {
 	ResourceClass  string     `json:"resource_class,omitempty"`
 	Hostname       string     `json:"hostname"`
@@ -153,6 +395,11 @@ type RunnerInstance struct {
 	LastUsed       *time.Time `json:"last_used"`
 	IP             string     `json:"ip,omitempty"`
 	Version        string     `json:"version"`
+	// Type is the kind of runner, machine or container, if the API reports it.
+	Type string `json:"type,omitempty"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
 }
 
 func runnerQueryFromString(query string) url.Values {
@@ -164,15 +411,237 @@ func runnerQueryFromString(query string) url.Values {
 	}
 }
 
+// MaxInstancePageSize is the largest page of runner instances the API will return.
+const MaxInstancePageSize = 1000
+
+// InstanceListOptions holds the optional settings for listing runner instances.
+type InstanceListOptions struct {
+	// PageSize is the number of instances to request per page, up to
+	// MaxInstancePageSize. Zero leaves it to the API's default.
+	PageSize int
+	// Limit stops paging once this many instances have been fetched. Zero
+	// fetches every instance.
+	Limit int
+	// PageToken, if set, carries on from where an earlier listing stopped.
+	PageToken string
+}
+
 func (r *Runner) GetRunnerInstances(query string) ([]RunnerInstance, error) {
-	req, err := r.rc.NewRequest("GET", &url.URL{Path: "runner", RawQuery: runnerQueryFromString(query).Encode()}, nil)
+	instances, _, err := r.GetRunnerInstancesWithOptions(query, InstanceListOptions{})
+	return instances, err
+}
+
+// GetRunnerInstancesWithOptions lists runner instances like GetRunnerInstances,
+// following the API's pages until all instances, or opts.Limit of them, have
+// been fetched. The token of the page after them is returned too, empty if
+// there are no more.
+func (r *Runner) GetRunnerInstancesWithOptions(query string, opts InstanceListOptions) ([]RunnerInstance, string, error) {
+	pager := r.rc.NewPagerWithContext(r.ctx, "runner", runnerQueryFromString(query), opts.PageToken)
+	pager.PageSize = opts.PageSize
+	pager.Limit = opts.Limit
+	if pager.PageSize == 0 && opts.Limit > 0 && opts.Limit <= MaxInstancePageSize {
+		// Don't fetch more than we're going to keep.
+		pager.PageSize = opts.Limit
+	}
+
+	items := []RunnerInstance{}
+	err := pager.All(&items)
+	return items, pager.NextPageToken(), err
+}
+
+// ErrTasksNotSupported is returned by GetTasks when the API doesn't report task history.
+var ErrTasksNotSupported = errors.New("task history is not supported by this API")
+
+// Task is a job run, or waiting to be run, by a resource-class.
+type Task struct {
+	ID            string `json:"id"`
+	ResourceClass string `json:"resource_class"`
+	// Status is one of queued, running, succeeded, failed or canceled.
+	Status    string     `json:"status"`
+	QueuedAt  *time.Time `json:"queued_at"`
+	StartedAt *time.Time `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at"`
+	// RunnerName is the name of the runner instance that ran the task.
+	RunnerName string `json:"runner_name,omitempty"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+// TaskListOptions holds the optional settings for listing tasks.
+type TaskListOptions struct {
+	// Limit stops paging once this many tasks have been fetched. Zero fetches
+	// every task the API has kept.
+	Limit int
+	// Since, if set, leaves out tasks queued before this time, and stops
+	// paging once they are reached.
+	Since time.Time
+	// PageToken, if set, carries on from where an earlier listing stopped.
+	PageToken string
+}
+
+// GetTasks lists the tasks of a resource-class, most recent first, following
+// the API's pages until all tasks, or opts.Limit of them, have been fetched.
+// The token of the page after them is returned too, empty if there are no more.
+func (r *Runner) GetTasks(resourceClass string, opts TaskListOptions) ([]Task, string, error) {
+	pager := r.rc.NewPagerWithContext(r.ctx, "runner/tasks", url.Values{"resource-class": {resourceClass}}, opts.PageToken)
+	// Tasks left out by opts.Since don't count towards the limit, so it is
+	// applied here rather than by the pager.
+	pager.PageSize = opts.Limit
+
+	items := []Task{}
+	for pager.More() {
+		var page []Task
+		err := pager.Next(&page)
+		if unsupported(pager.StatusCode(), err) {
+			return nil, "", ErrTasksNotSupported
+		}
+		if err != nil {
+			return items, "", notFound(pager.StatusCode(), err)
+		}
+		// Tasks are most recent first, so once one was queued before opts.Since
+		// there is no need to look any further.
+		for _, t := range page {
+			if !opts.Since.IsZero() && t.QueuedAt != nil && t.QueuedAt.Before(opts.Since) {
+				return items, "", nil
+			}
+			items = append(items, t)
+		}
+		if opts.Limit > 0 && len(items) >= opts.Limit {
+			return items[:opts.Limit], pager.NextPageToken(), nil
+		}
+	}
+	return items, "", nil
+}
+
+// ErrTaskLogsNotSupported is returned by GetTaskLogs when the API doesn't serve task logs.
+var ErrTaskLogsNotSupported = errors.New("task logs are not supported by this API")
+
+// TaskLogLine is a line of output from a task run by a runner.
+type TaskLogLine struct {
+	Time    time.Time `json:"time"`
+	TaskID  string    `json:"task_id"`
+	Message string    `json:"message"`
+
+	// RawExtra holds any fields in the response not known to this version of the CLI.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+// TaskLogPage is a page of task log lines. Passing NextPageToken to
+// GetTaskLogs fetches the lines that follow, including ones logged since.
+type TaskLogPage struct {
+	Lines         []TaskLogLine `json:"items"`
+	NextPageToken string        `json:"next_page_token"`
+}
+
+// GetTaskLogs fetches the log lines of the tasks run by a resource-class, or of
+// a single task if taskID is set, starting from pageToken or the beginning if empty.
+func (r *Runner) GetTaskLogs(resourceClass, taskID, pageToken string) (*TaskLogPage, error) {
+	query := url.Values{}
+	query.Set("resource-class", resourceClass)
+	if taskID != "" {
+		query.Set("task-id", taskID)
+	}
+	if pageToken != "" {
+		query.Set("page-token", pageToken)
+	}
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: "runner/tasks/logs", RawQuery: query.Encode()}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TaskLogPage{}
+	statusCode, err := r.rc.DoRequest(req, page)
+	if unsupported(statusCode, err) {
+		return nil, ErrTaskLogsNotSupported
+	}
+	return page, notFound(statusCode, err)
+}
+
+// ErrInstanceDeleteNotSupported is returned by DeleteRunnerInstance when the API doesn't support deleting instances.
+var ErrInstanceDeleteNotSupported = errors.New("deleting runner instances is not supported by this API")
+
+// DeleteRunnerInstance removes a runner instance, such as one left behind by an agent that no longer runs.
+func (r *Runner) DeleteRunnerInstance(id string) error {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "DELETE", &url.URL{Path: "runner/" + url.PathEscape(id)}, nil)
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrInstanceDeleteNotSupported
+	}
+	return notFound(statusCode, err)
+}
+
+// ErrInstanceDrainNotSupported is returned by DrainRunnerInstance when the API doesn't support draining instances.
+var ErrInstanceDrainNotSupported = errors.New("draining runner instances is not supported by this API")
+
+// DrainRunnerInstance stops a runner instance from claiming new tasks, while
+// letting the tasks it is running finish.
+func (r *Runner) DrainRunnerInstance(id string) error {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "POST", &url.URL{Path: "runner/" + url.PathEscape(id) + "/drain"}, nil)
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrInstanceDrainNotSupported
+	}
+	return notFound(statusCode, err)
+}
+
+// ErrInstanceLabelsNotSupported is returned by the label methods when the API doesn't support instance labels.
+var ErrInstanceLabelsNotSupported = errors.New("runner instance labels are not supported by this API")
+
+func instanceLabelsPath(id string) string {
+	return "runner/" + url.PathEscape(id) + "/labels"
+}
+
+func (r *Runner) GetRunnerInstanceLabels(id string) (map[string]string, error) {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "GET", &url.URL{Path: instanceLabelsPath(id)}, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := struct {
-		Items []RunnerInstance `json:"items"`
+		Labels map[string]string `json:"labels"`
 	}{}
-	_, err = r.rc.DoRequest(req, &resp)
-	return resp.Items, err
+	statusCode, err := r.rc.DoRequest(req, &resp)
+	if unsupported(statusCode, err) {
+		return nil, ErrInstanceLabelsNotSupported
+	}
+	return resp.Labels, notFound(statusCode, err)
+}
+
+func (r *Runner) SetRunnerInstanceLabel(id, key, value string) error {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "PUT", &url.URL{Path: instanceLabelsPath(id) + "/" + url.PathEscape(key)}, struct {
+		Value string `json:"value"`
+	}{
+		Value: value,
+	})
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrInstanceLabelsNotSupported
+	}
+	return notFound(statusCode, err)
+}
+
+func (r *Runner) RemoveRunnerInstanceLabel(id, key string) error {
+	req, err := r.rc.NewRequestWithContext(r.ctx, "DELETE", &url.URL{Path: instanceLabelsPath(id) + "/" + url.PathEscape(key)}, nil)
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := r.rc.DoRequest(req, nil)
+	if unsupported(statusCode, err) {
+		return ErrInstanceLabelsNotSupported
+	}
+	return notFound(statusCode, err)
 }
@@ -2,6 +2,9 @@ package runner
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -55,6 +58,54 @@ func TestRunner_CreateResourceClass(t *testing.T) {
 	})
 }
 
+func TestRunner_CreateResourceClassWithLabels(t *testing.T) {
+	t.Run("Check labels are sent", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "resource_class": "the-namespace/the-resource-class", "labels": {"team": "mobile"}}`)
+		defer cleanup()
+
+		rc, err := runner.CreateResourceClassWithOptions("the-namespace/the-resource-class", "the-description",
+			ResourceClassOptions{Labels: map[string]string{"team": "mobile"}})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(rc.Labels, map[string]string{"team": "mobile"}))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"resource_class":"the-namespace/the-resource-class","description":"the-description","labels":{"team":"mobile"}}`+"\n"))
+	})
+
+	t.Run("Check labels ignored", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "resource_class": "the-namespace/the-resource-class"}`)
+		defer cleanup()
+
+		rc, err := runner.CreateResourceClassWithOptions("the-namespace/the-resource-class", "the-description",
+			ResourceClassOptions{Labels: map[string]string{"team": "mobile"}})
+		assert.Check(t, cmp.Equal(err, ErrResourceClassLabelsNotSupported))
+		assert.Check(t, cmp.Equal(rc.ID, "the-id"))
+	})
+}
+
+func TestRunner_SetResourceClassLabels(t *testing.T) {
+	t.Run("Check request", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNoContent, ``)
+		defer cleanup()
+
+		err := runner.SetResourceClassLabels("the-id", map[string]string{"team": "mobile"})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/resource/the-id/labels"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "PUT"))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"labels":{"team":"mobile"}}`+"\n"))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+		defer cleanup()
+
+		err := runner.SetResourceClassLabels("the-id", nil)
+		assert.Check(t, cmp.Equal(err, ErrResourceClassLabelsNotSupported))
+	})
+}
+
 func TestRunner_GetResourceClassByName(t *testing.T) {
 	fix := fixture{}
 	runner, cleanup := fix.Run(
@@ -167,6 +218,19 @@ func TestRunner_DeleteResourceClass(t *testing.T) {
 	})
 }
 
+func TestRunner_WithContext(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusOK, ``)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runner.WithContext(ctx).DeleteResourceClass("51628548-4627-4813-9f9b-8cc9637ac879")
+	assert.Check(t, errors.Is(err, context.Canceled))
+	assert.NilError(t, runner.DeleteResourceClass("51628548-4627-4813-9f9b-8cc9637ac879"))
+}
+
 func TestRunner_DeleteResourceClass_PathEscaping(t *testing.T) {
 	fix := fixture{}
 	runner, cleanup := fix.Run(http.StatusOK, ``)
@@ -182,6 +246,32 @@ func TestRunner_DeleteResourceClass_PathEscaping(t *testing.T) {
 	})
 }
 
+func TestRunner_SetResourceClassTokenTTL(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusOK, ``)
+	defer cleanup()
+
+	t.Run("Check TTL is set", func(t *testing.T) {
+		err := runner.SetResourceClassTokenTTL("the-id", 7*24*time.Hour)
+		assert.NilError(t, err)
+	})
+
+	t.Run("Check request", func(t *testing.T) {
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/resource/the-id/token-ttl"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "PUT"))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"ttl_seconds":604800}`+"\n"))
+	})
+}
+
+func TestRunner_SetResourceClassTokenTTL_NotSupported(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+	defer cleanup()
+
+	err := runner.SetResourceClassTokenTTL("the-id", time.Hour)
+	assert.Check(t, cmp.Equal(err, ErrTokenTTLNotSupported))
+}
+
 func TestRunner_CreateToken(t *testing.T) {
 	fix := fixture{}
 	runner, cleanup := fix.Run(
@@ -328,6 +418,103 @@ func TestRunner_DeleteToken_PathEscaping(t *testing.T) {
 	})
 }
 
+func TestRunner_GetQuotas(t *testing.T) {
+	t.Run("Check quotas", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(
+			http.StatusOK,
+			`
+{
+	"plan_concurrency": 30,
+	"running_tasks": 12,
+	"resource_classes": [
+		{
+			"resource_class": "the-namespace/the-resource-class",
+			"max_concurrency": 10,
+			"running_tasks": 10
+		}
+	]
+}`,
+		)
+		defer cleanup()
+
+		quotas, err := runner.GetQuotas("the-namespace")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(quotas, &Quotas{
+			PlanConcurrency: 30,
+			RunningTasks:    12,
+			ResourceClasses: []ResourceClassQuota{
+				{ResourceClass: "the-namespace/the-resource-class", MaxConcurrency: 10, RunningTasks: 10},
+			},
+		}))
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/quota", RawQuery: "namespace=the-namespace"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "GET"))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+		defer cleanup()
+
+		_, err := runner.GetQuotas("the-namespace")
+		assert.Check(t, cmp.Equal(err, ErrQuotasNotSupported))
+	})
+}
+
+func TestRunner_GetTokenUsage(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(
+		http.StatusOK,
+		`
+{
+	"items": [
+		{
+			"hostname": "the-hostname",
+			"ip": "192.0.2.1",
+			"last_used": "2020-10-01T09:55:00.000000Z"
+		}
+	]
+}`,
+	)
+	defer cleanup()
+
+	t.Run("Check token usage", func(t *testing.T) {
+		usage, err := runner.GetTokenUsage("ca5341fd-9b4d-4704-b16e-1b496d6012f2")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(usage, []TokenUsage{
+			{
+				Hostname: "the-hostname",
+				IP:       "192.0.2.1",
+				LastUsed: time.Date(2020, 10, 1, 9, 55, 0, 0, time.UTC),
+			},
+		}))
+	})
+
+	t.Run("Check request", func(t *testing.T) {
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/token/ca5341fd-9b4d-4704-b16e-1b496d6012f2/usage"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "GET"))
+	})
+}
+
+func TestRunner_GetTokenUsage_NotSupported(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+	defer cleanup()
+
+	_, err := runner.GetTokenUsage("ca5341fd-9b4d-4704-b16e-1b496d6012f2")
+	assert.Check(t, cmp.Equal(err, ErrTokenUsageNotSupported))
+}
+
+func TestRunner_GetTokenUsage_NotFound(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Token not found"}`)
+	defer cleanup()
+
+	_, err := runner.GetTokenUsage("ca5341fd-9b4d-4704-b16e-1b496d6012f2")
+	assert.Check(t, errors.Is(err, ErrNotFound))
+	assert.Check(t, cmp.Error(err, "Token not found: not found"))
+}
+
 func TestRunner_GetRunnerInstances_ByNamespace(t *testing.T) {
 	fix := fixture{}
 	runner, cleanup := fix.Run(
@@ -472,3 +659,389 @@ func (f *fixture) Run(statusCode int, respBody string) (r *Runner, cleanup func(
 
 	return New(rest.New(server.URL, "api/v2", "fake-token")), server.Close
 }
+
+func TestRunner_GetRunnerInstances_UnknownFields(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(
+		http.StatusOK,
+		`
+{
+	"items": [
+		{
+			"resource_class": "the-namespace/the-resource-class",
+			"hostname": "the-hostname",
+			"name": "the-name",
+			"version": "5.4.3",
+			"platform": "linux/arm64",
+			"labels": {"zone": "a"}
+		}
+	],
+	"total_count": 1
+}`,
+	)
+	defer cleanup()
+
+	runners, err := runner.GetRunnerInstances("the-namespace")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(runners, []RunnerInstance{
+		{
+			ResourceClass: "the-namespace/the-resource-class",
+			Hostname:      "the-hostname",
+			Name:          "the-name",
+			Version:       "5.4.3",
+			RawExtra: map[string]json.RawMessage{
+				"platform": json.RawMessage(`"linux/arm64"`),
+				"labels":   json.RawMessage(`{"zone": "a"}`),
+			},
+		},
+	}))
+}
+
+func TestRunner_GetRunnerInstancesWithOptions_Pages(t *testing.T) {
+	var queries []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page-token") {
+		case "":
+			_, _ = io.WriteString(w, `{"items": [{"name": "the-name-1"}], "next_page_token": "page-2"}`)
+		case "page-2":
+			_, _ = io.WriteString(w, `{"items": [{"name": "the-name-2"}], "next_page_token": "page-2"}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	runner := New(rest.New(server.URL, "api/v2", "fake-token"))
+
+	runners, _, err := runner.GetRunnerInstancesWithOptions("the-namespace", InstanceListOptions{PageSize: 25})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(runners, []RunnerInstance{{Name: "the-name-1"}, {Name: "the-name-2"}}))
+
+	assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+		{"namespace": {"the-namespace"}, "page-size": {"25"}},
+		{"namespace": {"the-namespace"}, "page-size": {"25"}, "page-token": {"page-2"}},
+	}))
+}
+
+func TestRunner_GetRunnerInstancesWithOptions_Limit(t *testing.T) {
+	var queries []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page-token") {
+		case "":
+			_, _ = io.WriteString(w, `{"items": [{"name": "the-name-1"}, {"name": "the-name-2"}], "next_page_token": "page-2"}`)
+		case "page-2":
+			_, _ = io.WriteString(w, `{"items": [{"name": "the-name-3"}, {"name": "the-name-4"}], "next_page_token": "page-3"}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	runner := New(rest.New(server.URL, "api/v2", "fake-token"))
+
+	t.Run("Check paging stops at the limit", func(t *testing.T) {
+		queries = nil
+		runners, _, err := runner.GetRunnerInstancesWithOptions("the-namespace", InstanceListOptions{PageSize: 2, Limit: 3})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(runners, []RunnerInstance{{Name: "the-name-1"}, {Name: "the-name-2"}, {Name: "the-name-3"}}))
+		assert.Check(t, cmp.Len(queries, 2))
+	})
+
+	t.Run("Check a small limit shrinks the page", func(t *testing.T) {
+		queries = nil
+		runners, _, err := runner.GetRunnerInstancesWithOptions("the-namespace", InstanceListOptions{PageSize: 100, Limit: 1})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(runners, []RunnerInstance{{Name: "the-name-1"}}))
+		assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+			{"namespace": {"the-namespace"}, "page-size": {"1"}},
+		}))
+	})
+
+	t.Run("Check listing carries on from a page token", func(t *testing.T) {
+		queries = nil
+		runners, next, err := runner.GetRunnerInstancesWithOptions("the-namespace", InstanceListOptions{Limit: 2})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(runners, 2))
+		assert.Check(t, cmp.Equal(next, "page-2"))
+
+		runners, next, err = runner.GetRunnerInstancesWithOptions("the-namespace", InstanceListOptions{Limit: 2, PageToken: next})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(runners, []RunnerInstance{{Name: "the-name-3"}, {Name: "the-name-4"}}))
+		assert.Check(t, cmp.Equal(next, "page-3"))
+		assert.Check(t, cmp.DeepEqual(queries[1], url.Values{"namespace": {"the-namespace"}, "page-size": {"2"}, "page-token": {"page-2"}}))
+	})
+}
+
+func TestRunner_CreateTokenWithOptions_Expiry(t *testing.T) {
+	expiresAt := time.Date(2020, 10, 2, 9, 55, 0, 0, time.UTC)
+
+	t.Run("Check expiry is sent and returned", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "token": "the-token", "expires_at": "2020-10-02T09:55:00Z"}`)
+		defer cleanup()
+
+		token, err := runner.CreateTokenWithOptions("the-namespace/the-resource-class", "the-nickname", TokenOptions{ExpiresAt: &expiresAt})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(token.ExpiresAt, &expiresAt))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"resource_class":"the-namespace/the-resource-class","nickname":"the-nickname","expires_at":"2020-10-02T09:55:00Z"}`+"\n"))
+	})
+
+	t.Run("Check unsupported expiry", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "token": "the-token"}`)
+		defer cleanup()
+
+		token, err := runner.CreateTokenWithOptions("the-namespace/the-resource-class", "the-nickname", TokenOptions{ExpiresAt: &expiresAt})
+		assert.Check(t, errors.Is(err, ErrTokenExpiryNotSupported))
+		assert.Check(t, cmp.Equal(token.ID, "the-id"))
+	})
+}
+
+func TestRunner_CreateToken_MissingFields(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "token": "the-token"}`)
+	defer cleanup()
+
+	token, err := runner.CreateToken("the-namespace/the-resource-class", "the-nickname")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(token, &Token{ID: "the-id", Token: "the-token"}))
+}
+
+func TestRunner_SetRunnerInstanceLabel(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusOK, ``)
+	defer cleanup()
+
+	t.Run("Check label is set", func(t *testing.T) {
+		err := runner.SetRunnerInstanceLabel("the-instance", "maintenance", "true")
+		assert.NilError(t, err)
+	})
+
+	t.Run("Check request", func(t *testing.T) {
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/the-instance/labels/maintenance"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "PUT"))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"value":"true"}`+"\n"))
+	})
+}
+
+func TestRunner_GetRunnerInstanceLabels(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusOK, `{"labels": {"maintenance": "true", "zone": "a"}}`)
+	defer cleanup()
+
+	labels, err := runner.GetRunnerInstanceLabels("the-instance")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(labels, map[string]string{"maintenance": "true", "zone": "a"}))
+	assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/the-instance/labels"}))
+}
+
+func TestRunner_RemoveRunnerInstanceLabel_NotSupported(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusMethodNotAllowed, `{"message": "Method Not Allowed"}`)
+	defer cleanup()
+
+	err := runner.RemoveRunnerInstanceLabel("the-instance", "maintenance")
+	assert.Check(t, cmp.Equal(err, ErrInstanceLabelsNotSupported))
+	assert.Check(t, cmp.Equal(fix.Method(), "DELETE"))
+}
+
+func TestRunner_DeleteRunnerInstance(t *testing.T) {
+	t.Run("Check request", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNoContent, ``)
+		defer cleanup()
+
+		err := runner.DeleteRunnerInstance("the-instance")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/the-instance"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "DELETE"))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusMethodNotAllowed, `{"message": "Method Not Allowed"}`)
+		defer cleanup()
+
+		err := runner.DeleteRunnerInstance("the-instance")
+		assert.Check(t, cmp.Equal(err, ErrInstanceDeleteNotSupported))
+	})
+}
+
+func TestRunner_DrainRunnerInstance(t *testing.T) {
+	t.Run("Check request", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNoContent, ``)
+		defer cleanup()
+
+		err := runner.DrainRunnerInstance("the-instance")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/the-instance/drain"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "POST"))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+		defer cleanup()
+
+		err := runner.DrainRunnerInstance("the-instance")
+		assert.Check(t, cmp.Equal(err, ErrInstanceDrainNotSupported))
+	})
+}
+
+func TestRunner_UpdateResourceClass(t *testing.T) {
+	t.Run("Check request", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `{"id": "the-id", "resource_class": "the-namespace/the-resource-class", "description": "the-new-description"}`)
+		defer cleanup()
+
+		rc, err := runner.UpdateResourceClass("the-id", "the-new-description")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(rc, &ResourceClass{
+			ID:            "the-id",
+			ResourceClass: "the-namespace/the-resource-class",
+			Description:   "the-new-description",
+		}))
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{Path: "/api/v2/runner/resource/the-id"}))
+		assert.Check(t, cmp.Equal(fix.Method(), "PATCH"))
+		assert.Check(t, cmp.Equal(fix.Body(), `{"description":"the-new-description"}`+"\n"))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+		defer cleanup()
+
+		_, err := runner.UpdateResourceClass("the-id", "the-new-description")
+		assert.Check(t, cmp.Equal(err, ErrResourceClassUpdateNotSupported))
+	})
+}
+
+func TestRunner_GetTaskLogs(t *testing.T) {
+	t.Run("Check request", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusOK, `
+{
+	"items": [
+		{"time": "2021-06-01T12:00:00Z", "task_id": "the-task", "message": "Starting task"}
+	],
+	"next_page_token": "page-2"
+}`)
+		defer cleanup()
+
+		page, err := runner.GetTaskLogs("the-namespace/the-resource-class", "the-task", "page-1")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(page, &TaskLogPage{
+			Lines: []TaskLogLine{
+				{Time: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), TaskID: "the-task", Message: "Starting task"},
+			},
+			NextPageToken: "page-2",
+		}))
+		assert.Check(t, cmp.Equal(fix.URL(), url.URL{
+			Path:     "/api/v2/runner/tasks/logs",
+			RawQuery: "page-token=page-1&resource-class=the-namespace%2Fthe-resource-class&task-id=the-task",
+		}))
+	})
+
+	t.Run("Check unsupported", func(t *testing.T) {
+		fix := fixture{}
+		runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+		defer cleanup()
+
+		_, err := runner.GetTaskLogs("the-namespace/the-resource-class", "", "")
+		assert.Check(t, cmp.Equal(err, ErrTaskLogsNotSupported))
+	})
+}
+
+func TestRunner_GetTasks(t *testing.T) {
+	var queries []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page-token") {
+		case "":
+			_, _ = io.WriteString(w, `{"items": [{"id": "task-1", "status": "running", "started_at": "2021-06-01T12:00:00Z", "runner_name": "the-name"}], "next_page_token": "page-2"}`)
+		case "page-2":
+			_, _ = io.WriteString(w, `{"items": [{"id": "task-2", "status": "succeeded"}], "next_page_token": ""}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	runner := New(rest.New(server.URL, "api/v2", "fake-token"))
+	started := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Check every page is fetched", func(t *testing.T) {
+		queries = nil
+		tasks, _, err := runner.GetTasks("the-namespace/the-resource-class", TaskListOptions{})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(tasks, []Task{
+			{ID: "task-1", Status: "running", StartedAt: &started, RunnerName: "the-name"},
+			{ID: "task-2", Status: "succeeded"},
+		}))
+		assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+			{"resource-class": {"the-namespace/the-resource-class"}},
+			{"resource-class": {"the-namespace/the-resource-class"}, "page-token": {"page-2"}},
+		}))
+	})
+
+	t.Run("Check paging stops at the limit", func(t *testing.T) {
+		queries = nil
+		tasks, next, err := runner.GetTasks("the-namespace/the-resource-class", TaskListOptions{Limit: 1})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(tasks, 1))
+		assert.Check(t, cmp.Equal(next, "page-2"))
+		assert.Check(t, cmp.Len(queries, 1))
+	})
+
+	t.Run("Check listing carries on from a page token", func(t *testing.T) {
+		queries = nil
+		tasks, next, err := runner.GetTasks("the-namespace/the-resource-class", TaskListOptions{Limit: 1, PageToken: "page-2"})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(tasks, []Task{{ID: "task-2", Status: "succeeded"}}))
+		assert.Check(t, cmp.Equal(next, ""))
+		assert.Check(t, cmp.DeepEqual(queries, []url.Values{
+			{"resource-class": {"the-namespace/the-resource-class"}, "page-size": {"1"}, "page-token": {"page-2"}},
+		}))
+	})
+}
+
+func TestRunner_GetTasks_Since(t *testing.T) {
+	var pages int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"items": [
+			{"id": "task-1", "queued_at": "2021-06-01T12:00:00Z"},
+			{"id": "task-2", "queued_at": "2021-05-31T12:00:00Z"},
+			{"id": "task-3", "queued_at": "2021-05-30T12:00:00Z"}
+		], "next_page_token": "page-2"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	runner := New(rest.New(server.URL, "api/v2", "fake-token"))
+
+	tasks, _, err := runner.GetTasks("the-namespace/the-resource-class", TaskListOptions{
+		Since: time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC),
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(tasks, 2))
+	assert.Check(t, cmp.Equal(tasks[0].ID, "task-1"))
+	assert.Check(t, cmp.Equal(tasks[1].ID, "task-2"))
+	assert.Check(t, cmp.Equal(pages, 1))
+}
+
+func TestRunner_GetTasks_NotSupported(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusNotFound, `{"message": "Not Found"}`)
+	defer cleanup()
+
+	_, _, err := runner.GetTasks("the-namespace/the-resource-class", TaskListOptions{})
+	assert.Check(t, cmp.Equal(err, ErrTasksNotSupported))
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/update"
 	"github.com/CircleCI-Public/circleci-cli/version"
-	"github.com/briandowns/spinner"
 )
 
+// checkForUpdates prints the notice found by the last update check, and starts
+// the next check in the background when one is due. Its notice is printed by
+// the next invocation, so no command waits on the GitHub API. A check still
+// running when the command finishes is abandoned, and made again once due.
 func checkForUpdates(opts *settings.Config) error {
 	if opts.SkipUpdateCheck {
 		return nil
@@ -25,59 +28,82 @@ func checkForUpdates(opts *settings.Config) error {
 		return err
 	}
 
-	if update.ShouldCheckForUpdates(updateCheck) {
-		log := log.New(os.Stderr, "", 0)
-		slug := "CircleCI-Public/circleci-cli"
-
-		spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		spr.Writer = os.Stderr
-		spr.Suffix = " Checking for updates..."
-		spr.Start()
-
-		check, err := update.CheckForUpdates(opts.GitHubAPI, slug, version.Version, version.PackageManager())
+	if updateCheck.Notice != "" {
+		// A notice for a version that has since been replaced is out of date.
+		if updateCheck.NoticeVersion == version.Version {
+			log := log.New(os.Stderr, "", 0)
+			log.Println(updateCheck.Notice)
+			log.Println("") // Print a new-line after all of that
+		}
 
-		if err != nil {
-			spr.Stop()
+		updateCheck.Notice = ""
+		updateCheck.NoticeVersion = ""
+		if err = updateCheck.WriteToDisk(); err != nil {
 			return err
 		}
+	}
 
-		if !check.Found {
-			spr.Suffix = "No updates found."
-			time.Sleep(300 * time.Millisecond)
-			spr.Stop()
+	if !update.ShouldCheckForUpdates(updateCheck) {
+		return nil
+	}
 
-			updateCheck.LastUpdateCheck = time.Now()
-			err = updateCheck.WriteToDisk()
-			return err
-		}
+	loc, err := opts.Location()
+	if err != nil {
+		return err
+	}
 
-		if update.IsLatestVersion(check) {
-			spr.Suffix = "Already up-to-date."
-			time.Sleep(300 * time.Millisecond)
-			spr.Stop()
+	proxy, err := opts.ProxyFunc()
+	if err != nil {
+		return err
+	}
 
-			updateCheck.LastUpdateCheck = time.Now()
-			err = updateCheck.WriteToDisk()
-			return err
-		}
-		spr.Stop()
+	options := []update.CheckOption{update.WithChannel(opts.UpdateChannel), update.WithLocation(loc), update.WithProxy(proxy),
+		update.WithReleaseMirror(opts.ReleaseURL)}
+	if opts.UpdatePrerelease {
+		options = append(options, update.WithPrereleases())
+	}
 
-		if opts.Debug {
-			log.Println(update.DebugVersion(check))
-			log.Println("")
-		}
+	// Record the attempt before it is made, as the CLI may exit before the
+	// check finishes, and every later command would otherwise start another.
+	updateCheck.LastUpdateCheck = time.Now()
+	if err = updateCheck.WriteToDisk(); err != nil {
+		return err
+	}
+
+	go checkInBackground(opts, updateCheck, options...)
 
-		log.Println(update.ReportVersion(check))
-		log.Println(update.HowToUpdate(check))
+	return nil
+}
 
-		log.Println("") // Print a new-line after all of that
+// checkInBackground looks for a newer release and saves the notice to print
+// about it. Failures are left for the check after next to retry, as there is
+// no good place to report them while another command is running.
+func checkInBackground(opts *settings.Config, updateCheck *settings.UpdateCheck, options ...update.CheckOption) {
+	slug := "CircleCI-Public/circleci-cli"
 
-		updateCheck.LastUpdateCheck = time.Now()
-		err = updateCheck.WriteToDisk()
-		if err != nil {
-			return err
-		}
+	check, err := update.CheckForUpdates(opts.GitHubAPI, slug, version.Version, version.InstalledPackageManager(), options...)
+	if err != nil {
+		return
 	}
 
-	return nil
+	if !check.Found || update.IsLatestVersion(check) {
+		return
+	}
+
+	notice := update.UpdateNotice(check, updateNoticeConfig(opts))
+	if notice != "" && opts.Debug {
+		notice = update.DebugVersion(check) + "\n\n" + notice
+	}
+	updateCheck.Notice = notice
+	updateCheck.NoticeVersion = version.Version
+	_ = updateCheck.WriteToDisk()
+}
+
+// updateNoticeConfig maps the user's settings onto the update notice options.
+func updateNoticeConfig(opts *settings.Config) update.NoticeConfig {
+	return update.NoticeConfig{
+		SuppressInstructions: opts.SuppressUpdateInstructions,
+		CustomMessage:        opts.CustomUpdateMessage,
+		NagLevel:             opts.UpdateNagLevel,
+	}
 }
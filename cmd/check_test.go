@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"io/ioutil"
 	"net/http"
 	"os/exec"
 	"time"
@@ -56,10 +57,6 @@ var _ = Describe("Check", func() {
 			)
 			Expect(err).ShouldNot(HaveOccurred())
 
-			command = commandWithHome(checkCLI, tempSettings.Home,
-				"help", "--skip-update-check=false", "--github-api", tempSettings.TestServer.URL(),
-			)
-
 			response = `
 [
   {
@@ -104,15 +101,52 @@ var _ = Describe("Check", func() {
 		})
 
 		Context("using a binary release", func() {
-			It("with flag should tell the user how to update and install", func() {
+			run := func() *gexec.Session {
+				command = commandWithHome(checkCLI, tempSettings.Home,
+					"help", "--skip-update-check=false", "--github-api", tempSettings.TestServer.URL(),
+				)
 				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				return session
+			}
 
-				Eventually(session.Err).Should(gbytes.Say("You are running 0.0.0-dev"))
-				Eventually(session.Err).Should(gbytes.Say("A new release is available (.*)"))
-				Eventually(session.Err).Should(gbytes.Say("You can update with `circleci update install`"))
+			It("with flag should tell the user how to update and install on the next run", func() {
+				tempSettings.Update.Write([]byte("last_checked_at: 2999-01-01T00:00:00Z\nnotice: |-\n  You are running 0.0.0-dev\n  A new release is available (1.0.0)\n  You can update with `circleci update install`\nnotice_version: 0.0.0-dev\n"))
 
-				Eventually(session).Should(gexec.Exit(0))
+				session := run()
+				Expect(session.Err).To(gbytes.Say("You are running 0.0.0-dev"))
+				Expect(session.Err).To(gbytes.Say("A new release is available (.*)"))
+				Expect(session.Err).To(gbytes.Say("You can update with `circleci update install`"))
+
+				session = run()
+				Expect(string(session.Err.Contents())).ToNot(ContainSubstring("A new release is available"))
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("should record the check without waiting for it", func() {
+				tempSettings.TestServer.SetHandler(0, func(w http.ResponseWriter, _ *http.Request) {
+					time.Sleep(3 * time.Second)
+					_, _ = w.Write([]byte(response))
+				})
+
+				start := time.Now()
+				run()
+				Expect(time.Since(start)).To(BeNumerically("<", 3*time.Second))
+				contents, err := ioutil.ReadFile(tempSettings.Update.File.Name())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(contents)).ToNot(ContainSubstring("last_checked_at: 0001-01-01"))
+
+				run()
+				Expect(len(tempSettings.TestServer.ReceivedRequests())).To(BeNumerically("<=", 1))
+			})
+
+			It("should not print a notice found for another version", func() {
+				tempSettings.Update.Write([]byte("last_checked_at: 2999-01-01T00:00:00Z\nnotice: A new release is available (2.0.0)\nnotice_version: 0.0.1\n"))
+
+				session := run()
+				Expect(string(session.Err.Contents())).ToNot(ContainSubstring("A new release is available"))
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
 			})
 		})
 	})
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Background update check", func() {
+	var (
+		server *ghttp.Server
+		dir    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		server = ghttp.NewServer()
+		dir, err = ioutil.TempDir("", "circleci-cli-check")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("should save the notice for the next invocation to print", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+			ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{"tag_name": "v1.0.0", "assets": [{"id": 1, "name": "%s_%s.tar.gz"}]}]`,
+				runtime.GOOS, runtime.GOARCH)),
+		))
+
+		updateCheck := &settings.UpdateCheck{FileUsed: filepath.Join(dir, "update_check.yml")}
+		checkInBackground(&settings.Config{GitHubAPI: server.URL() + "/"}, updateCheck)
+
+		content, err := ioutil.ReadFile(updateCheck.FileUsed)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("A new release is available (1.0.0)"))
+	})
+})
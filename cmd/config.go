@@ -62,7 +62,7 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 		Short:   "Check that the config file is well formed.",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
-			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
+			opts.cl = graphql.NewClient(config.GraphQLHTTPClient(), config.Host, config.Endpoint, config.Token, config.Debug)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return validateConfig(opts, cmd.Flags())
@@ -83,7 +83,7 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 		Short: "Validate config and display expanded configuration.",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
-			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
+			opts.cl = graphql.NewClient(config.GraphQLHTTPClient(), config.Host, config.Endpoint, config.Token, config.Debug)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return processConfig(opts, cmd.Flags())
@@ -111,10 +111,24 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 	migrateCommand.PersistentFlags().StringP("config", "c", ".circleci/config.yml", "path to config file")
 	migrateCommand.PersistentFlags().BoolP("in-place", "i", false, "whether to update file in place.  If false, emits to stdout")
 
+	dumpCommand := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the effective CLI settings and where each value came from",
+		Long: `Print the effective CLI settings and where each value came from.
+
+Each setting is annotated with its source: a command line flag, an environment
+variable, the settings file, or the built-in default. The token is redacted.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return dumpConfig(opts, cmd.Flags())
+		},
+		Args: cobra.NoArgs,
+	}
+
 	configCmd.AddCommand(packCommand)
 	configCmd.AddCommand(validateCommand)
 	configCmd.AddCommand(processCommand)
 	configCmd.AddCommand(migrateCommand)
+	configCmd.AddCommand(dumpCommand)
 
 	return configCmd
 }
@@ -204,3 +218,36 @@ func packConfig(opts configOptions) error {
 func migrateConfig(opts configOptions) error {
 	return proxy.Exec([]string{"config", "migrate"}, opts.args)
 }
+
+// settingFlags maps the global flags onto the settings they override.
+var settingFlags = map[string]string{
+	"debug":             "debug",
+	"token":             "token",
+	"host":              "host",
+	"endpoint":          "endpoint",
+	"github-api":        "github_api",
+	"skip-update-check": "skip_update_check",
+	"header":            "extra_headers",
+	"timezone":          "timezone",
+	"retries":           "retries",
+	"timeout":           "timeout",
+}
+
+func dumpConfig(opts configOptions, flags *pflag.FlagSet) error {
+	for flag, key := range settingFlags {
+		if flags.Changed(flag) {
+			opts.cfg.SetSource(key, settings.SourceFlag)
+		}
+	}
+
+	all, err := opts.cfg.Settings()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Settings file: %s\n\n", opts.cfg.FileUsed)
+	for _, s := range all {
+		fmt.Printf("%s: %s (%s)\n", s.Key, s.Value, s.Source)
+	}
+	return nil
+}
@@ -365,4 +365,48 @@ var _ = Describe("Config", func() {
 			})
 		})
 	})
+
+	Describe("dump", func() {
+		var (
+			command      *exec.Cmd
+			tempSettings *clitest.TempSettings
+		)
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			tempSettings.Config.Write([]byte(`
+host: https://file.example.com
+endpoint: graphql-file
+extra_headers:
+  X-Api-Key: file-secret-key
+`))
+
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"config", "dump",
+				"--skip-update-check",
+				"--endpoint", "graphql-flag",
+			)
+			command.Env = append(command.Env, "CIRCLECI_CLI_TOKEN=env-secret-token-1234")
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("prints each setting with its source and redacts the token", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			out := string(session.Out.Contents())
+			Expect(out).To(ContainSubstring("Settings file: " + tempSettings.Config.Path))
+			Expect(out).To(ContainSubstring("host: https://file.example.com (file)\n"))
+			Expect(out).To(ContainSubstring("endpoint: graphql-flag (flag)\n"))
+			Expect(out).To(ContainSubstring("token: ********1234 (env)\n"))
+			Expect(out).To(ContainSubstring("rest_endpoint: api/v2 (default)\n"))
+			Expect(out).To(ContainSubstring("extra_headers: X-Api-Key=[REDACTED] (file)\n"))
+			Expect(out).NotTo(ContainSubstring("env-secret-token"))
+			Expect(out).NotTo(ContainSubstring("file-secret-key"))
+		})
+	})
 })
@@ -26,7 +26,7 @@ func newDiagnosticCommand(config *settings.Config) *cobra.Command {
 		Short: "Check the status of your CircleCI CLI.",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
-			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
+			opts.cl = graphql.NewClient(config.GraphQLHTTPClient(), config.Host, config.Endpoint, config.Token, config.Debug)
 		},
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return diagnostic(opts)
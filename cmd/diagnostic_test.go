@@ -136,6 +136,20 @@ token: mytoken
 			})
 		})
 
+		Context("invalid timeout in config file", func() {
+			BeforeEach(func() {
+				tempSettings.Config.Write([]byte("token: mytoken\ntimeout: soon\n"))
+			})
+
+			It("print error rather than panic", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say(`Error: invalid timeout "soon", expected a positive duration such as 30s`))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(string(session.Err.Contents())).ToNot(ContainSubstring("panic"))
+			})
+		})
+
 		Context("debug outputs introspection query results", func() {
 			BeforeEach(func() {
 				tempSettings.Config.Write([]byte(`token: zomg`))
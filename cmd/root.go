@@ -3,15 +3,20 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/CircleCI-Public/circleci-cli/api/debug"
 	"github.com/CircleCI-Public/circleci-cli/api/header"
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
 	"github.com/CircleCI-Public/circleci-cli/cmd/runner"
 	"github.com/CircleCI-Public/circleci-cli/data"
 	"github.com/CircleCI-Public/circleci-cli/md_docs"
 	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/CircleCI-Public/circleci-cli/update"
 	"github.com/CircleCI-Public/circleci-cli/version"
 )
 
@@ -30,12 +35,16 @@ var rootOptions *settings.Config
 // rootTokenFromFlag stores the value passed in through the flag --token
 var rootTokenFromFlag string
 
+// rootRetriesFromFlag stores the value passed in through the flag --retries
+var rootRetriesFromFlag int
+
 // Execute adds all child commands to rootCmd and
 // sets flags appropriately. This function is called
 // by main.main(). It only needs to happen once to
 // the rootCmd.
 func Execute() {
 	header.SetCommandStr(CommandStr())
+	update.RemoveOldBinary()
 	command := MakeCommands()
 	if err := command.Execute(); err != nil {
 		os.Exit(-1)
@@ -103,6 +112,12 @@ func MakeCommands() *cobra.Command {
 		panic(err)
 	}
 
+	redactor, err := rootOptions.Redactor()
+	if err != nil {
+		panic(err)
+	}
+	redact.SetDefault(redactor)
+
 	loaded, err := data.LoadData()
 	if err != nil {
 		panic(err)
@@ -158,14 +173,19 @@ func MakeCommands() *cobra.Command {
 
 	flags := rootCmd.PersistentFlags()
 
-	flags.BoolVar(&rootOptions.Debug, "debug", rootOptions.Debug, "Enable debug logging.")
+	flags.BoolVar(&rootOptions.Debug, "debug", rootOptions.Debug, "Log every API request and response to stderr, with secrets redacted")
 	flags.StringVar(&rootTokenFromFlag, "token", "", "your token for using CircleCI, also CIRCLECI_CLI_TOKEN")
 	flags.StringVar(&rootOptions.Host, "host", rootOptions.Host, "URL to your CircleCI host, also CIRCLECI_CLI_HOST")
 	flags.StringVar(&rootOptions.Endpoint, "endpoint", rootOptions.Endpoint, "URI to your CircleCI GraphQL API endpoint")
 	flags.StringVar(&rootOptions.GitHubAPI, "github-api", "https://api.github.com/", "Change the default endpoint to GitHub API for retrieving updates")
 	flags.BoolVar(&rootOptions.SkipUpdateCheck, "skip-update-check", skipUpdateByDefault(), "Skip the check for updates check run before every command.")
+	flags.Var(headerValue{headers: &rootOptions.ExtraHeaders}, "header", "Extra HTTP header to send with REST API requests, as key=value. Can be repeated.")
+	flags.StringVar(&rootOptions.TraceFile, "trace-file", "", "Append a trace of every REST API request and response to this file, with secrets redacted")
+	flags.IntVar(&rootRetriesFromFlag, "retries", 0, "Times to retry REST API requests that fail with a transient error (default 3)")
+	flags.Var(timeoutValue{timeout: &rootOptions.Timeout}, "timeout", "Time limit for each API request, such as 2m (default 30s, 10s for the REST API and none for the GraphQL API)")
+	flags.StringVar(&rootOptions.Timezone, "timezone", rootOptions.Timezone, "Show absolute timestamps in utc or local time (default local)")
 
-	hidden := []string{"github-api", "debug", "endpoint"}
+	hidden := []string{"github-api", "endpoint"}
 
 	for _, f := range hidden {
 		if err := flags.MarkHidden(f); err != nil {
@@ -197,9 +217,24 @@ func prepare() {
 	if rootTokenFromFlag != "" {
 		rootOptions.Token = rootTokenFromFlag
 	}
+	if rootCmd.PersistentFlags().Changed("retries") {
+		rootOptions.Retries = &rootRetriesFromFlag
+	}
+	// The shared HTTP client was made before the flags were parsed.
+	if rootCmd.PersistentFlags().Changed("timeout") && rootOptions.HTTPClient != nil {
+		rootOptions.HTTPClient.Timeout, _ = rootOptions.HTTPTimeout()
+	}
+	// The REST client builds its own HTTP client, and logs its requests itself.
+	if rootOptions.Debug && rootOptions.HTTPClient != nil {
+		rootOptions.HTTPClient = debug.Client(rootOptions.HTTPClient, os.Stderr, nil)
+	}
 }
 
 func rootCmdPreRun(rootOptions *settings.Config) error {
+	if _, err := rootOptions.HTTPTimeout(); err != nil {
+		return err
+	}
+
 	// If an error occurs checking for updates, we should print the error but
 	// not break the CLI entirely.
 	err := checkForUpdates(rootOptions)
@@ -216,6 +251,10 @@ func validateToken(rootOptions *settings.Config) error {
 		url string
 	)
 
+	if _, err = rootOptions.HTTPTimeout(); err != nil {
+		return err
+	}
+
 	if rootOptions.Host == defaultHost {
 		url = rootOptions.Data.Links.NewAPIToken
 	} else {
@@ -272,7 +311,7 @@ func visitAll(root *cobra.Command, fn func(*cobra.Command)) {
 
 func isUpdateIncluded(packageManager string) bool {
 	switch packageManager {
-	case "homebrew", "snap":
+	case "homebrew", "snap", "chocolatey", "scoop", "apt", "yum", "nix":
 		return false
 	default:
 		return true
@@ -294,6 +333,66 @@ This project is the seed for CircleCI's new command-line application.`
 For more help, see the documentation here: %s`, long, config.Data.Links.CLIDocs)
 }
 
+// headerValue is a repeatable flag of key=value pairs that are added to a header map.
+type headerValue struct {
+	headers *map[string]string
+}
+
+func (h headerValue) String() string {
+	if h.headers == nil {
+		return ""
+	}
+
+	pairs := []string{}
+	for name, value := range *h.headers {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (h headerValue) Set(v string) error {
+	kv := strings.SplitN(v, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return fmt.Errorf("expected a header in the form key=value, got %q", v)
+	}
+
+	if *h.headers == nil {
+		*h.headers = map[string]string{}
+	}
+	(*h.headers)[strings.TrimSpace(kv[0])] = kv[1]
+	return nil
+}
+
+func (h headerValue) Type() string {
+	return "key=value"
+}
+
+// timeoutValue is a flag for the timeout setting, checked as it is parsed.
+type timeoutValue struct {
+	timeout *string
+}
+
+func (t timeoutValue) String() string {
+	if t.timeout == nil {
+		return ""
+	}
+	return *t.timeout
+}
+
+func (t timeoutValue) Set(v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("expected a positive duration such as 30s, got %q", v)
+	}
+	*t.timeout = d.String()
+	return nil
+}
+
+func (t timeoutValue) Type() string {
+	return "duration"
+}
+
 func skipUpdateByDefault() bool {
 	return os.Getenv("CI") == "true" || os.Getenv("CIRCLECI_CLI_SKIP_UPDATE_CHECK") == "true"
 }
@@ -0,0 +1,226 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+	"github.com/CircleCI-Public/circleci-cli/settings"
+)
+
+// auditFilename is the file in the settings directory that runner commands
+// append a record of their changes to, as newline delimited JSON.
+const auditFilename = "runner-audit.log"
+
+// auditLogPath is overridden in tests, to keep them out of the settings directory.
+var auditLogPath = func() string {
+	return filepath.Join(settings.SettingsPath(), auditFilename)
+}
+
+// auditEntry records a resource-class or token being created or deleted.
+// Token secrets are never recorded.
+type auditEntry struct {
+	Time time.Time `json:"time"`
+	// User is the local user that ran the command.
+	User string `json:"user"`
+	// Action is one of create or delete.
+	Action string `json:"action"`
+	// Kind is one of resource-class or token.
+	Kind          string `json:"kind"`
+	ID            string `json:"id"`
+	ResourceClass string `json:"resource_class,omitempty"`
+	Nickname      string `json:"nickname,omitempty"`
+}
+
+// auditedRunner records every resource-class and token created or deleted
+// through it. A change that can't be recorded is reported on stderr, rather
+// than failing a command whose change has already been made.
+type auditedRunner struct {
+	running
+	path   string
+	stderr io.Writer
+}
+
+func newAuditedRunner(r running, path string, stderr io.Writer) *auditedRunner {
+	return &auditedRunner{running: r, path: path, stderr: stderr}
+}
+
+func (a *auditedRunner) WithContext(ctx context.Context) running {
+	return newAuditedRunner(a.running.WithContext(ctx), a.path, a.stderr)
+}
+
+func (a *auditedRunner) CreateResourceClass(resourceClass, desc string) (*runner.ResourceClass, error) {
+	return a.CreateResourceClassWithOptions(resourceClass, desc, runner.ResourceClassOptions{})
+}
+
+func (a *auditedRunner) CreateResourceClassWithOptions(resourceClass, desc string, opts runner.ResourceClassOptions) (*runner.ResourceClass, error) {
+	rc, err := a.running.CreateResourceClassWithOptions(resourceClass, desc, opts)
+	// The resource-class is also returned when it was created without its labels.
+	if rc != nil && rc.ID != "" {
+		a.record(auditEntry{Action: "create", Kind: "resource-class", ID: rc.ID, ResourceClass: rc.ResourceClass})
+	}
+	return rc, err
+}
+
+func (a *auditedRunner) DeleteResourceClass(id string) error {
+	err := a.running.DeleteResourceClass(id)
+	if err == nil {
+		a.record(auditEntry{Action: "delete", Kind: "resource-class", ID: id})
+	}
+	return err
+}
+
+func (a *auditedRunner) CreateToken(resourceClass, nickname string) (*runner.Token, error) {
+	return a.CreateTokenWithOptions(resourceClass, nickname, runner.TokenOptions{})
+}
+
+func (a *auditedRunner) CreateTokenWithOptions(resourceClass, nickname string, opts runner.TokenOptions) (*runner.Token, error) {
+	token, err := a.running.CreateTokenWithOptions(resourceClass, nickname, opts)
+	// The token is also returned when it was created without its expiry.
+	if token != nil && token.ID != "" {
+		a.record(auditEntry{Action: "create", Kind: "token", ID: token.ID, ResourceClass: token.ResourceClass, Nickname: token.Nickname})
+	}
+	return token, err
+}
+
+func (a *auditedRunner) DeleteToken(id string) error {
+	err := a.running.DeleteToken(id)
+	if err == nil {
+		a.record(auditEntry{Action: "delete", Kind: "token", ID: id})
+	}
+	return err
+}
+
+func (a *auditedRunner) record(e auditEntry) {
+	e.Time = timeNow().UTC()
+	e.User = currentUser()
+	if err := appendAuditEntry(a.path, e); err != nil {
+		fmt.Fprintf(a.stderr, "Failed to record the %s of %s %s in the audit log: %s\n", e.Action, e.Kind, e.ID, err)
+	}
+}
+
+func appendAuditEntry(path string, e auditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+// readAuditLog reads the entries recorded since the given time, oldest first.
+// A missing log has no entries.
+func readAuditLog(path string, since time.Time) ([]auditEntry, error) {
+	entries := []auditEntry{}
+	f, err := os.Open(path) // #nosec
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to read line %d of %s: %w", line, path, err)
+		}
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func newAuditCommand(o *runnerOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Review the resource-classes and tokens created and deleted from this machine",
+		Long: `Review the resource-classes and tokens created and deleted from this machine.
+
+Every runner command that creates or deletes a resource-class or token records
+when, by which local user, and its ID in ` + auditFilename + ` in the settings directory.`,
+		// The audit log is local, so it can be read without a CLI token.
+		PersistentPreRunE: func(*cobra.Command, []string) error { return o.applySettings() },
+	}
+
+	var since, output string
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the recorded changes, oldest first",
+		Aliases: []string{"ls"},
+		Example: `  circleci runner audit list
+  circleci runner audit list --since 7d --output json`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if output != "table" && output != "json" && output != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", output)
+			}
+			var from time.Time
+			if since != "" {
+				d, err := parseDays(since)
+				if err != nil || d <= 0 {
+					return fmt.Errorf("invalid --since %q, expected a positive duration such as 24h or 7d", since)
+				}
+				from = timeNow().Add(-d)
+			}
+
+			entries, err := readAuditLog(o.auditPath, from)
+			if err != nil {
+				return err
+			}
+			if output != "table" {
+				return writeStructured(cmd.OutOrStdout(), output, entries)
+			}
+
+			table := tablewriter.NewWriter(cmd.OutOrStdout())
+			defer table.Render()
+			table.SetHeader([]string{"Time", "User", "Action", "Kind", "ID", "Resource Class", "Nickname"})
+			for _, e := range entries {
+				table.Append([]string{formatTime(e.Time), e.User, e.Action, e.Kind, e.ID, e.ResourceClass, e.Nickname})
+			}
+			return nil
+		},
+	}
+	listCmd.PersistentFlags().StringVar(&since, "since", "", "Only list changes made within a duration, such as 24h or 7d")
+	listCmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	cmd.AddCommand(listCmd)
+
+	return cmd
+}
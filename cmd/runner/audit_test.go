@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_AuditedRunner(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC) }
+
+	path := filepath.Join(t.TempDir(), "circleci", auditFilename)
+	mock := &runnerMock{rcLabelsUnsupported: true}
+	stderr := new(bytes.Buffer)
+	a := newAuditedRunner(mock, path, stderr)
+
+	rc, err := a.CreateResourceClass("my-namespace/my-resource-class", "my-description")
+	assert.NilError(t, err)
+	_, err = a.CreateResourceClassWithOptions("my-namespace/other", "", runner.ResourceClassOptions{Labels: map[string]string{"os": "linux"}})
+	assert.Check(t, errors.Is(err, runner.ErrResourceClassLabelsNotSupported))
+	token, err := a.CreateToken("my-namespace/my-resource-class", "my-nickname")
+	assert.NilError(t, err)
+	assert.NilError(t, a.DeleteToken(token.ID))
+	assert.NilError(t, a.DeleteResourceClass(rc.ID))
+	assert.Check(t, a.DeleteToken("missing") != nil)
+
+	entries, err := readAuditLog(path, time.Time{})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(stderr.String(), ""))
+	assert.Assert(t, cmp.Len(entries, 5))
+	assert.Check(t, cmp.DeepEqual(entries[0], auditEntry{
+		Time:          timeNow(),
+		User:          currentUser(),
+		Action:        "create",
+		Kind:          "resource-class",
+		ID:            rc.ID,
+		ResourceClass: "my-namespace/my-resource-class",
+	}))
+	assert.Check(t, cmp.Equal(entries[1].ResourceClass, "my-namespace/other"))
+	assert.Check(t, cmp.DeepEqual(entries[2], auditEntry{
+		Time:          timeNow(),
+		User:          currentUser(),
+		Action:        "create",
+		Kind:          "token",
+		ID:            token.ID,
+		ResourceClass: "my-namespace/my-resource-class",
+		Nickname:      "my-nickname",
+	}))
+	assert.Check(t, cmp.DeepEqual(entries[3], auditEntry{Time: timeNow(), User: currentUser(), Action: "delete", Kind: "token", ID: token.ID}))
+	assert.Check(t, cmp.DeepEqual(entries[4], auditEntry{Time: timeNow(), User: currentUser(), Action: "delete", Kind: "resource-class", ID: rc.ID}))
+
+	log, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Check(t, !bytes.Contains(log, []byte(token.Token)), "the token's secret is not recorded")
+
+	t.Run("unwritable log", func(t *testing.T) {
+		stderr := new(bytes.Buffer)
+		a := newAuditedRunner(&runnerMock{}, t.TempDir(), stderr)
+		_, err := a.CreateResourceClass("my-namespace/my-resource-class", "my-description")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stderr.String(), "Failed to record the create of resource-class d8bc155b-5e91-4765-b327-0fa256f0229e in the audit log"))
+	})
+}
+
+func Test_AuditList(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC) }
+
+	path := filepath.Join(t.TempDir(), auditFilename)
+	for _, e := range []auditEntry{
+		{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), User: "alice", Action: "create", Kind: "resource-class", ID: "rc-id", ResourceClass: "my-namespace/my-resource-class"},
+		{Time: time.Date(2022, 3, 9, 0, 0, 0, 0, time.UTC), User: "bob", Action: "create", Kind: "token", ID: "token-id", ResourceClass: "my-namespace/my-resource-class", Nickname: "my-nickname"},
+	} {
+		assert.NilError(t, appendAuditEntry(path, e))
+	}
+
+	run := func(path string, args ...string) (string, error) {
+		cmd := newAuditCommand(&runnerOpts{auditPath: path})
+		stdout := new(bytes.Buffer)
+		cmd.SetOut(stdout)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(append([]string{"list"}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run(path)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `+----------------------+-------+--------+----------------+----------+--------------------------------+-------------+
+|         TIME         | USER  | ACTION |      KIND      |    ID    |         RESOURCE CLASS         |  NICKNAME   |
++----------------------+-------+--------+----------------+----------+--------------------------------+-------------+
+| 2022-03-01T00:00:00Z | alice | create | resource-class | rc-id    | my-namespace/my-resource-class |             |
+| 2022-03-09T00:00:00Z | bob   | create | token          | token-id | my-namespace/my-resource-class | my-nickname |
++----------------------+-------+--------+----------------+----------+--------------------------------+-------------+
+`))
+	})
+
+	t.Run("since", func(t *testing.T) {
+		out, err := run(path, "--since", "7d", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, `"id": "token-id"`))
+		assert.Check(t, !bytes.Contains([]byte(out), []byte("rc-id")))
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		_, err := run(path, "--since", "soon")
+		assert.Error(t, err, `invalid --since "soon", expected a positive duration such as 24h or 7d`)
+	})
+
+	t.Run("no log", func(t *testing.T) {
+		out, err := run(filepath.Join(t.TempDir(), auditFilename), "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "[]\n"))
+	})
+}
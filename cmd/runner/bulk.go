@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// Outcomes of an item of a bulk operation.
+const (
+	bulkSuccess = "success"
+	bulkError   = "error"
+)
+
+// bulkResult is the outcome of one item of a bulk operation.
+type bulkResult struct {
+	Item   string `json:"item"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// bulkOptions are the flags shared by commands operating on many items.
+type bulkOptions struct {
+	output          string
+	continueOnError bool
+	ignoreErrors    bool
+}
+
+func (b *bulkOptions) addFlags(cmd *cobra.Command, o *runnerOpts) {
+	cmd.PersistentFlags().StringVar(&b.output, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format for the result of each item, one of table, json or yaml")
+	cmd.PersistentFlags().BoolVar(&b.continueOnError, "continue-on-error", false,
+		"Carry on with the remaining items after one fails")
+	cmd.PersistentFlags().BoolVar(&b.ignoreErrors, "ignore-errors", false,
+		"Exit successfully even if items failed, requires --continue-on-error")
+}
+
+func (b *bulkOptions) validate() error {
+	if b.output != "table" && b.output != "json" && b.output != "yaml" {
+		return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", b.output)
+	}
+	if b.ignoreErrors && !b.continueOnError {
+		return errors.New("--ignore-errors requires --continue-on-error")
+	}
+	return nil
+}
+
+// run applies fn to each item in turn, stopping after the first failure
+// unless --continue-on-error was given. fn returns a detail to report for a
+// successful item.
+func (b *bulkOptions) run(items []string, fn func(item string) (string, error)) []bulkResult {
+	results := make([]bulkResult, 0, len(items))
+	for _, item := range items {
+		detail, err := fn(item)
+		if err != nil {
+			results = append(results, bulkResult{Item: item, Status: bulkError, Detail: err.Error()})
+			if !b.continueOnError {
+				break
+			}
+			continue
+		}
+		results = append(results, bulkResult{Item: item, Status: bulkSuccess, Detail: detail})
+	}
+	return results
+}
+
+// report writes the results, and returns an error naming the operation if any
+// of the total items failed or were not attempted, unless errors are ignored.
+func (b *bulkOptions) report(w io.Writer, operation string, total int, results []bulkResult) error {
+	var err error
+	if b.output != "table" {
+		err = writeStructured(w, b.output, results)
+	} else {
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Item", "Status", "Detail"})
+		for _, r := range results {
+			table.Append([]string{r.Item, r.Status, r.Detail})
+		}
+		table.Render()
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Status == bulkError {
+			failed++
+		}
+	}
+	if failed == 0 || b.ignoreErrors {
+		return nil
+	}
+	if skipped := total - len(results); skipped > 0 {
+		return fmt.Errorf("%s failed for %d of %d items, %d not attempted", operation, failed, total, skipped)
+	}
+	return fmt.Errorf("%s failed for %d of %d items", operation, failed, total)
+}
@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// failingDeleteMock fails to delete the tokens with the given IDs.
+type failingDeleteMock struct {
+	*runnerMock
+	failing map[string]bool
+}
+
+func (f failingDeleteMock) WithContext(ctx context.Context) running {
+	f.runnerMock.WithContext(ctx)
+	return f
+}
+
+func (f failingDeleteMock) DeleteToken(id string) error {
+	if f.failing[id] {
+		return errors.New("permission denied")
+	}
+	return f.runnerMock.DeleteToken(id)
+}
+
+func Test_TokenDeleteAll(t *testing.T) {
+	newMock := func() failingDeleteMock {
+		return failingDeleteMock{
+			runnerMock: &runnerMock{tokens: []runner.Token{
+				{ID: "t1", Nickname: "one", ResourceClass: "my-namespace/my-resource-class"},
+				{ID: "t2", Nickname: "two", ResourceClass: "my-namespace/my-resource-class"},
+				{ID: "t3", Nickname: "three", ResourceClass: "my-namespace/my-resource-class"},
+				{ID: "t4", Nickname: "other", ResourceClass: "my-namespace/other-resource-class"},
+			}},
+			failing: map[string]bool{"t2": true},
+		}
+	}
+
+	run := func(r running, args ...string) ([]bulkResult, string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: r, confirm: func(string) bool { return true }}, newTokenCommand, append([]string{"delete-all", "my-namespace/my-resource-class"}, args...)...)
+		// On failure cobra follows the results with the error and usage.
+		var results []bulkResult
+		_ = json.NewDecoder(strings.NewReader(stdout)).Decode(&results)
+		return results, stdout, err
+	}
+
+	t.Run("all succeed", func(t *testing.T) {
+		mock := newMock()
+		mock.failing = nil
+
+		results, _, err := run(mock, "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(results, []bulkResult{
+			{Item: "t1", Status: "success", Detail: "deleted token one"},
+			{Item: "t2", Status: "success", Detail: "deleted token two"},
+			{Item: "t3", Status: "success", Detail: "deleted token three"},
+		}))
+		assert.Check(t, cmp.Len(mock.tokens, 1))
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		mock := newMock()
+
+		results, _, err := run(mock, "--output", "json")
+		assert.Error(t, err, "delete tokens failed for 1 of 3 items, 1 not attempted")
+		assert.Check(t, cmp.DeepEqual(results, []bulkResult{
+			{Item: "t1", Status: "success", Detail: "deleted token one"},
+			{Item: "t2", Status: "error", Detail: "permission denied"},
+		}))
+		assert.Check(t, cmp.Len(mock.tokens, 3))
+	})
+
+	t.Run("continues on error", func(t *testing.T) {
+		mock := newMock()
+
+		results, _, err := run(mock, "--output", "json", "--continue-on-error")
+		assert.Error(t, err, "delete tokens failed for 1 of 3 items")
+		assert.Check(t, cmp.DeepEqual(results, []bulkResult{
+			{Item: "t1", Status: "success", Detail: "deleted token one"},
+			{Item: "t2", Status: "error", Detail: "permission denied"},
+			{Item: "t3", Status: "success", Detail: "deleted token three"},
+		}))
+		assert.Check(t, cmp.Len(mock.tokens, 2))
+	})
+
+	t.Run("ignores errors", func(t *testing.T) {
+		results, _, err := run(newMock(), "--output", "json", "--continue-on-error", "--ignore-errors")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(results, 3))
+		assert.Check(t, cmp.Equal(results[1].Status, "error"))
+	})
+
+	t.Run("ignore errors requires continue on error", func(t *testing.T) {
+		_, _, err := run(newMock(), "--ignore-errors")
+		assert.Error(t, err, "--ignore-errors requires --continue-on-error")
+	})
+
+	t.Run("table", func(t *testing.T) {
+		_, out, err := run(newMock(), "--continue-on-error")
+		assert.Error(t, err, "delete tokens failed for 1 of 3 items")
+		assert.Check(t, cmp.Contains(out, "| t2   | error   | permission denied   |"))
+	})
+
+	t.Run("asks for confirmation", func(t *testing.T) {
+		mock := newMock()
+		var asked string
+		o := &runnerOpts{r: mock, confirm: func(message string) bool {
+			asked = message
+			return false
+		}}
+
+		_, _, err := executeRunnerCommand(t, o, newTokenCommand, "delete-all", "my-namespace/my-resource-class")
+		assert.Error(t, err, "not deleting the tokens of resource-class my-namespace/my-resource-class")
+		assert.Check(t, cmp.Equal(asked, "Are you sure you want to delete all 3 tokens of resource-class my-namespace/my-resource-class?"))
+		assert.Check(t, cmp.Len(mock.tokens, 4))
+	})
+
+	t.Run("force", func(t *testing.T) {
+		mock := newMock()
+		mock.failing = nil
+		o := &runnerOpts{r: mock, confirm: func(string) bool {
+			t.Error("asked for confirmation despite --force")
+			return false
+		}}
+
+		_, _, err := executeRunnerCommand(t, o, newTokenCommand, "delete-all", "my-namespace/my-resource-class", "--force")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(mock.tokens, 1))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mock := newMock()
+
+		_, out, err := run(mock, "--dry-run")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "Would delete token one (t1)\nWould delete token two (t2)\nWould delete token three (t3)\n"))
+		assert.Check(t, cmp.Len(mock.tokens, 4))
+	})
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+type instanceField struct {
+	name   string
+	header string
+	value  func(r runner.RunnerInstance) string
+}
+
+// instanceFields are the columns available when listing runner instances, in
+// their default order. Names match the JSON fields of runner.RunnerInstance.
+var instanceFields = []instanceField{
+	{"name", "Name", func(r runner.RunnerInstance) string { return r.Name }},
+	{"resource_class", "Resource Class", func(r runner.RunnerInstance) string { return r.ResourceClass }},
+	{"hostname", "Hostname", func(r runner.RunnerInstance) string { return r.Hostname }},
+	{"first_connected", "First Connected", func(r runner.RunnerInstance) string { return formatOptionalTime(r.FirstConnected) }},
+	{"last_connected", "Last Connected", func(r runner.RunnerInstance) string { return formatOptionalTime(r.LastConnected) }},
+	{"last_used", "Last Used", func(r runner.RunnerInstance) string { return formatOptionalTime(r.LastUsed) }},
+	{"ip", "IP", func(r runner.RunnerInstance) string { return r.IP }},
+	{"version", "Version", func(r runner.RunnerInstance) string { return r.Version }},
+	{"type", "Type", func(r runner.RunnerInstance) string { return r.Type }},
+}
+
+func instanceFieldNames() []string {
+	names := make([]string, len(instanceFields))
+	for i, f := range instanceFields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// selectInstanceFields returns the fields with the given names, in the order
+// given, or all fields if there are none.
+func selectInstanceFields(names []string) ([]instanceField, error) {
+	if len(names) == 0 {
+		return instanceFields, nil
+	}
+
+	fields := make([]instanceField, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, f := range instanceFields {
+			if f.name == name {
+				fields = append(fields, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(instanceFieldNames(), ", "))
+		}
+	}
+	return fields, nil
+}
+
+// readFieldsFile reads a newline separated list of field names. Blank lines
+// and lines starting with # are ignored.
+func readFieldsFile(path string) ([]string, error) {
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+// instanceColumns resolves the --columns and --fields-from-file flags,
+// with --columns taking precedence when both are given.
+func instanceColumns(columns []string, fieldsFile string) ([]instanceField, error) {
+	if len(columns) > 0 || fieldsFile == "" {
+		return selectInstanceFields(columns)
+	}
+
+	names, err := readFieldsFile(fieldsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fields file: %s", err)
+	}
+
+	fields, err := selectInstanceFields(names)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fieldsFile, err)
+	}
+	return fields, nil
+}
@@ -1,13 +1,157 @@
 package runner
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
 
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"github.com/CircleCI-Public/circleci-cli/api/runner"
 )
 
+// Placeholders in the launch-agent config printed by generateAgentConfig, to
+// be replaced for each machine the agent runs on.
+const (
+	agentNamePlaceholder = "REPLACE-WITH-A-UNIQUE-RUNNER-NAME"
+	// The launch agent replaces %s with the ID of each task.
+	agentWorkingDirectory = "/var/opt/circleci/workdir/%s"
+)
+
+// agentWorkingDirectories are working directories that the launch-agent can
+// use out of the box on each platform it supports.
+var agentWorkingDirectories = map[string]string{
+	"linux":   agentWorkingDirectory,
+	"darwin":  "/var/tmp/circleci/workdir/%s",
+	"windows": `C:\ProgramData\CircleCI\workdir\%s`,
+}
+
+func newConfigCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Generate launch-agent configuration",
+	}
+
+	var resourceClass, tokenFile, name, platform, apiURL string
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Print a launch-agent-config.yaml for an existing token",
+		Long: `Print a launch-agent-config.yaml for an existing token.
+
+The config uses a working directory that suits the platform, and is checked
+before it is printed. The token is read from --token-file, or from stdin if it is -.`,
+		Example: `  circleci runner config generate --resource-class my-namespace/my-resource-class --token-file token.txt > launch-agent-config.yaml
+  circleci runner config generate --resource-class my-namespace/my-resource-class --token-file token.txt --platform windows --name build-win-1`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			workDir, ok := agentWorkingDirectories[platform]
+			if !ok {
+				return fmt.Errorf("unsupported platform %q, expected one of %s", platform, strings.Join(agentPlatforms(), ", "))
+			}
+
+			token, err := readTokenFile(tokenFile, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				if name, err = os.Hostname(); err != nil {
+					return fmt.Errorf("failed to name the runner after this machine, set --name: %w", err)
+				}
+			}
+
+			config := agentConfig{
+				API: apiConfig{
+					AuthToken: token,
+					URL:       apiURL,
+				},
+				Runner: &agentRunnerConfig{
+					Name:                    name,
+					WorkingDirectory:        workDir,
+					CleanupWorkingDirectory: true,
+				},
+			}
+			if err = validateAgentConfig(resourceClass, config); err != nil {
+				return err
+			}
+
+			if _, err = fmt.Fprintf(cmd.OutOrStdout(), "# launch-agent-config.yaml for resource-class %s on %s.\n", resourceClass, platform); err != nil {
+				return err
+			}
+			return yaml.NewEncoder(cmd.OutOrStdout()).Encode(&config)
+		},
+	}
+	generateCmd.PersistentFlags().StringVar(&resourceClass, "resource-class", "", "Resource-class the token belongs to")
+	generateCmd.PersistentFlags().StringVar(&tokenFile, "token-file", "", "File holding the token, or - for stdin")
+	generateCmd.PersistentFlags().StringVar(&name, "name", "", "Name of the runner, unique to the machine it runs on (default the hostname)")
+	generateCmd.PersistentFlags().StringVar(&platform, "platform", runtime.GOOS,
+		"Platform the agent runs on, one of "+strings.Join(agentPlatforms(), ", "))
+	generateCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "URL of the runner API, for CircleCI server installs")
+	_ = generateCmd.MarkPersistentFlagRequired("resource-class")
+	_ = generateCmd.MarkPersistentFlagRequired("token-file")
+	cmd.AddCommand(generateCmd)
+
+	return cmd
+}
+
+func agentPlatforms() []string {
+	platforms := make([]string, 0, len(agentWorkingDirectories))
+	for p := range agentWorkingDirectories {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// readTokenFile reads a token saved in a file, or from stdin if path is -.
+func readTokenFile(path string, stdin io.Reader) (string, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(stdin)
+	} else {
+		b, err = ioutil.ReadFile(path) // #nosec
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// validateAgentConfig checks a generated config for the mistakes that would
+// stop the agent from starting or connecting.
+func validateAgentConfig(resourceClass string, c agentConfig) error {
+	if parts := strings.Split(resourceClass, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid resource-class %q, expected namespace/name", resourceClass)
+	}
+	if c.API.AuthToken == "" {
+		return errors.New("the token is empty")
+	}
+	if strings.ContainsAny(c.API.AuthToken, " \t\r\n") {
+		return errors.New("the token contains whitespace, the token file should hold only the token")
+	}
+	if c.API.URL != "" {
+		u, err := url.Parse(c.API.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid API URL %q, expected an http or https URL", c.API.URL)
+		}
+	}
+	if strings.TrimSpace(c.Runner.Name) == "" {
+		return errors.New("the runner name is empty")
+	}
+	if !strings.Contains(c.Runner.WorkingDirectory, "%s") {
+		return fmt.Errorf("working directory %q must contain %%s, which is replaced with the ID of each task", c.Runner.WorkingDirectory)
+	}
+	return nil
+}
+
 func generateConfig(t runner.Token, w io.Writer) (err error) {
 	return yaml.NewEncoder(w).Encode(&agentConfig{
 		API: apiConfig{
@@ -16,10 +160,61 @@ func generateConfig(t runner.Token, w io.Writer) (err error) {
 	})
 }
 
+// generateAgentConfig writes a complete launch-agent-config.yaml for the
+// token, with placeholders for the settings that differ between machines.
+func generateAgentConfig(t runner.Token, w io.Writer) (err error) {
+	_, err = fmt.Fprintf(w, `# launch-agent-config.yaml for resource-class %s, using token %s (%s).
+# Replace runner.name with a name unique to this machine.
+`, t.ResourceClass, t.Nickname, t.ID)
+	if err != nil {
+		return err
+	}
+
+	return yaml.NewEncoder(w).Encode(&agentConfig{
+		API: apiConfig{
+			AuthToken: t.Token,
+		},
+		Runner: &agentRunnerConfig{
+			Name:                    agentNamePlaceholder,
+			WorkingDirectory:        agentWorkingDirectory,
+			CleanupWorkingDirectory: true,
+		},
+	})
+}
+
+// writeInstalledAgentConfig writes the launch-agent-config.yaml of an agent
+// installed on this machine, under the given name and working directory.
+func writeInstalledAgentConfig(t runner.Token, name, workDir string, w io.Writer) (err error) {
+	_, err = fmt.Fprintf(w, "# launch-agent-config.yaml for resource-class %s, using token %s (%s).\n", t.ResourceClass, t.Nickname, t.ID)
+	if err != nil {
+		return err
+	}
+
+	return yaml.NewEncoder(w).Encode(&agentConfig{
+		API: apiConfig{
+			AuthToken: t.Token,
+		},
+		Runner: &agentRunnerConfig{
+			Name:                    name,
+			WorkingDirectory:        workDir,
+			CleanupWorkingDirectory: true,
+		},
+	})
+}
+
 type agentConfig struct {
-	API apiConfig `yaml:"api"`
+	API    apiConfig          `yaml:"api"`
+	Runner *agentRunnerConfig `yaml:"runner,omitempty"`
 }
 
 type apiConfig struct {
 	AuthToken string `yaml:"auth_token"`
+	// URL is the runner API to connect to, for CircleCI server installs.
+	URL string `yaml:"url,omitempty"`
+}
+
+type agentRunnerConfig struct {
+	Name                    string `yaml:"name"`
+	WorkingDirectory        string `yaml:"working_directory"`
+	CleanupWorkingDirectory bool   `yaml:"cleanup_working_directory"`
 }
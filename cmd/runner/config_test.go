@@ -2,10 +2,16 @@ package runner
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"gopkg.in/yaml.v3"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 
 	"github.com/CircleCI-Public/circleci-cli/api/runner"
@@ -25,3 +31,111 @@ func Test_generateConfig(t *testing.T) {
 	assert.NilError(t, err)
 	golden.Assert(t, b.String(), "expected-config.yaml")
 }
+
+func Test_generateAgentConfig(t *testing.T) {
+	token := runner.Token{
+		ID:            "da73786c-ebbc-4c07-849a-5590f7eef509",
+		Token:         "1a34e5519976717fb808ad8900cadbecc686facee3f9ca56c5ba1ad30e50cab7e5fa328409065c64",
+		ResourceClass: "the-namespace/the-resource-class",
+		Nickname:      "the-nickname",
+		CreatedAt:     time.Date(2020, 03, 04, 16, 13, 53, 00, time.UTC),
+	}
+
+	b := bytes.Buffer{}
+	err := generateAgentConfig(token, &b)
+	assert.NilError(t, err)
+	golden.Assert(t, b.String(), "expected-agent-config.yaml")
+}
+
+func Test_ConfigGenerate(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token.txt")
+	assert.NilError(t, ioutil.WriteFile(tokenFile, []byte("the-token\n"), 0600))
+
+	run := func(stdin string, args ...string) (string, error) {
+		cmd := newConfigCommand(&runnerOpts{r: &runnerMock{}}, nil)
+		stdout := new(bytes.Buffer)
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(stdout)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(append([]string{"generate"}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	for _, platform := range []string{"linux", "darwin", "windows"} {
+		platform := platform
+		t.Run(platform, func(t *testing.T) {
+			out, err := run("",
+				"--resource-class", "the-namespace/the-resource-class",
+				"--token-file", tokenFile,
+				"--name", "the-runner",
+				"--platform", platform,
+			)
+			assert.NilError(t, err)
+			golden.Assert(t, out, fmt.Sprintf("expected-generated-config-%s.yaml", platform))
+
+			var config agentConfig
+			assert.NilError(t, yaml.Unmarshal([]byte(out), &config))
+			assert.Check(t, cmp.Equal(config.API.AuthToken, "the-token"))
+		})
+	}
+
+	t.Run("token from stdin with API URL", func(t *testing.T) {
+		out, err := run("the-token",
+			"--resource-class", "the-namespace/the-resource-class",
+			"--token-file", "-",
+			"--name", "the-runner",
+			"--platform", "linux",
+			"--api-url", "https://runner.circleci.example.com",
+		)
+		assert.NilError(t, err)
+
+		var config agentConfig
+		assert.NilError(t, yaml.Unmarshal([]byte(out), &config))
+		assert.Check(t, cmp.Equal(config.API.AuthToken, "the-token"))
+		assert.Check(t, cmp.Equal(config.API.URL, "https://runner.circleci.example.com"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			stdin   string
+			args    []string
+			wantErr string
+		}{
+			{
+				name:    "resource-class without namespace",
+				args:    []string{"--resource-class", "the-resource-class", "--token-file", tokenFile},
+				wantErr: `invalid resource-class "the-resource-class", expected namespace/name`,
+			},
+			{
+				name:    "empty token",
+				stdin:   "\n",
+				args:    []string{"--resource-class", "the-namespace/the-resource-class", "--token-file", "-"},
+				wantErr: "the token is empty",
+			},
+			{
+				name:    "token with whitespace",
+				stdin:   "the token",
+				args:    []string{"--resource-class", "the-namespace/the-resource-class", "--token-file", "-"},
+				wantErr: "the token contains whitespace, the token file should hold only the token",
+			},
+			{
+				name:    "unknown platform",
+				args:    []string{"--resource-class", "the-namespace/the-resource-class", "--token-file", tokenFile, "--platform", "plan9"},
+				wantErr: `unsupported platform "plan9", expected one of darwin, linux, windows`,
+			},
+			{
+				name:    "API URL without scheme",
+				args:    []string{"--resource-class", "the-namespace/the-resource-class", "--token-file", tokenFile, "--api-url", "runner.example.com"},
+				wantErr: `invalid API URL "runner.example.com", expected an http or https URL`,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := run(tt.stdin, append(tt.args, "--name", "the-runner")...)
+				assert.Check(t, cmp.Error(err, tt.wantErr))
+			})
+		}
+	})
+}
@@ -0,0 +1,275 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultRunnerAPIURL is the runner API the launch-agent connects to, unless
+// its config sets api.url.
+const defaultRunnerAPIURL = "https://runner.circleci.com"
+
+// agentTokenCheckPath is requested with the agent's token to find out whether
+// the runner API accepts it.
+const agentTokenCheckPath = "/api/v2/runner/tasks/running"
+
+const (
+	// maxClockSkew is how far the clock can be from the runner API's before
+	// the agent's requests are likely to be rejected.
+	maxClockSkew = time.Minute
+	// minFreeDisk is the least free space to leave for task working directories.
+	minFreeDisk = 2 << 30
+)
+
+// These are overridden in tests.
+var (
+	diskFree = freeDiskSpace
+	lookPath = exec.LookPath
+	// checkContainerEngine checks that a container engine is running.
+	checkContainerEngine = func(path string) error {
+		out, err := exec.Command(path, "info").CombinedOutput() // #nosec
+		if err != nil {
+			return fmt.Errorf("%s info failed: %w: %s", filepath.Base(path), err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+)
+
+// doctorCheck is the outcome of one of the checks made by runner doctor.
+type doctorCheck struct {
+	Name string `json:"name"`
+	// Result is one of pass, warn or fail.
+	Result string `json:"result"`
+	Detail string `json:"detail"`
+}
+
+func newDoctorCommand(o *runnerOpts) *cobra.Command {
+	var configPath, output string
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that this machine can run a launch-agent",
+		Long: `Check that this machine can run a launch-agent.
+
+Checks the launch-agent config, that the runner API can be reached and accepts
+the agent's token, the clock, free disk space for task working directories and,
+for container runners, the container engine. Exits with an error if any check fails.`,
+		Example: `  circleci runner doctor
+  circleci runner doctor --config /etc/circleci-runner/launch-agent-config.yaml --output json`,
+		Args: cobra.NoArgs,
+		// The checks use the agent's config rather than the CLI's, so they can
+		// be run on a runner host without a CLI token.
+		PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json", output)
+			}
+
+			checks := runDoctor(o.httpClient, configPath)
+			if output == "json" {
+				if err := writeJSON(cmd.OutOrStdout(), checks); err != nil {
+					return err
+				}
+			} else {
+				table := tablewriter.NewWriter(cmd.OutOrStdout())
+				table.SetHeader([]string{"Check", "Result", "Detail"})
+				table.SetAutoWrapText(false)
+				for _, c := range checks {
+					table.Append([]string{c.Name, strings.ToUpper(c.Result), c.Detail})
+				}
+				table.Render()
+			}
+
+			failed := 0
+			for _, c := range checks {
+				if c.Result == "fail" {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&configPath, "config", filepath.Join(defaultAgentInstallDir(), "launch-agent-config.yaml"),
+		"Path to the launch-agent config")
+	cmd.PersistentFlags().StringVar(&output, "output", "table", "Output format, one of table or json")
+	return cmd
+}
+
+// runDoctor makes every check in turn. Checks that need something an earlier
+// check couldn't find are skipped with a warning.
+func runDoctor(client *http.Client, configPath string) []doctorCheck {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	config, err := readAgentConfig(configPath)
+	if err != nil {
+		// Without a config, the agent's API URL and token aren't known.
+		return append([]doctorCheck{
+			{Name: "config", Result: "fail", Detail: err.Error()},
+			{Name: "api", Result: "warn", Detail: "not checked, as the config couldn't be read"},
+			{Name: "token", Result: "warn", Detail: "not checked, as the config couldn't be read"},
+			{Name: "clock", Result: "warn", Detail: "not checked, as the config couldn't be read"},
+		}, checkDisk(agentConfig{}), checkContainer())
+	}
+
+	checks := []doctorCheck{{Name: "config", Result: "pass", Detail: fmt.Sprintf("read %s", configPath)}}
+	if config.API.AuthToken == "" {
+		checks[0] = doctorCheck{Name: "config", Result: "fail", Detail: fmt.Sprintf("%s has no api.auth_token", configPath)}
+	}
+	checks = append(checks, checkRunnerAPI(client, config)...)
+	return append(checks, checkDisk(config), checkContainer())
+}
+
+func readAgentConfig(path string) (agentConfig, error) {
+	var config agentConfig
+	b, err := ioutil.ReadFile(path) // #nosec
+	if err == nil {
+		err = yaml.Unmarshal(b, &config)
+	}
+	if err != nil {
+		return config, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// checkRunnerAPI checks that the runner API can be reached, then that it
+// accepts the agent's token and agrees with the local clock.
+func checkRunnerAPI(client *http.Client, config agentConfig) []doctorCheck {
+	apiURL := strings.TrimSuffix(config.API.URL, "/")
+	if apiURL == "" {
+		apiURL = defaultRunnerAPIURL
+	}
+
+	started := time.Now()
+	resp, err := client.Get(apiURL)
+	if err != nil {
+		return []doctorCheck{
+			{Name: "api", Result: "fail", Detail: fmt.Sprintf("couldn't reach %s: %s", apiURL, err)},
+			{Name: "token", Result: "warn", Detail: "not checked, as the runner API couldn't be reached"},
+			{Name: "clock", Result: "warn", Detail: "not checked, as the runner API couldn't be reached"},
+		}
+	}
+	_ = resp.Body.Close()
+
+	return []doctorCheck{
+		{Name: "api", Result: "pass", Detail: fmt.Sprintf("reached %s in %s", apiURL, time.Since(started).Round(time.Millisecond))},
+		checkAgentToken(client, apiURL, config.API.AuthToken),
+		checkClock(resp.Header.Get("Date"), timeNow()),
+	}
+}
+
+func checkAgentToken(client *http.Client, apiURL, token string) doctorCheck {
+	check := doctorCheck{Name: "token"}
+	if token == "" {
+		check.Result, check.Detail = "fail", "no token to check"
+		return check
+	}
+
+	req, err := http.NewRequest("GET", apiURL+agentTokenCheckPath, nil)
+	if err != nil {
+		check.Result, check.Detail = "fail", err.Error()
+		return check
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Result, check.Detail = "fail", fmt.Sprintf("couldn't check the token: %s", err)
+		return check
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		check.Result, check.Detail = "fail", fmt.Sprintf("the runner API rejected the token (HTTP %d), create a new one with `circleci runner token create`", resp.StatusCode)
+		return check
+	}
+	check.Result, check.Detail = "pass", "the runner API accepted the token"
+	return check
+}
+
+// checkClock compares now with the Date header of a runner API response.
+func checkClock(date string, now time.Time) doctorCheck {
+	check := doctorCheck{Name: "clock"}
+	server, err := http.ParseTime(date)
+	if err != nil {
+		check.Result, check.Detail = "warn", "not checked, as the runner API didn't send the time"
+		return check
+	}
+
+	skew := now.Sub(server).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		check.Result, check.Detail = "fail", fmt.Sprintf("the clock is %s off the runner API's, check that NTP is running", skew)
+		return check
+	}
+	check.Result, check.Detail = "pass", fmt.Sprintf("the clock is within %s of the runner API's", maxClockSkew)
+	return check
+}
+
+// checkDisk checks the free space where task working directories are created.
+func checkDisk(config agentConfig) doctorCheck {
+	check := doctorCheck{Name: "disk"}
+	dir := os.TempDir()
+	if config.Runner != nil && config.Runner.WorkingDirectory != "" {
+		dir = strings.SplitN(config.Runner.WorkingDirectory, "%s", 2)[0]
+	}
+	// The working directory is only created when a task runs, so measure the
+	// closest directory that exists.
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := diskFree(dir)
+	if err != nil {
+		check.Result, check.Detail = "warn", fmt.Sprintf("couldn't measure free space in %s: %s", dir, err)
+		return check
+	}
+	if free < minFreeDisk {
+		check.Result, check.Detail = "fail", fmt.Sprintf("only %s free in %s, at least %s is needed", formatBytes(free), dir, formatBytes(minFreeDisk))
+		return check
+	}
+	check.Result, check.Detail = "pass", fmt.Sprintf("%s free in %s", formatBytes(free), dir)
+	return check
+}
+
+// checkContainer checks for a running container engine, which only container
+// runners need.
+func checkContainer() doctorCheck {
+	check := doctorCheck{Name: "container"}
+	for _, engine := range []string{"docker", "podman"} {
+		path, err := lookPath(engine)
+		if err != nil {
+			continue
+		}
+		if err = checkContainerEngine(path); err != nil {
+			check.Result, check.Detail = "fail", err.Error()
+			return check
+		}
+		check.Result, check.Detail = "pass", fmt.Sprintf("%s is running", engine)
+		return check
+	}
+	check.Result, check.Detail = "warn", "neither docker nor podman was found, which only container runners need"
+	return check
+}
+
+func formatBytes(n uint64) string {
+	const gib = 1 << 30
+	if n >= gib {
+		return fmt.Sprintf("%.1f GiB", float64(n)/gib)
+	}
+	return fmt.Sprintf("%d MiB", n>>20)
+}
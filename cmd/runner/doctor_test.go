@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func Test_RunnerDoctor(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	defer func(f func(string) (uint64, error)) { diskFree = f }(diskFree)
+	defer func(f func(string) (string, error)) { lookPath = f }(lookPath)
+	defer func(f func(string) error) { checkContainerEngine = f }(checkContainerEngine)
+
+	serverTime := now
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+		if r.URL.Path == agentTokenCheckPath && r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	writeConfig := func(t *testing.T, token string) string {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "launch-agent-config.yaml")
+		config := fmt.Sprintf("api:\n  auth_token: %s\n  url: %s\nrunner:\n  name: my-runner\n  working_directory: %s\n",
+			token, server.URL, filepath.Join(dir, "workdir", "%s"))
+		assert.NilError(t, ioutil.WriteFile(path, []byte(config), 0600))
+		return path
+	}
+
+	run := func(t *testing.T, configPath string) (map[string]doctorCheck, error) {
+		cmd := newDoctorCommand(&runnerOpts{httpClient: server.Client()})
+		stdout := new(bytes.Buffer)
+		cmd.SetOut(stdout)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs([]string{"--config", configPath, "--output", "json"})
+		err := cmd.Execute()
+
+		var checks []doctorCheck
+		// A failed check also prints the error and usage after the JSON.
+		assert.NilError(t, json.NewDecoder(stdout).Decode(&checks))
+		byName := map[string]doctorCheck{}
+		for _, c := range checks {
+			byName[c.Name] = c
+		}
+		return byName, err
+	}
+
+	healthy := func() {
+		serverTime = now
+		diskFree = func(string) (uint64, error) { return 10 << 30, nil }
+		lookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+		checkContainerEngine = func(string) error { return nil }
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		healthy()
+
+		checks, err := run(t, writeConfig(t, "good-token"))
+		assert.NilError(t, err)
+		for _, name := range []string{"config", "api", "token", "clock", "disk", "container"} {
+			assert.Check(t, cmp.Equal(checks[name].Result, "pass"), name)
+		}
+		assert.Check(t, cmp.Contains(checks["disk"].Detail, "10.0 GiB free in "))
+		assert.Check(t, cmp.Equal(checks["container"].Detail, "docker is running"))
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		healthy()
+		serverTime = now.Add(-5 * time.Minute)
+		diskFree = func(string) (uint64, error) { return 100 << 20, nil }
+		checkContainerEngine = func(string) error { return errors.New("docker info failed: daemon not running") }
+
+		checks, err := run(t, writeConfig(t, "bad-token"))
+		assert.Error(t, err, "4 check(s) failed")
+		assert.Check(t, cmp.Equal(checks["token"].Result, "fail"))
+		assert.Check(t, cmp.Contains(checks["token"].Detail, "HTTP 401"))
+		assert.Check(t, cmp.Equal(checks["clock"].Result, "fail"))
+		assert.Check(t, cmp.Contains(checks["clock"].Detail, "5m0s off"))
+		assert.Check(t, cmp.Equal(checks["disk"].Result, "fail"))
+		assert.Check(t, cmp.Contains(checks["disk"].Detail, "only 100 MiB free"))
+		assert.Check(t, cmp.Equal(checks["container"].Result, "fail"))
+	})
+
+	t.Run("missing config and no container engine", func(t *testing.T) {
+		healthy()
+		lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+		checks, err := run(t, filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err, "1 check(s) failed")
+		assert.Check(t, cmp.Equal(checks["config"].Result, "fail"))
+		assert.Check(t, cmp.Equal(checks["api"].Result, "warn"))
+		assert.Check(t, cmp.Equal(checks["container"].Result, "warn"))
+	})
+}
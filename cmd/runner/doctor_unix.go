@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import "syscall"
+
+// freeDiskSpace is how many bytes are free for an unprivileged user on the
+// filesystem holding path.
+func freeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
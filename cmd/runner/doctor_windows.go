@@ -0,0 +1,7 @@
+package runner
+
+import "errors"
+
+func freeDiskSpace(string) (uint64, error) {
+	return 0, errors.New("not supported on Windows")
+}
@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// errEnvOutputList is returned when `--output env` is requested for a command
+// that can return more than one result, as the variables would collide.
+var errEnvOutputList = errors.New("output format env is only supported for commands returning a single result")
+
+// envVar is a single KEY=value line of `--output env`.
+type envVar struct {
+	key   string
+	value string
+}
+
+// writeEnv prints vars as KEY=value lines suitable for `eval` or sourcing from a shell.
+func writeEnv(w io.Writer, vars []envVar) error {
+	for _, v := range vars {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.key, shellQuote(v.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote single quotes s if it contains anything the shell would interpret.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
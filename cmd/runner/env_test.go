@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_EnvOutput(t *testing.T) {
+	t.Run("token create", func(t *testing.T) {
+		mock := runnerMock{}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newTokenCommand, "create", "my-namespace/my-resource-class", "it's my $token", "--output", "env")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, `CIRCLECI_RUNNER_TOKEN=fake-token
+CIRCLECI_RUNNER_TOKEN_ID=987905d7-6780-4fed-a637-37277c373629
+CIRCLECI_RUNNER_TOKEN_NICKNAME='it'\''s my $token'
+CIRCLECI_RESOURCE_CLASS=my-namespace/my-resource-class
+`))
+	})
+
+	t.Run("resource-class describe", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class", Description: "Build \"fast\" `now`\nsecond line", Type: "machine"},
+		}}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newResourceClassCommand, "describe", "my-namespace/my-resource-class", "--output", "env")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, "CIRCLECI_RESOURCE_CLASS=my-namespace/my-resource-class\n"+
+			"CIRCLECI_RESOURCE_CLASS_ID=d8bc155b-5e91-4765-b327-0fa256f0229e\n"+
+			"CIRCLECI_RESOURCE_CLASS_DESCRIPTION='Build \"fast\" `now`\nsecond line'\n"+
+			"CIRCLECI_RESOURCE_CLASS_TYPE=machine\n"))
+	})
+
+	t.Run("list is rejected", func(t *testing.T) {
+		mock := runnerMock{}
+		_, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--output", "env")
+		assert.Error(t, err, "output format env is only supported for commands returning a single result")
+	})
+}
+
+func Test_shellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain-value_1.0", want: "plain-value_1.0"},
+		{in: "", want: "''"},
+		{in: "two words", want: "'two words'"},
+		{in: "it's", want: `'it'\''s'`},
+		{in: "$HOME;rm", want: "'$HOME;rm'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Check(t, cmp.Equal(shellQuote(tt.in), tt.want))
+		})
+	}
+}
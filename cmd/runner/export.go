@@ -0,0 +1,262 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// resourceClassExportVersion is the version of the export format written by
+// resource-class export. Import refuses anything else, rather than guess.
+const resourceClassExportVersion = 1
+
+// resourceClassExport is what resource-class export writes and import reads.
+// Resource-classes are named without their namespace, so they can be imported
+// into another one.
+type resourceClassExport struct {
+	Version         int                   `json:"version" yaml:"version"`
+	Namespace       string                `json:"namespace" yaml:"namespace"`
+	ResourceClasses []exportResourceClass `json:"resource_classes" yaml:"resource_classes"`
+}
+
+type exportResourceClass struct {
+	Name                   string            `json:"name" yaml:"name"`
+	Description            string            `json:"description" yaml:"description"`
+	DefaultTokenTTLSeconds int64             `json:"default_token_ttl_seconds,omitempty" yaml:"default_token_ttl_seconds,omitempty"`
+	Labels                 map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// Tokens are the nicknames of the resource-class's tokens. Their secrets
+	// can't be read back, so import can only mint new tokens with these names.
+	Tokens []string `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+}
+
+// importedResourceClass is what resource-class import writes for each
+// resource-class, with any tokens minted for it.
+type importedResourceClass struct {
+	ResourceClass *runner.ResourceClass `json:"resource_class"`
+	Created       bool                  `json:"created"`
+	Tokens        []runner.Token        `json:"tokens,omitempty"`
+}
+
+func newResourceClassExportCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	var namespace, output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the resource-classes of a namespace",
+		Long: `Export the resource-classes of a namespace, to be recreated elsewhere with import.
+
+The export holds each resource-class's description, default token TTL, labels
+and the nicknames of its tokens. Token secrets can't be exported.`,
+		Example: `  circleci runner resource-class export --namespace my-namespace > resource-classes.yaml`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "yaml" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, expected one of yaml, json", output)
+			}
+
+			export := resourceClassExport{
+				Version:         resourceClassExportVersion,
+				Namespace:       namespace,
+				ResourceClasses: []exportResourceClass{},
+			}
+			err := o.withTimeout("export resource-classes", o.timeout(bulkTimeout, 0), func(r running) error {
+				rcs, err := r.GetResourceClassesByNamespace(namespace)
+				if err != nil {
+					return err
+				}
+				for _, rc := range rcs {
+					tokens, err := r.GetRunnerTokensByResourceClass(rc.ResourceClass)
+					if err != nil {
+						return err
+					}
+					e := exportResourceClass{
+						Name:                   strings.TrimPrefix(rc.ResourceClass, namespace+"/"),
+						Description:            rc.Description,
+						DefaultTokenTTLSeconds: rc.DefaultTokenTTLSeconds,
+						Labels:                 rc.Labels,
+					}
+					for _, t := range tokens {
+						e.Tokens = append(e.Tokens, t.Nickname)
+					}
+					export.ResourceClasses = append(export.ResourceClasses, e)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return writeStructured(cmd.OutOrStdout(), output, export)
+		},
+	}
+	cmd.PersistentFlags().StringVar(&namespace, "namespace", "", "Namespace to export the resource-classes of")
+	cmd.PersistentFlags().StringVar(&output, "output", "yaml", "Output format, one of yaml or json")
+	_ = cmd.MarkPersistentFlagRequired("namespace")
+	return cmd
+}
+
+func newResourceClassImportCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	var file, namespace, output string
+	var generateTokens bool
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Recreate exported resource-classes",
+		Long: `Recreate resource-classes written by export, in the same or another namespace.
+
+Resource-classes that already exist are left as they are. With --generate-tokens,
+a token is created for every token nickname in the export that the
+resource-class doesn't have yet, and printed: they are new tokens, so each
+runner's launch-agent config has to be updated.
+Use the global --host and --token flags to import into another server install.`,
+		Example: `  circleci runner resource-class import --file resource-classes.yaml --namespace new-namespace
+  circleci runner resource-class import --file - --generate-tokens < resource-classes.yaml > tokens.yaml`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "yaml" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, expected one of yaml, json", output)
+			}
+
+			export, err := readResourceClassExport(file, namespace, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			imported := []importedResourceClass{}
+			err = o.withTimeout("import resource-classes", o.timeout(bulkTimeout, 0), func(r running) error {
+				for _, e := range export.ResourceClasses {
+					i, err := importResourceClass(r, export.Namespace+"/"+e.Name, e, generateTokens, cmd.ErrOrStderr())
+					if i.ResourceClass != nil {
+						imported = append(imported, i)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+			// Write out what was imported even if a later resource-class failed,
+			// so no token that was minted is lost.
+			if werr := writeStructured(cmd.OutOrStdout(), output, imported); werr != nil {
+				return werr
+			}
+			return err
+		},
+	}
+	cmd.PersistentFlags().StringVar(&file, "file", "", "File written by export, or - for stdin")
+	cmd.PersistentFlags().StringVar(&namespace, "namespace", "", "Namespace to create the resource-classes in (default the exported namespace)")
+	cmd.PersistentFlags().BoolVar(&generateTokens, "generate-tokens", false,
+		"Create a token for every exported token nickname")
+	cmd.PersistentFlags().StringVar(&output, "output", "yaml", "Output format, one of yaml or json")
+	_ = cmd.MarkPersistentFlagRequired("file")
+	return cmd
+}
+
+// readResourceClassExport reads and checks an export, from stdin if path is -.
+// The export's namespace is replaced by namespace unless it is empty.
+func readResourceClassExport(path, namespace string, stdin io.Reader) (*resourceClassExport, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(stdin)
+	} else {
+		b, err = ioutil.ReadFile(path) // #nosec
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	export := &resourceClassExport{}
+	if err = yaml.Unmarshal(b, export); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+	if export.Version != resourceClassExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d, expected %d", export.Version, resourceClassExportVersion)
+	}
+	if namespace != "" {
+		export.Namespace = namespace
+	}
+	if export.Namespace == "" {
+		return nil, errors.New("export has no namespace, set one with --namespace")
+	}
+	for _, e := range export.ResourceClasses {
+		if e.Name == "" || strings.Contains(e.Name, "/") {
+			return nil, fmt.Errorf("invalid resource-class name %q in export, expected a name without a namespace", e.Name)
+		}
+	}
+	return export, nil
+}
+
+// importResourceClass creates a resource-class from an export unless it
+// exists already, then sets its default token TTL and mints its tokens if
+// asked to. Tokens whose nickname the resource-class already has are not minted
+// again, so an import can be repeated. The resource-class is returned along
+// with any error, once known.
+func importResourceClass(r running, name string, e exportResourceClass, generateTokens bool, stderr io.Writer) (importedResourceClass, error) {
+	var i importedResourceClass
+	rc, err := r.GetResourceClassByName(name)
+	switch {
+	case err == nil:
+		fmt.Fprintf(stderr, "Resource class %s already exists, not creating it\n", name)
+	case errors.Is(err, runner.ErrNotFound):
+		rc, err = r.CreateResourceClassWithOptions(name, e.Description, runner.ResourceClassOptions{Labels: e.Labels})
+		if errors.Is(err, runner.ErrResourceClassLabelsNotSupported) {
+			fmt.Fprintf(stderr, "Resource class %s was created without its labels, as %s\n", name, err)
+		} else if err != nil {
+			return i, fmt.Errorf("failed to create resource-class %s: %w", name, err)
+		}
+		i.Created = true
+		fmt.Fprintf(stderr, "Created resource-class %s\n", name)
+	default:
+		return i, err
+	}
+	i.ResourceClass = rc
+
+	if i.Created && e.DefaultTokenTTLSeconds > 0 {
+		ttl := time.Duration(e.DefaultTokenTTLSeconds) * time.Second
+		if err = r.SetResourceClassTokenTTL(rc.ID, ttl); err != nil {
+			return i, fmt.Errorf("failed to set the default token TTL of resource-class %s: %w", name, err)
+		}
+		rc.DefaultTokenTTLSeconds = e.DefaultTokenTTLSeconds
+	}
+
+	if !generateTokens {
+		return i, nil
+	}
+	existing := map[string]bool{}
+	if !i.Created {
+		tokens, err := r.GetRunnerTokensByResourceClass(name)
+		if err != nil {
+			return i, fmt.Errorf("failed to list the tokens of resource-class %s: %w", name, err)
+		}
+		for _, t := range tokens {
+			existing[t.Nickname] = true
+		}
+	}
+	var opts runner.TokenOptions
+	if rc.DefaultTokenTTLSeconds > 0 {
+		expiresAt := timeNow().Add(time.Duration(rc.DefaultTokenTTLSeconds) * time.Second).UTC()
+		opts.ExpiresAt = &expiresAt
+	}
+	for _, nickname := range e.Tokens {
+		if existing[nickname] {
+			fmt.Fprintf(stderr, "Token %s of resource-class %s already exists, not creating it\n", nickname, name)
+			continue
+		}
+		token, err := createToken(r, name, nickname, opts, stderr)
+		if err != nil {
+			return i, fmt.Errorf("failed to create token %s for resource-class %s: %w", nickname, name, err)
+		}
+		i.Tokens = append(i.Tokens, *token)
+	}
+	return i, nil
+}
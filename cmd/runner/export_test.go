@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_ResourceClassExportImport(t *testing.T) {
+	mock := &runnerMock{
+		resourceClasses: []runner.ResourceClass{
+			{ID: "id-a", ResourceClass: "old-namespace/rc-a", Description: "Linux hosts", DefaultTokenTTLSeconds: 604800},
+			{ID: "id-b", ResourceClass: "old-namespace/rc-b", Description: "macOS hosts", Labels: map[string]string{"team": "mobile"}},
+			{ID: "id-c", ResourceClass: "other-namespace/rc-c", Description: "Not exported"},
+		},
+		tokens: []runner.Token{
+			{ID: "token-1", ResourceClass: "old-namespace/rc-a", Nickname: "host-1"},
+			{ID: "token-2", ResourceClass: "old-namespace/rc-a", Nickname: "host-2"},
+		},
+	}
+
+	run := func(stdin string, args ...string) (string, string, error) {
+		cmd := newResourceClassCommand(&runnerOpts{r: mock}, nil)
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return stdout.String(), stderr.String(), err
+	}
+
+	export, _, err := run("", "export", "--namespace", "old-namespace")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(export, `version: 1
+namespace: old-namespace
+resource_classes:
+  - name: rc-a
+    description: Linux hosts
+    default_token_ttl_seconds: 604800
+    tokens:
+      - host-1
+      - host-2
+  - name: rc-b
+    description: macOS hosts
+    labels:
+      team: mobile
+`))
+
+	t.Run("into another namespace", func(t *testing.T) {
+		out, stderr, err := run(export, "import", "--file", "-", "--namespace", "new-namespace", "--generate-tokens", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stderr, "Created resource-class new-namespace/rc-a\nCreated resource-class new-namespace/rc-b\n"))
+
+		var imported []importedResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &imported))
+		assert.Assert(t, cmp.Len(imported, 2))
+		assert.Check(t, cmp.Equal(imported[0].ResourceClass.ResourceClass, "new-namespace/rc-a"))
+		assert.Check(t, cmp.Equal(imported[0].ResourceClass.DefaultTokenTTLSeconds, int64(604800)))
+		assert.Check(t, imported[0].Created)
+		assert.Assert(t, cmp.Len(imported[0].Tokens, 2))
+		assert.Check(t, cmp.Equal(imported[0].Tokens[0].Nickname, "host-1"))
+		assert.Check(t, cmp.Equal(imported[0].Tokens[0].Token, "fake-token"))
+		assert.Assert(t, imported[0].Tokens[0].ExpiresAt != nil)
+		assert.Check(t, cmp.Equal(time.Until(*imported[0].Tokens[0].ExpiresAt).Round(time.Hour), 168*time.Hour))
+		assert.Check(t, cmp.Equal(imported[1].ResourceClass.Description, "macOS hosts"))
+		assert.Check(t, cmp.DeepEqual(imported[1].ResourceClass.Labels, map[string]string{"team": "mobile"}))
+		assert.Check(t, cmp.Len(imported[1].Tokens, 0))
+	})
+
+	t.Run("existing resource-classes are left alone", func(t *testing.T) {
+		tokens := len(mock.tokens)
+		out, stderr, err := run(export, "import", "--file", "-", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stderr, "Resource class old-namespace/rc-a already exists, not creating it\n"))
+
+		var imported []importedResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &imported))
+		assert.Assert(t, cmp.Len(imported, 2))
+		assert.Check(t, !imported[0].Created)
+		assert.Check(t, cmp.Len(mock.tokens, tokens))
+	})
+
+	t.Run("existing tokens are not minted again", func(t *testing.T) {
+		tokens := len(mock.tokens)
+		out, stderr, err := run(export, "import", "--file", "-", "--namespace", "new-namespace", "--generate-tokens", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stderr, "Token host-1 of resource-class new-namespace/rc-a already exists, not creating it\n"))
+		assert.Check(t, cmp.Contains(stderr, "Token host-2 of resource-class new-namespace/rc-a already exists, not creating it\n"))
+
+		var imported []importedResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &imported))
+		assert.Assert(t, cmp.Len(imported, 2))
+		assert.Check(t, cmp.Len(imported[0].Tokens, 0))
+		assert.Check(t, cmp.Len(mock.tokens, tokens))
+	})
+
+	t.Run("no namespace", func(t *testing.T) {
+		_, _, err := run("version: 1\nresource_classes:\n  - name: rc\n", "import", "--file", "-")
+		assert.Error(t, err, "export has no namespace, set one with --namespace")
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, _, err := run("version: 2\nnamespace: old-namespace\n", "import", "--file", "-")
+		assert.Error(t, err, "unsupported export version 2, expected 1")
+	})
+
+	t.Run("name with a namespace", func(t *testing.T) {
+		_, _, err := run("version: 1\nnamespace: old-namespace\nresource_classes:\n  - name: ns/rc\n", "import", "--file", "-")
+		assert.Error(t, err, `invalid resource-class name "ns/rc" in export, expected a name without a namespace`)
+	})
+}
@@ -0,0 +1,332 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// launchAgentURL is where launch-agent releases are published.
+const launchAgentURL = "https://circleci-binary-releases.s3.amazonaws.com/circleci-launch-agent"
+
+// Where agents are registered as services. They are overridden in tests.
+var (
+	systemdUnitDir   = "/etc/systemd/system"
+	launchdDaemonDir = "/Library/LaunchDaemons"
+	// runServiceCommand runs a command that registers or starts a service.
+	runServiceCommand = func(name string, args ...string) error {
+		out, err := exec.Command(name, args...).CombinedOutput() // #nosec
+		if err != nil {
+			return fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, bytes.TrimSpace(out))
+		}
+		return nil
+	}
+)
+
+// agentInstall holds the flags of runner install.
+type agentInstall struct {
+	resourceClass string
+	description   string
+	name          string
+	installDir    string
+	service       string
+	agentURL      string
+	agentVersion  string
+}
+
+func newInstallCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	var opts agentInstall
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install a launch-agent on this machine",
+		Long: `Install a launch-agent on this machine.
+
+Downloads the launch-agent for this platform, creates the resource-class if it
+doesn't exist along with a token for this machine, and writes
+launch-agent-config.yaml next to the agent. An existing config is kept, along
+with its token. With --service, also registers the agent as a systemd or
+launchd service so that it starts on boot.`,
+		Example: `  sudo circleci runner install --resource-class my-namespace/my-resource-class
+  sudo circleci runner install --resource-class my-namespace/my-resource-class --service systemd`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return installAgent(o, opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.resourceClass, "resource-class", "", "Resource-class the agent runs tasks for, created if it doesn't exist")
+	cmd.PersistentFlags().StringVar(&opts.description, "description", "", "Description of the resource-class if it is created")
+	cmd.PersistentFlags().StringVar(&opts.name, "name", "", "Name of the runner, unique to this machine (default the hostname)")
+	cmd.PersistentFlags().StringVar(&opts.installDir, "install-dir", defaultAgentInstallDir(), "Directory to install the agent and its config in")
+	cmd.PersistentFlags().StringVar(&opts.service, "service", "", "Register the agent as a service, one of systemd or launchd")
+	cmd.PersistentFlags().StringVar(&opts.agentVersion, "agent-version", "", "Version of the launch-agent to install (default the latest)")
+	cmd.PersistentFlags().StringVar(&opts.agentURL, "agent-url", launchAgentURL, "Where launch-agent releases are downloaded from")
+	_ = cmd.MarkPersistentFlagRequired("resource-class")
+	return cmd
+}
+
+func defaultAgentInstallDir() string {
+	if runtime.GOOS == "windows" {
+		return `C:\Program Files\CircleCI`
+	}
+	return "/opt/circleci"
+}
+
+func agentBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "circleci-launch-agent.exe"
+	}
+	return "circleci-launch-agent"
+}
+
+func installAgent(o *runnerOpts, opts agentInstall, stdout, stderr io.Writer) error {
+	switch {
+	case opts.service == "":
+	case opts.service == "systemd" && runtime.GOOS == "linux":
+	case opts.service == "launchd" && runtime.GOOS == "darwin":
+	case opts.service == "systemd" || opts.service == "launchd":
+		return fmt.Errorf("%s services are not supported on %s", opts.service, runtime.GOOS)
+	default:
+		return fmt.Errorf("unsupported service %q, expected one of systemd, launchd", opts.service)
+	}
+
+	if opts.name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to name the runner after this machine, set --name: %w", err)
+		}
+		opts.name = hostname
+	}
+
+	if err := os.MkdirAll(opts.installDir, 0755); err != nil {
+		return err
+	}
+	agentPath := filepath.Join(opts.installDir, agentBinaryName())
+	configPath := filepath.Join(opts.installDir, "launch-agent-config.yaml")
+
+	client := o.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	version, err := downloadLaunchAgent(client, opts.agentURL, opts.agentVersion, agentPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Installed launch-agent %s to %s\n", version, agentPath)
+
+	if _, err = os.Stat(configPath); err == nil {
+		fmt.Fprintf(stderr, "Keeping the existing config %s\n", configPath)
+	} else if err = writeAgentInstallConfig(o, opts, configPath, stderr); err != nil {
+		return err
+	}
+
+	switch opts.service {
+	case "systemd":
+		err = registerSystemdService(agentPath, configPath)
+	case "launchd":
+		err = registerLaunchdService(agentPath, configPath)
+	default:
+		_, err = fmt.Fprintf(stdout, "Start the agent with:\n  %s --config %s\n", agentPath, configPath)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Registered the agent as a %s service, it is now running\n", opts.service)
+	return err
+}
+
+// writeAgentInstallConfig creates the resource-class if needed and a token
+// named after the runner, and writes the agent config using it.
+func writeAgentInstallConfig(o *runnerOpts, opts agentInstall, configPath string, stderr io.Writer) error {
+	fmt.Fprint(stderr, terms)
+
+	var token *runner.Token
+	err := o.withTimeout("create token", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+		_, err = r.GetResourceClassByName(opts.resourceClass)
+		if errors.Is(err, runner.ErrNotFound) {
+			fmt.Fprintf(stderr, "Creating resource-class %s\n", opts.resourceClass)
+			_, err = r.CreateResourceClass(opts.resourceClass, opts.description)
+		}
+		if err != nil {
+			return err
+		}
+
+		var tokenOpts runner.TokenOptions
+		if tokenOpts.ExpiresAt, err = defaultTokenExpiry(r, opts.resourceClass, timeNow()); err != nil {
+			return err
+		}
+		token, err = createToken(r, opts.resourceClass, opts.name, tokenOpts, stderr)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	workDir := filepath.Join(opts.installDir, "workdir", "%s")
+	if err = writeInstalledAgentConfig(*token, opts.name, workDir, buf); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(configPath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("created token %s but failed to write %s: %w", token.ID, configPath, err)
+	}
+	fmt.Fprintf(stderr, "Wrote %s using new token %s\n", configPath, token.ID)
+	return nil
+}
+
+// downloadLaunchAgent installs the launch-agent for this platform at dst,
+// after checking it against the release checksums. An empty version installs
+// the latest release. It returns the version installed.
+func downloadLaunchAgent(client *http.Client, baseURL, version, dst string) (string, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if version == "" {
+		release, err := fetchURL(client, baseURL+"/release.txt")
+		if err != nil {
+			return "", fmt.Errorf("failed to find the latest launch-agent: %w", err)
+		}
+		version = strings.TrimSpace(string(release))
+	}
+
+	checksums, err := fetchURL(client, baseURL+"/"+version+"/checksums.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to download launch-agent %s checksums: %w", version, err)
+	}
+	file := path.Join(runtime.GOOS, runtime.GOARCH, agentBinaryName())
+	want, ok := findChecksum(checksums, file)
+	if !ok {
+		return "", fmt.Errorf("launch-agent %s is not available for %s/%s", version, runtime.GOOS, runtime.GOARCH)
+	}
+
+	agent, err := fetchURL(client, baseURL+"/"+version+"/"+file)
+	if err != nil {
+		return "", fmt.Errorf("failed to download launch-agent %s: %w", version, err)
+	}
+	sum := sha256.Sum256(agent)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return "", fmt.Errorf("downloaded launch-agent %s has checksum %s, expected %s", version, got, want)
+	}
+
+	// Write next to the destination and rename, so a running agent is never
+	// left with a partly written binary.
+	tmp := dst + ".new"
+	if err = ioutil.WriteFile(tmp, agent, 0755); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return version, nil
+}
+
+// findChecksum looks up the SHA-256 of file in a checksums.txt, whose lines
+// are a checksum followed by a file name, optionally marked binary with *.
+func findChecksum(checksums []byte, file string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == file {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+func fetchURL(client *http.Client, u string) ([]byte, error) {
+	resp, err := client.Get(u) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+const systemdUnit = `[Unit]
+Description=CircleCI Runner
+After=network.target
+
+[Service]
+ExecStart=%s --config %s
+Restart=always
+User=root
+NotifyAccess=exec
+TimeoutStopSec=18300
+
+[Install]
+WantedBy=multi-user.target
+`
+
+func registerSystemdService(agentPath, configPath string) error {
+	unit := filepath.Join(systemdUnitDir, "circleci.service")
+	if err := ioutil.WriteFile(unit, []byte(fmt.Sprintf(systemdUnit, agentPath, configPath)), 0644); err != nil {
+		return err
+	}
+	if err := runServiceCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	return runServiceCommand("systemctl", "enable", "--now", "circleci.service")
+}
+
+const launchdPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+  <dict>
+    <key>Label</key>
+    <string>com.circleci.runner</string>
+    <key>Program</key>
+    <string>%[1]s</string>
+    <key>ProgramArguments</key>
+    <array>
+      <string>%[1]s</string>
+      <string>--config</string>
+      <string>%[2]s</string>
+    </array>
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <true/>
+    <key>ProcessType</key>
+    <string>Interactive</string>
+    <key>StandardOutPath</key>
+    <string>/Library/Logs/com.circleci.runner.log</string>
+    <key>StandardErrorPath</key>
+    <string>/Library/Logs/com.circleci.runner.log</string>
+  </dict>
+</plist>
+`
+
+func registerLaunchdService(agentPath, configPath string) error {
+	plist := filepath.Join(launchdDaemonDir, "com.circleci.runner.plist")
+	content := fmt.Sprintf(launchdPlist, xmlEscape(agentPath), xmlEscape(configPath))
+	if err := ioutil.WriteFile(plist, []byte(content), 0644); err != nil {
+		return err
+	}
+	return runServiceCommand("launchctl", "load", plist)
+}
+
+func xmlEscape(s string) string {
+	buf := &bytes.Buffer{}
+	_ = xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_RunnerInstall(t *testing.T) {
+	agent := []byte("launch-agent binary")
+	sum := sha256.Sum256(agent)
+	file := path.Join(runtime.GOOS, runtime.GOARCH, agentBinaryName())
+
+	checksum := hex.EncodeToString(sum[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release.txt":
+			fmt.Fprintln(w, "1.0.11-6a58b1b")
+		case "/1.0.11-6a58b1b/checksums.txt":
+			fmt.Fprintf(w, "%s *%s\n", checksum, file)
+		case "/1.0.11-6a58b1b/" + file:
+			_, _ = w.Write(agent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	run := func(mock *runnerMock, installDir string, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock, httpClient: server.Client()}, newInstallCommand, append([]string{
+			"--resource-class", "my-namespace/my-resource-class",
+			"--name", "my-runner",
+			"--install-dir", installDir,
+			"--agent-url", server.URL,
+		}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("fresh install", func(t *testing.T) {
+		mock := runnerMock{}
+		dir := t.TempDir()
+
+		out, err := run(&mock, dir)
+		assert.NilError(t, err)
+
+		installed, err := ioutil.ReadFile(filepath.Join(dir, agentBinaryName()))
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(installed, agent))
+
+		assert.Check(t, cmp.Len(mock.resourceClasses, 1))
+		assert.Assert(t, cmp.Len(mock.tokens, 1))
+		assert.Check(t, cmp.Equal(mock.tokens[0].Nickname, "my-runner"))
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "launch-agent-config.yaml"))
+		assert.NilError(t, err)
+		var config agentConfig
+		assert.NilError(t, yaml.Unmarshal(b, &config))
+		assert.Check(t, cmp.Equal(config.API.AuthToken, "fake-token"))
+		assert.Check(t, cmp.Equal(config.Runner.Name, "my-runner"))
+		assert.Check(t, cmp.Equal(config.Runner.WorkingDirectory, filepath.Join(dir, "workdir", "%s")))
+
+		assert.Check(t, cmp.Contains(out, "--config "+filepath.Join(dir, "launch-agent-config.yaml")))
+	})
+
+	t.Run("keeps an existing config", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{{ResourceClass: "my-namespace/my-resource-class"}}}
+		dir := t.TempDir()
+		config := filepath.Join(dir, "launch-agent-config.yaml")
+		assert.NilError(t, ioutil.WriteFile(config, []byte("api:\n  auth_token: existing\n"), 0600))
+
+		_, err := run(&mock, dir, "--agent-version", "1.0.11-6a58b1b")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(mock.tokens, 0))
+
+		b, err := ioutil.ReadFile(config)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(string(b), "existing"))
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		defer func(c string) { checksum = c }(checksum)
+		checksum = strings.Repeat("0", 64)
+		mock := runnerMock{}
+
+		_, err := run(&mock, t.TempDir())
+		assert.ErrorContains(t, err, "downloaded launch-agent 1.0.11-6a58b1b has checksum")
+		assert.Check(t, cmp.Len(mock.tokens, 0))
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := run(&runnerMock{}, t.TempDir(), "--agent-version", "0.0.1")
+		assert.ErrorContains(t, err, "failed to download launch-agent 0.0.1 checksums")
+	})
+
+	t.Run("unsupported service", func(t *testing.T) {
+		_, err := run(&runnerMock{}, t.TempDir(), "--service", "upstart")
+		assert.Error(t, err, `unsupported service "upstart", expected one of systemd, launchd`)
+	})
+
+	if runtime.GOOS == "linux" {
+		t.Run("systemd", func(t *testing.T) {
+			defer func(dir string, f func(string, ...string) error) {
+				systemdUnitDir, runServiceCommand = dir, f
+			}(systemdUnitDir, runServiceCommand)
+			systemdUnitDir = t.TempDir()
+			var commands []string
+			runServiceCommand = func(name string, args ...string) error {
+				commands = append(commands, name+" "+strings.Join(args, " "))
+				return nil
+			}
+			dir := t.TempDir()
+
+			out, err := run(&runnerMock{}, dir, "--service", "systemd")
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(out, "Registered the agent as a systemd service, it is now running\n"))
+			assert.Check(t, cmp.DeepEqual(commands, []string{"systemctl daemon-reload", "systemctl enable --now circleci.service"}))
+
+			unit, err := ioutil.ReadFile(filepath.Join(systemdUnitDir, "circleci.service"))
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Contains(string(unit), "ExecStart="+filepath.Join(dir, "circleci-launch-agent")+" --config "))
+		})
+	}
+}
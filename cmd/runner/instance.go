@@ -1,79 +1,840 @@
 package runner
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/blang/semver"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 
 	"github.com/CircleCI-Public/circleci-cli/api/runner"
 )
 
+// timeNow is overridden in tests so instance status can be computed against a fixed clock.
+var timeNow = time.Now
+
+// timeLocation is where absolute timestamps are shown, set from the timezone setting.
+var timeLocation = time.Local
+
+// instanceOfflineAfter is how long an instance can go without connecting
+// before it is considered offline.
+const instanceOfflineAfter = 5 * time.Minute
+
+// defaultInstancePageSize is how many instances are requested per page by default.
+const defaultInstancePageSize = 100
+
 func newRunnerInstanceCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "instance",
 		Short: "Operate on runner instances",
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	var groupBy, output, fieldsFile, resourceClassGlob, olderThan, newerThan, state, stale, runnerType, pageToken string
+	var columns []string
+	var listTimeoutFlag, watchInterval time.Duration
+	var pageSize, limit int
+	var watch, all bool
+	listCmd := &cobra.Command{
 		Use:   "list <namespace or resource-class>",
 		Short: "List runner instances",
 		Example: `  circleci runner instance ls my-namespace
-  circleci runner instance ls my-namespace/my-resource-class`,
+  circleci runner instance ls my-namespace/my-resource-class
+  circleci runner instance ls my-namespace --group-by version
+  circleci runner instance ls my-namespace --resource-class-glob 'my-namespace/team-a-*'
+  circleci runner instance ls my-namespace --older-agent-than 1.1.0
+  circleci runner instance ls my-namespace --state disconnected
+  circleci runner instance ls my-namespace --stale 7d
+  circleci runner instance ls my-namespace --type container
+  circleci runner instance ls my-namespace --limit 20
+  circleci runner instance ls my-namespace --limit 20 --page-token <token>
+  circleci runner instance ls my-namespace --watch --watch-interval 30s
+  circleci runner instance ls my-namespace --columns name,hostname,version --output csv`,
 		Aliases: []string{"ls"},
 		Args:    cobra.ExactArgs(1),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			runners, err := o.r.GetRunnerInstances(args[0])
+			if output == "env" {
+				return errEnvOutputList
+			}
+			if output != "table" && output != "json" && output != "yaml" && output != "csv" && output != "markdown" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml, csv, markdown", output)
+			}
+
+			fields, err := instanceColumns(columns, fieldsFile)
+			if err != nil {
+				return err
+			}
+
+			if pageSize < 0 {
+				return fmt.Errorf("invalid page size %d, expected a positive number or 0 for the API default", pageSize)
+			}
+			if pageSize > runner.MaxInstancePageSize {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Page size %d exceeds the maximum of %d, using %d\n",
+					pageSize, runner.MaxInstancePageSize, runner.MaxInstancePageSize)
+				pageSize = runner.MaxInstancePageSize
+			}
+
+			if limit < 0 {
+				return fmt.Errorf("invalid limit %d, expected a positive number", limit)
+			}
+			if all && limit > 0 {
+				return errors.New("--all and --limit can't be used together")
+			}
+
+			if resourceClassGlob != "" {
+				if _, err = path.Match(resourceClassGlob, ""); err != nil {
+					return fmt.Errorf("invalid resource-class glob %q: %w", resourceClassGlob, err)
+				}
+			}
+
+			versions, err := newAgentVersionFilter(olderThan, newerThan)
 			if err != nil {
 				return err
 			}
 
-			table := newRunnerInstanceTable(cmd.OutOrStdout())
+			connectivity, err := newConnectivityFilter(state, stale)
+			if err != nil {
+				return err
+			}
+			if err = validateRunnerType(runnerType); err != nil {
+				return err
+			}
+
+			if watch {
+				if output != "table" || groupBy != "" {
+					return errors.New("--watch can only be used with --output table and without --group-by")
+				}
+				if watchInterval <= 0 {
+					return fmt.Errorf("invalid watch interval %s, expected a positive duration", watchInterval)
+				}
+				if pageToken != "" {
+					return errors.New("--watch and --page-token can't be used together")
+				}
+			}
+
+			list := func() ([]runner.RunnerInstance, error) {
+				var runners []runner.RunnerInstance
+				var next string
+				err := o.withTimeout("list runner instances", o.timeout(listTimeout, listTimeoutFlag), func(r running) (err error) {
+					opts := runner.InstanceListOptions{PageSize: pageSize, Limit: limit, PageToken: pageToken}
+					runners, next, err = r.GetRunnerInstancesWithOptions(args[0], opts)
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+				if next != "" && !watch {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Stopped after %d instances, use --page-token %s to list the next ones or --all to list every instance\n", limit, next)
+				}
+				if resourceClassGlob != "" {
+					runners = filterByResourceClass(runners, resourceClassGlob)
+				}
+				if versions != nil {
+					var unparseable []runner.RunnerInstance
+					runners, unparseable = versions.filter(runners)
+					if len(unparseable) > 0 {
+						reportUnparseableVersions(cmd.ErrOrStderr(), unparseable)
+					}
+				}
+				if connectivity != nil {
+					runners = connectivity.filter(runners, timeNow())
+				}
+				if runnerType != "" {
+					runners = filterInstancesByType(runners, runnerType, cmd.ErrOrStderr())
+				}
+				return runners, nil
+			}
+
+			if watch {
+				ctx, cancel := interruptContext()
+				defer cancel()
+
+				return watchInstanceTable(ctx, cmd.OutOrStdout(), watchInterval, list, func(w io.Writer, runners []runner.RunnerInstance, changed map[string]string) {
+					table := newRunnerInstanceTable(w, fields)
+					for _, r := range runners {
+						appendWatchedRunnerInstance(table, fields, r, changed[r.ResourceClass+"/"+r.Name])
+					}
+					table.Render()
+				})
+			}
+
+			runners, err := list()
+			if err != nil {
+				return err
+			}
+
+			if groupBy != "" {
+				groups, err := groupRunnerInstances(runners, groupBy, timeNow())
+				if err != nil {
+					return err
+				}
+				if output == "json" || output == "yaml" {
+					return writeStructured(cmd.OutOrStdout(), output, groups)
+				}
+				if output == "markdown" {
+					rows := make([][]string, len(groups))
+					for i, g := range groups {
+						rows[i] = instanceGroupRow(g)
+					}
+					return writeMarkdownTable(cmd.OutOrStdout(), instanceGroupHeader(groupBy), rows)
+				}
+
+				table := newInstanceGroupTable(cmd.OutOrStdout(), groupBy)
+				defer table.Render()
+				for _, g := range groups {
+					appendInstanceGroup(table, g)
+				}
+				return nil
+			}
+
+			if output == "json" || output == "yaml" {
+				return writeStructured(cmd.OutOrStdout(), output, runners)
+			}
+
+			if output == "csv" {
+				return writeRunnerInstancesCSV(cmd.OutOrStdout(), fields, runners)
+			}
+
+			if output == "markdown" {
+				rows := make([][]string, len(runners))
+				for i, r := range runners {
+					rows[i] = runnerInstanceRow(fields, r)
+				}
+				return writeMarkdownTable(cmd.OutOrStdout(), runnerInstanceHeader(fields), rows)
+			}
+
+			table := newRunnerInstanceTable(cmd.OutOrStdout(), fields)
 			defer table.Render()
 			for _, r := range runners {
-				appendRunnerInstance(table, r)
+				appendRunnerInstance(table, fields, r)
 			}
 
 			return nil
 		},
-	})
+	}
+	listCmd.PersistentFlags().StringVar(&groupBy, "group-by", "",
+		"Summarise instance counts by one of resource-class, version or status")
+	listCmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("table", "table", "json", "yaml", "csv"),
+		"Output format, one of table, json, yaml, csv or markdown")
+	listCmd.PersistentFlags().StringSliceVar(&columns, "columns", nil,
+		"Comma separated fields to show as table or csv columns, one of "+strings.Join(instanceFieldNames(), ", "))
+	listCmd.PersistentFlags().StringVar(&fieldsFile, "fields-from-file", "",
+		"Read the fields to show, one per line, from this file (--columns takes precedence)")
+	listCmd.PersistentFlags().StringVar(&resourceClassGlob, "resource-class-glob", "",
+		"Only list instances whose resource-class matches this glob, such as my-namespace/team-a-*")
+	listCmd.PersistentFlags().StringVar(&olderThan, "older-agent-than", "",
+		"Only list instances running an agent version older than this")
+	listCmd.PersistentFlags().StringVar(&newerThan, "newer-agent-than", "",
+		"Only list instances running an agent version newer than this")
+	listCmd.PersistentFlags().StringVar(&state, "state", "",
+		"Only list instances that are connected (online) or disconnected (offline)")
+	listCmd.PersistentFlags().StringVar(&stale, "stale", "",
+		"Only list instances that haven't connected for at least this long, such as 24h or 7d")
+	listCmd.PersistentFlags().StringVar(&runnerType, "type", "",
+		"Only list instances of this type of runner, machine or container")
+	listCmd.PersistentFlags().DurationVar(&listTimeoutFlag, "list-timeout", 0,
+		"Time limit for listing instances (default 30s)")
+	listCmd.PersistentFlags().BoolVar(&watch, "watch", false,
+		"Redraw the table every --watch-interval until interrupted, highlighting instances that appeared, disappeared or changed status")
+	listCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", 10*time.Second,
+		"How often to refresh the table with --watch")
+	listCmd.PersistentFlags().IntVar(&pageSize, "page-size", defaultInstancePageSize,
+		fmt.Sprintf("Number of instances to fetch per request, at most %d (0 uses the API default)", runner.MaxInstancePageSize))
+	listCmd.PersistentFlags().IntVar(&limit, "limit", 0,
+		"Stop after fetching this many instances (0 fetches them all)")
+	listCmd.PersistentFlags().BoolVar(&all, "all", false,
+		"Fetch every instance, however many pages that takes (the default unless --limit is given)")
+	listCmd.PersistentFlags().StringVar(&pageToken, "page-token", "",
+		"Carry on listing from where an earlier --limit stopped")
+	cmd.AddCommand(listCmd)
+
+	var namespace, watchOutput string
+	var interval time.Duration
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch runner instances for changes in status",
+		Long: `Watch runner instances for changes in status.
+
+Prints a line whenever an instance appears, disappears, or goes online or offline,
+until interrupted.`,
+		Example: `  circleci runner instance watch --namespace my-namespace
+  circleci runner instance watch --namespace my-namespace --output json | grep offline`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if watchOutput != "table" && watchOutput != "json" && watchOutput != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", watchOutput)
+			}
+
+			ctx, cancel := interruptContext()
+			defer cancel()
+
+			return watchRunnerInstances(ctx, o.r, namespace, interval, func(e instanceEvent) error {
+				if watchOutput == "json" {
+					return json.NewEncoder(cmd.OutOrStdout()).Encode(e)
+				}
+				if watchOutput == "yaml" {
+					// Each event is its own document, so the stream can be read as it arrives.
+					if _, err := fmt.Fprintln(cmd.OutOrStdout(), "---"); err != nil {
+						return err
+					}
+					return writeYAML(cmd.OutOrStdout(), e)
+				}
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), e)
+				return err
+			})
+		},
+	}
+	watchCmd.PersistentFlags().StringVar(&namespace, "namespace", "",
+		"Namespace (or resource-class) to watch")
+	watchCmd.PersistentFlags().DurationVar(&interval, "interval", 10*time.Second,
+		"How often to poll for changes")
+	watchCmd.PersistentFlags().StringVar(&watchOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	_ = watchCmd.MarkPersistentFlagRequired("namespace")
+	cmd.AddCommand(watchCmd)
+
+	var force, deleteDryRun bool
+	deleteCmd := &cobra.Command{
+		Use:   "delete <instance-id>",
+		Short: "Delete a runner instance",
+		Long: `Delete a runner instance, such as one left behind by an agent that no longer runs.
+
+An agent that is still running will show up again the next time it connects.`,
+		Example: `  circleci runner instance delete my-instance --force
+  circleci runner instance delete my-instance --dry-run`,
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if deleteDryRun {
+				_, err := fmt.Fprintf(cmd.OutOrStdout(), "Would delete runner instance %s\n", args[0])
+				return err
+			}
+			if !force && !o.askToConfirm(fmt.Sprintf("Are you sure you want to delete runner instance %s?", args[0])) {
+				return fmt.Errorf("not deleting runner instance %s", args[0])
+			}
+
+			err := o.withTimeout("delete runner instance", o.timeout(mutateTimeout, 0), func(r running) error {
+				return r.DeleteRunnerInstance(args[0])
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Deleted runner instance %s\n", args[0])
+			return err
+		},
+	}
+	deleteCmd.PersistentFlags().BoolVarP(&force, "force", "f", false,
+		"Delete the instance without asking for confirmation")
+	deleteCmd.PersistentFlags().BoolVar(&deleteDryRun, "dry-run", false,
+		"Print the instance that would be deleted, without deleting it")
+	cmd.AddCommand(deleteCmd)
+
+	var drainResourceClass string
+	var revokeToken, drainForce bool
+	drainCmd := &cobra.Command{
+		Use:   "drain <instance-id>",
+		Short: "Stop a runner instance from claiming new tasks",
+		Long: `Stop a runner instance from claiming new tasks, so its host can be taken down
+for maintenance once the tasks it is running have finished.
+
+Where the API can't drain instances, --revoke-token instead deletes the token the
+instance's host uses, found from the tokens of --resource-class. Tokens that other
+hosts use as well are never revoked. A new token is needed to bring the host back.`,
+		Example: `  circleci runner instance drain my-instance
+  circleci runner instance drain my-instance --revoke-token --resource-class my-namespace/my-resource-class`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if revokeToken && drainResourceClass == "" {
+				return errors.New("--revoke-token needs --resource-class, to find the token of the instance")
+			}
+
+			err := o.withTimeout("drain runner instance", o.timeout(mutateTimeout, 0), func(r running) error {
+				return r.DrainRunnerInstance(args[0])
+			})
+			if errors.Is(err, runner.ErrInstanceDrainNotSupported) && revokeToken {
+				return drainByRevokingToken(o, args[0], drainResourceClass, drainForce, cmd.OutOrStdout())
+			}
+			if errors.Is(err, runner.ErrInstanceDrainNotSupported) {
+				return fmt.Errorf("%w, use --revoke-token to revoke the token of the instance instead", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Draining runner instance %s, it will finish its current tasks but not claim new ones\n", args[0])
+			return err
+		},
+	}
+	drainCmd.PersistentFlags().BoolVar(&revokeToken, "revoke-token", false,
+		"Revoke the token of the instance if the API can't drain it")
+	drainCmd.PersistentFlags().StringVar(&drainResourceClass, "resource-class", "",
+		"Resource-class of the instance, to find its token with --revoke-token")
+	drainCmd.PersistentFlags().BoolVarP(&drainForce, "force", "f", false,
+		"Revoke the token without asking for confirmation")
+	cmd.AddCommand(drainCmd)
+
+	var labelID, labelOutput string
+	var setLabels, removeLabels []string
+	labelCmd := &cobra.Command{
+		Use:   "label",
+		Short: "Show, set or remove labels on a runner instance",
+		Long: `Show, set or remove labels on a runner instance.
+
+Labels can be used to mark an instance, for example as being under maintenance.
+The labels of the instance are printed once any changes have been made.`,
+		Example: `  circleci runner instance label --id my-instance --set maintenance=true
+  circleci runner instance label --id my-instance --remove maintenance`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if labelOutput != "table" && labelOutput != "json" && labelOutput != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", labelOutput)
+			}
+
+			set, err := parseLabels(setLabels)
+			if err != nil {
+				return err
+			}
+
+			var labels map[string]string
+			err = o.withTimeout("label runner instance", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				for key, value := range set {
+					if err := r.SetRunnerInstanceLabel(labelID, key, value); err != nil {
+						return err
+					}
+				}
+				for _, key := range removeLabels {
+					if err := r.RemoveRunnerInstanceLabel(labelID, key); err != nil {
+						return err
+					}
+				}
+				labels, err = r.GetRunnerInstanceLabels(labelID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if labelOutput != "table" {
+				if labels == nil {
+					labels = map[string]string{}
+				}
+				return writeStructured(cmd.OutOrStdout(), labelOutput, labels)
+			}
+
+			keys := make([]string, 0, len(labels))
+			for k := range labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			table := tablewriter.NewWriter(cmd.OutOrStdout())
+			defer table.Render()
+			table.SetHeader([]string{"Key", "Value"})
+			for _, k := range keys {
+				table.Append([]string{k, labels[k]})
+			}
+			return nil
+		},
+	}
+	labelCmd.PersistentFlags().StringVar(&labelID, "id", "", "ID of the runner instance")
+	labelCmd.PersistentFlags().StringSliceVar(&setLabels, "set", nil, "Label to set, as key=value. Can be repeated.")
+	labelCmd.PersistentFlags().StringSliceVar(&removeLabels, "remove", nil, "Key of a label to remove. Can be repeated.")
+	labelCmd.PersistentFlags().StringVar(&labelOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	_ = labelCmd.MarkPersistentFlagRequired("id")
+	cmd.AddCommand(labelCmd)
 
 	return cmd
 }
 
-func newRunnerInstanceTable(writer io.Writer) *tablewriter.Table {
-	table := tablewriter.NewWriter(writer)
-	table.SetHeader([]string{
-		"Name",
-		"Resource Class",
-		"Hostname",
-		"First Connected",
-		"Last Connected",
-		"Last Used",
-		"IP",
-		"Version",
+// drainByRevokingToken stops an instance claiming new tasks by deleting the
+// token its host uses, as long as no other host uses it too.
+func drainByRevokingToken(o *runnerOpts, id, resourceClass string, force bool, stdout io.Writer) error {
+	var instance *runner.RunnerInstance
+	var revoke []runner.Token
+	err := o.withTimeout("find token of runner instance", o.timeout(listTimeout, 0), func(r running) error {
+		instances, err := r.GetRunnerInstances(resourceClass)
+		if err != nil {
+			return err
+		}
+		for i := range instances {
+			if instances[i].Name == id {
+				instance = &instances[i]
+				break
+			}
+		}
+		if instance == nil {
+			return fmt.Errorf("no runner instance %s found in resource-class %s", id, resourceClass)
+		}
+
+		tokens, err := r.GetRunnerTokensByResourceClass(resourceClass)
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			usage, err := r.GetTokenUsage(t.ID)
+			if err != nil {
+				return err
+			}
+			var used, shared bool
+			for _, u := range usage {
+				if u.Hostname == instance.Hostname {
+					used = true
+				} else {
+					shared = true
+				}
+			}
+			if used && shared {
+				return fmt.Errorf("token %s (%s) is used by other hosts as well as %s, not revoking it", t.Nickname, t.ID, instance.Hostname)
+			}
+			if used {
+				revoke = append(revoke, t)
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if len(revoke) == 0 {
+		return fmt.Errorf("no token of resource-class %s has been used recently by %s", resourceClass, instance.Hostname)
+	}
+
+	for _, t := range revoke {
+		if !force && !o.askToConfirm(fmt.Sprintf("Are you sure you want to revoke token %s (%s) used by %s?", t.Nickname, t.ID, instance.Hostname)) {
+			return fmt.Errorf("not revoking token %s", t.ID)
+		}
+		err = o.withTimeout("delete token", o.timeout(mutateTimeout, 0), func(r running) error {
+			return r.DeleteToken(t.ID)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Revoked token %s (%s) used by %s\n", t.Nickname, t.ID, instance.Hostname)
+	}
+	_, err = fmt.Fprintf(stdout, "Runner instance %s can't claim new tasks, create a new token to bring it back\n", id)
+	return err
+}
+
+func newRunnerInstanceTable(writer io.Writer, fields []instanceField) *tablewriter.Table {
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(runnerInstanceHeader(fields))
 	return table
 }
 
-func appendRunnerInstance(table *tablewriter.Table, r runner.RunnerInstance) {
-	table.Append([]string{
-		r.Name,
-		r.ResourceClass,
-		r.Hostname,
-		formatOptionalTime(r.FirstConnected),
-		formatOptionalTime(r.LastConnected),
-		formatOptionalTime(r.LastUsed),
-		r.IP,
-		r.Version,
-	})
+func appendRunnerInstance(table *tablewriter.Table, fields []instanceField, r runner.RunnerInstance) {
+	table.Append(runnerInstanceRow(fields, r))
+}
+
+func runnerInstanceHeader(fields []instanceField) []string {
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.header
+	}
+	return header
+}
+
+func runnerInstanceRow(fields []instanceField, r runner.RunnerInstance) []string {
+	row := make([]string, len(fields))
+	for i, f := range fields {
+		row[i] = f.value(r)
+	}
+	return row
+}
+
+func writeRunnerInstancesCSV(writer io.Writer, fields []instanceField, runners []runner.RunnerInstance) error {
+	w := csv.NewWriter(writer)
+
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range runners {
+		if err := w.Write(runnerInstanceRow(fields, r)); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
 }
 
 func formatOptionalTime(t *time.Time) string {
 	if t == nil {
 		return ""
 	}
-	return t.Format(time.RFC3339)
+	return formatTime(*t)
+}
+
+// formatTime renders an absolute timestamp in timeLocation.
+func formatTime(t time.Time) string {
+	return t.In(timeLocation).Format(time.RFC3339)
+}
+
+// filterByResourceClass returns the instances whose resource-class matches the
+// glob, which must already be known to be valid.
+func filterByResourceClass(runners []runner.RunnerInstance, glob string) []runner.RunnerInstance {
+	matched := []runner.RunnerInstance{}
+	for _, r := range runners {
+		if ok, _ := path.Match(glob, r.ResourceClass); ok {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// agentVersionFilter keeps instances whose agent version is strictly between
+// the bounds that are set.
+type agentVersionFilter struct {
+	older, newer *semver.Version
+}
+
+// newAgentVersionFilter parses the bounds given to --older-agent-than and
+// --newer-agent-than, returning nil if neither was given.
+func newAgentVersionFilter(olderThan, newerThan string) (*agentVersionFilter, error) {
+	if olderThan == "" && newerThan == "" {
+		return nil, nil
+	}
+
+	f := &agentVersionFilter{}
+	for _, bound := range []struct {
+		flag, value string
+		v           **semver.Version
+	}{
+		{"--older-agent-than", olderThan, &f.older},
+		{"--newer-agent-than", newerThan, &f.newer},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		v, err := semver.ParseTolerant(bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s version %q: %w", bound.flag, bound.value, err)
+		}
+		*bound.v = &v
+	}
+	return f, nil
+}
+
+// filter returns the instances within the bounds, and separately those whose
+// agent version can't be compared because it isn't valid semver.
+func (f *agentVersionFilter) filter(runners []runner.RunnerInstance) (matched, unparseable []runner.RunnerInstance) {
+	matched = []runner.RunnerInstance{}
+	for _, r := range runners {
+		v, err := semver.ParseTolerant(r.Version)
+		if err != nil {
+			unparseable = append(unparseable, r)
+			continue
+		}
+		if f.older != nil && !v.LT(*f.older) {
+			continue
+		}
+		if f.newer != nil && !v.GT(*f.newer) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	return matched, unparseable
+}
+
+// reportUnparseableVersions lists the instances left out of a version filtered
+// listing because their agent version couldn't be compared.
+func reportUnparseableVersions(w io.Writer, runners []runner.RunnerInstance) {
+	fmt.Fprintf(w, "%d instance(s) left out as their agent version couldn't be compared:\n", len(runners))
+	for _, r := range runners {
+		fmt.Fprintf(w, "  %s (%s): %q\n", r.Name, r.ResourceClass, r.Version)
+	}
+}
+
+// runnerTypes are the kinds of runner the API distinguishes.
+var runnerTypes = []string{"machine", "container"}
+
+// validateRunnerType checks the value given to --type, which may be empty.
+func validateRunnerType(t string) error {
+	if t == "" {
+		return nil
+	}
+	for _, known := range runnerTypes {
+		if t == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported runner type %q, expected one of %s", t, strings.Join(runnerTypes, ", "))
+}
+
+// filterInstancesByType returns the instances of the given type of runner,
+// reporting any left out because the API didn't say what type they are.
+func filterInstancesByType(runners []runner.RunnerInstance, runnerType string, stderr io.Writer) []runner.RunnerInstance {
+	matched := []runner.RunnerInstance{}
+	unknown := 0
+	for _, r := range runners {
+		switch r.Type {
+		case runnerType:
+			matched = append(matched, r)
+		case "":
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		fmt.Fprintf(stderr, "%d instance(s) left out as their runner type isn't known\n", unknown)
+	}
+	return matched
+}
+
+// connectivityFilter keeps instances by when they last connected. The API
+// can't filter on this, so it's done once the instances have been listed.
+type connectivityFilter struct {
+	// connected keeps only online instances if true, and only offline ones if false.
+	connected *bool
+	// stale, if set, keeps only instances that haven't connected for this long.
+	stale time.Duration
+}
+
+// newConnectivityFilter parses the values given to --state and --stale,
+// returning nil if neither was given.
+func newConnectivityFilter(state, stale string) (*connectivityFilter, error) {
+	if state == "" && stale == "" {
+		return nil, nil
+	}
+
+	f := &connectivityFilter{}
+	switch state {
+	case "":
+	case "connected":
+		connected := true
+		f.connected = &connected
+	case "disconnected":
+		connected := false
+		f.connected = &connected
+	default:
+		return nil, fmt.Errorf("unsupported state %q, expected one of connected, disconnected", state)
+	}
+
+	if stale != "" {
+		d, err := parseDays(stale)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid --stale duration %q, expected a positive duration such as 24h or 7d", stale)
+		}
+		if f.connected != nil && *f.connected {
+			return nil, errors.New("--stale can't be used with --state connected")
+		}
+		f.stale = d
+	}
+	return f, nil
+}
+
+// filter returns the instances that match. Instances that have never
+// connected are disconnected and count as stale however long the threshold.
+func (f *connectivityFilter) filter(runners []runner.RunnerInstance, now time.Time) []runner.RunnerInstance {
+	matched := []runner.RunnerInstance{}
+	for _, r := range runners {
+		if f.connected != nil && (instanceStatus(r, now) == "online") != *f.connected {
+			continue
+		}
+		if f.stale > 0 && r.LastConnected != nil && now.Sub(*r.LastConnected) < f.stale {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	return matched
+}
+
+// instanceStatus reports whether an instance has connected recently enough to be considered online.
+func instanceStatus(r runner.RunnerInstance, now time.Time) string {
+	if r.LastConnected == nil || now.Sub(*r.LastConnected) > instanceOfflineAfter {
+		return "offline"
+	}
+	return "online"
+}
+
+type instanceGroup struct {
+	Group   string `json:"group"`
+	Count   int    `json:"count"`
+	Online  int    `json:"online"`
+	Offline int    `json:"offline"`
+}
+
+// groupRunnerInstances aggregates instances by the given key. Groups are
+// sorted by key, so the output doesn't depend on the order the API returned
+// the instances in.
+func groupRunnerInstances(runners []runner.RunnerInstance, key string, now time.Time) ([]instanceGroup, error) {
+	var keyFn func(r runner.RunnerInstance) string
+	switch key {
+	case "resource-class":
+		keyFn = func(r runner.RunnerInstance) string { return r.ResourceClass }
+	case "version":
+		keyFn = func(r runner.RunnerInstance) string { return r.Version }
+	case "status":
+		keyFn = func(r runner.RunnerInstance) string { return instanceStatus(r, now) }
+	default:
+		return nil, fmt.Errorf("unsupported group-by key %q, expected one of resource-class, version, status", key)
+	}
+
+	groups := []instanceGroup{}
+	index := map[string]int{}
+	for _, r := range runners {
+		k := keyFn(r)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, instanceGroup{Group: k})
+		}
+
+		groups[i].Count++
+		if instanceStatus(r, now) == "online" {
+			groups[i].Online++
+		} else {
+			groups[i].Offline++
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Group < groups[j].Group })
+	return groups, nil
+}
+
+func newInstanceGroupTable(writer io.Writer, key string) *tablewriter.Table {
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(instanceGroupHeader(key))
+	return table
+}
+
+func appendInstanceGroup(table *tablewriter.Table, g instanceGroup) {
+	table.Append(instanceGroupRow(g))
+}
+
+func instanceGroupHeader(key string) []string {
+	header := map[string]string{
+		"resource-class": "Resource Class",
+		"version":        "Version",
+		"status":         "Status",
+	}[key]
+	return []string{header, "Count", "Online", "Offline"}
+}
+
+func instanceGroupRow(g instanceGroup) []string {
+	return []string{
+		g.Group,
+		strconv.Itoa(g.Count),
+		strconv.Itoa(g.Online),
+		strconv.Itoa(g.Offline),
+	}
 }
@@ -0,0 +1,693 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+	"github.com/CircleCI-Public/circleci-cli/settings"
+)
+
+func Test_RunnerInstance(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	online := now.Add(-time.Minute)
+	offline := now.Add(-time.Hour)
+
+	mock := runnerMock{}
+	cmd := newRunnerInstanceCommand(&runnerOpts{r: &mock}, nil)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+
+	t.Run("list grouped", func(t *testing.T) {
+		defer mock.reset()
+
+		tests := []struct {
+			key  string
+			want []instanceGroup
+		}{
+			{
+				key: "resource-class",
+				want: []instanceGroup{
+					{Group: "my-namespace/rc-a", Count: 2, Online: 1, Offline: 1},
+					{Group: "my-namespace/rc-b", Count: 1, Online: 0, Offline: 1},
+				},
+			},
+			{
+				key: "version",
+				want: []instanceGroup{
+					{Group: "1.0.0", Count: 2, Online: 1, Offline: 1},
+					{Group: "1.1.0", Count: 1, Online: 0, Offline: 1},
+				},
+			},
+			{
+				key: "status",
+				want: []instanceGroup{
+					{Group: "offline", Count: 2, Online: 0, Offline: 2},
+					{Group: "online", Count: 1, Online: 1, Offline: 0},
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.key, func(t *testing.T) {
+				mock.instances = []runner.RunnerInstance{
+					{Name: "a", ResourceClass: "my-namespace/rc-a", Version: "1.0.0", LastConnected: &online},
+					{Name: "b", ResourceClass: "my-namespace/rc-a", Version: "1.1.0", LastConnected: &offline},
+					{Name: "c", ResourceClass: "my-namespace/rc-b", Version: "1.0.0"},
+				}
+				defer stdout.Reset()
+				defer stderr.Reset()
+
+				cmd.SetArgs([]string{"list", "my-namespace", "--group-by", tt.key, "--output", "json"})
+				err := cmd.Execute()
+				assert.NilError(t, err)
+
+				var groups []instanceGroup
+				assert.NilError(t, json.Unmarshal(stdout.Bytes(), &groups))
+				assert.Check(t, cmp.DeepEqual(groups, tt.want))
+			})
+		}
+	})
+
+	t.Run("list grouped as table", func(t *testing.T) {
+		defer mock.reset()
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		mock.instances = []runner.RunnerInstance{
+			{Name: "a", ResourceClass: "my-namespace/rc-a", Version: "1.0.0", LastConnected: &online},
+		}
+
+		cmd.SetArgs([]string{"list", "my-namespace", "--group-by", "version", "--output", "table"})
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stdout.String(), "VERSION"))
+		assert.Check(t, cmp.Contains(stdout.String(), "1.0.0"))
+	})
+
+	t.Run("list with fields from file", func(t *testing.T) {
+		mock := runnerMock{instances: []runner.RunnerInstance{
+			{Name: "a", ResourceClass: "my-namespace/rc-a", Hostname: "host-a", Version: "1.0.0", LastConnected: &online},
+		}}
+
+		fieldsFile := filepath.Join(t.TempDir(), "fields")
+		assert.NilError(t, ioutil.WriteFile(fieldsFile, []byte("# our standard columns\nhostname\n\nversion\nlast_connected\n"), 0600))
+
+		tests := []struct {
+			name string
+			args []string
+			want string
+		}{
+			{
+				name: "file only",
+				args: []string{"--fields-from-file", fieldsFile},
+				want: "hostname,version,last_connected\nhost-a,1.0.0,2021-06-01T11:59:00Z\n",
+			},
+			{
+				name: "columns flag wins",
+				args: []string{"--fields-from-file", fieldsFile, "--columns", "name,resource_class"},
+				want: "name,resource_class\na,my-namespace/rc-a\n",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cmd := newRunnerInstanceCommand(&runnerOpts{r: &mock}, nil)
+				stdout := new(bytes.Buffer)
+				cmd.SetOut(stdout)
+				cmd.SetErr(new(bytes.Buffer))
+
+				cmd.SetArgs(append([]string{"list", "my-namespace", "--output", "csv"}, tt.args...))
+				err := cmd.Execute()
+				assert.NilError(t, err)
+				assert.Check(t, cmp.Equal(stdout.String(), tt.want))
+			})
+		}
+
+		t.Run("as table", func(t *testing.T) {
+			stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--fields-from-file", fieldsFile)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Contains(stdout, "HOSTNAME"))
+			assert.Check(t, !strings.Contains(stdout, "RESOURCE CLASS"))
+		})
+
+		t.Run("unknown field", func(t *testing.T) {
+			badFile := filepath.Join(t.TempDir(), "fields")
+			assert.NilError(t, ioutil.WriteFile(badFile, []byte("hostname\nos\n"), 0600))
+
+			_, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--fields-from-file", badFile)
+			assert.ErrorContains(t, err, badFile+`: unknown field "os", expected one of name, resource_class, hostname`)
+		})
+	})
+
+	t.Run("list grouped by unknown key", func(t *testing.T) {
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		cmd.SetArgs([]string{"list", "my-namespace", "--group-by", "hostname"})
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, `unsupported group-by key "hostname"`)
+	})
+}
+
+func Test_RunnerInstancePageSize(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		_, stderr, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace"}, args...)...)
+		return stderr, err
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, err := run(&mock)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.pageSize, 100))
+	})
+
+	t.Run("requested", func(t *testing.T) {
+		mock := runnerMock{}
+
+		stderr, err := run(&mock, "--page-size", "25")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.pageSize, 25))
+		assert.Check(t, cmp.Equal(stderr, ""))
+	})
+
+	t.Run("clamped to the maximum", func(t *testing.T) {
+		mock := runnerMock{}
+
+		stderr, err := run(&mock, "--page-size", "5000")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.pageSize, runner.MaxInstancePageSize))
+		assert.Check(t, cmp.Equal(stderr, "Page size 5000 exceeds the maximum of 1000, using 1000\n"))
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, err := run(&mock, "--page-size", "-1")
+		assert.Error(t, err, "invalid page size -1, expected a positive number or 0 for the API default")
+	})
+}
+
+func Test_RunnerInstanceLimit(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--output", "csv", "--columns", "name"}, args...)...)
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{instances: []runner.RunnerInstance{
+			{Name: "a", ResourceClass: "my-namespace/rc-a"},
+			{Name: "b", ResourceClass: "my-namespace/rc-a"},
+			{Name: "c", ResourceClass: "my-namespace/rc-b"},
+		}}
+	}
+
+	t.Run("limited", func(t *testing.T) {
+		mock := newMock()
+
+		stdout, stderr, err := run(mock, "--limit", "2")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.limit, 2))
+		assert.Check(t, cmp.Equal(stdout, "name\na\nb\n"))
+		assert.Check(t, cmp.Equal(stderr, "Stopped after 2 instances, use --page-token 2 to list the next ones or --all to list every instance\n"))
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		stdout, stderr, err := run(newMock(), "--limit", "2", "--page-token", "2")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, "name\nc\n"))
+		assert.Check(t, cmp.Equal(stderr, ""))
+	})
+
+	t.Run("page token with watch", func(t *testing.T) {
+		_, _, err := run(newMock(), "--output", "table", "--watch", "--page-token", "2")
+		assert.Error(t, err, "--watch and --page-token can't be used together")
+	})
+
+	t.Run("all", func(t *testing.T) {
+		mock := newMock()
+
+		stdout, stderr, err := run(mock, "--all")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.limit, 0))
+		assert.Check(t, cmp.Equal(stdout, "name\na\nb\nc\n"))
+		assert.Check(t, cmp.Equal(stderr, ""))
+	})
+
+	t.Run("all with a limit", func(t *testing.T) {
+		_, _, err := run(newMock(), "--all", "--limit", "2")
+		assert.Error(t, err, "--all and --limit can't be used together")
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, _, err := run(newMock(), "--limit", "-1")
+		assert.Error(t, err, "invalid limit -1, expected a positive number")
+	})
+}
+
+func Test_RunnerInstanceLabel(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newRunnerInstanceCommand, append([]string{"label", "--id", "my-instance"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("set", func(t *testing.T) {
+		mock := runnerMock{labels: map[string]map[string]string{"my-instance": {"zone": "a"}}}
+
+		out, err := run(&mock, "--set", "maintenance=true", "--set", "owner=infra")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.labels["my-instance"], map[string]string{"zone": "a", "maintenance": "true", "owner": "infra"}))
+		assert.Check(t, cmp.Contains(out, "| maintenance | true  |"))
+		assert.Check(t, cmp.Contains(out, "infra"))
+	})
+
+	t.Run("as yaml", func(t *testing.T) {
+		mock := runnerMock{labels: map[string]map[string]string{"my-instance": {"zone": "a"}}}
+
+		out, err := run(&mock, "--set", "maintenance=true", "--output", "yaml")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "maintenance: \"true\"\nzone: a\n"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mock := runnerMock{labels: map[string]map[string]string{"my-instance": {"zone": "a", "maintenance": "true"}}}
+
+		out, err := run(&mock, "--remove", "maintenance")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.labels["my-instance"], map[string]string{"zone": "a"}))
+		assert.Check(t, !strings.Contains(out, "maintenance"))
+		assert.Check(t, cmp.Contains(out, "zone"))
+	})
+
+	t.Run("bad label", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, err := run(&mock, "--set", "maintenance")
+		assert.Error(t, err, `expected a label in the form key=value, got "maintenance"`)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		mock := runnerMock{labelsErr: runner.ErrInstanceLabelsNotSupported}
+
+		_, err := run(&mock, "--set", "maintenance=true")
+		assert.Error(t, err, "runner instance labels are not supported by this API")
+	})
+}
+
+func Test_RunnerInstanceTimezone(t *testing.T) {
+	defer func(loc *time.Location) { timeLocation = loc }(timeLocation)
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone("EST", -5*60*60)
+
+	connected := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	mock := runnerMock{instances: []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/rc-a", LastConnected: &connected},
+	}}
+
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "utc", want: "a,2021-06-01T12:00:00Z\n"},
+		{timezone: "local", want: "a,2021-06-01T07:00:00-05:00\n"},
+		{timezone: "", want: "a,2021-06-01T07:00:00-05:00\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			loc, err := (&settings.Config{Timezone: tt.timezone}).Location()
+			assert.NilError(t, err)
+			timeLocation = loc
+
+			stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--output", "csv", "--columns", "name,last_connected")
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(stdout, "name,last_connected\n"+tt.want))
+		})
+	}
+}
+
+func Test_RunnerInstanceResourceClassGlob(t *testing.T) {
+	mock := runnerMock{instances: []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/team-a-linux"},
+		{Name: "b", ResourceClass: "my-namespace/team-a-macos"},
+		{Name: "c", ResourceClass: "my-namespace/team-b-linux"},
+		{Name: "d", ResourceClass: "my-namespace/shared"},
+	}}
+
+	run := func(args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--output", "csv", "--columns", "name"}, args...)...)
+		return stdout, err
+	}
+
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{glob: "my-namespace/team-a-*", want: "name\na\nb\n"},
+		{glob: "my-namespace/*-linux", want: "name\na\nc\n"},
+		{glob: "my-namespace/team-[ab]-linux", want: "name\na\nc\n"},
+		{glob: "my-namespace/shared", want: "name\nd\n"},
+		{glob: "other-namespace/*", want: "name\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			out, err := run("--resource-class-glob", tt.glob)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(out, tt.want))
+		})
+	}
+
+	t.Run("grouped", func(t *testing.T) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--resource-class-glob", "*/team-*", "--group-by", "resource-class", "--output", "json")
+		assert.NilError(t, err)
+
+		var groups []instanceGroup
+		assert.NilError(t, json.Unmarshal([]byte(stdout), &groups))
+		assert.Check(t, cmp.Len(groups, 3))
+	})
+
+	t.Run("bad pattern", func(t *testing.T) {
+		_, err := run("--resource-class-glob", "my-namespace/team-[")
+		assert.Error(t, err, `invalid resource-class glob "my-namespace/team-[": syntax error in pattern`)
+	})
+}
+
+func Test_RunnerInstanceAgentVersionFilter(t *testing.T) {
+	mock := runnerMock{instances: []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/rc", Version: "1.0.0"},
+		{Name: "b", ResourceClass: "my-namespace/rc", Version: "1.1.0"},
+		{Name: "c", ResourceClass: "my-namespace/rc", Version: "v1.2.0"},
+		{Name: "d", ResourceClass: "my-namespace/rc", Version: "1.3.0"},
+		{Name: "e", ResourceClass: "my-namespace/rc", Version: "dev"},
+		{Name: "f", ResourceClass: "my-namespace/rc"},
+	}}
+
+	run := func(args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--output", "csv", "--columns", "name"}, args...)...)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "older than", args: []string{"--older-agent-than", "1.2.0"}, want: "name\na\nb\n"},
+		{name: "newer than", args: []string{"--newer-agent-than", "1.1.0"}, want: "name\nc\nd\n"},
+		{name: "between", args: []string{"--newer-agent-than", "1.0.0", "--older-agent-than", "1.3.0"}, want: "name\nb\nc\n"},
+		{name: "tolerant bound", args: []string{"--older-agent-than", "v1.1"}, want: "name\na\n"},
+		{name: "nothing matches", args: []string{"--newer-agent-than", "1.3.0"}, want: "name\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, stderr, err := run(tt.args...)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(out, tt.want))
+			assert.Check(t, cmp.Equal(stderr, "2 instance(s) left out as their agent version couldn't be compared:\n"+
+				"  e (my-namespace/rc): \"dev\"\n"+
+				"  f (my-namespace/rc): \"\"\n"))
+		})
+	}
+
+	t.Run("unfiltered", func(t *testing.T) {
+		out, stderr, err := run()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "name\na\nb\nc\nd\ne\nf\n"))
+		assert.Check(t, cmp.Equal(stderr, ""))
+	})
+
+	t.Run("bad version", func(t *testing.T) {
+		_, _, err := run("--older-agent-than", "latest")
+		assert.ErrorContains(t, err, `invalid --older-agent-than version "latest": `)
+	})
+}
+
+func Test_RunnerInstanceConnectivityFilter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	mock := runnerMock{instances: []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/rc", LastConnected: ago(time.Minute)},
+		{Name: "b", ResourceClass: "my-namespace/rc", LastConnected: ago(time.Hour)},
+		{Name: "c", ResourceClass: "my-namespace/rc", LastConnected: ago(48 * time.Hour)},
+		{Name: "d", ResourceClass: "my-namespace/rc", LastConnected: ago(30 * 24 * time.Hour)},
+		{Name: "e", ResourceClass: "my-namespace/rc"},
+	}}
+
+	run := func(args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--output", "csv", "--columns", "name"}, args...)...)
+		return stdout, err
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "connected", args: []string{"--state", "connected"}, want: "name\na\n"},
+		{name: "disconnected", args: []string{"--state", "disconnected"}, want: "name\nb\nc\nd\ne\n"},
+		{name: "stale hours", args: []string{"--stale", "24h"}, want: "name\nc\nd\ne\n"},
+		{name: "stale days", args: []string{"--stale", "7d"}, want: "name\nd\ne\n"},
+		{name: "disconnected and stale", args: []string{"--state", "disconnected", "--stale", "30m"}, want: "name\nb\nc\nd\ne\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := run(tt.args...)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(out, tt.want))
+		})
+	}
+
+	t.Run("bad state", func(t *testing.T) {
+		_, err := run("--state", "online")
+		assert.Error(t, err, `unsupported state "online", expected one of connected, disconnected`)
+	})
+
+	t.Run("bad stale duration", func(t *testing.T) {
+		_, err := run("--stale", "a while")
+		assert.Error(t, err, `invalid --stale duration "a while", expected a positive duration such as 24h or 7d`)
+	})
+
+	t.Run("stale and connected", func(t *testing.T) {
+		_, err := run("--state", "connected", "--stale", "24h")
+		assert.Error(t, err, "--stale can't be used with --state connected")
+	})
+}
+
+func Test_RunnerInstanceTypeFilter(t *testing.T) {
+	mock := runnerMock{instances: []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/rc", Type: "machine"},
+		{Name: "b", ResourceClass: "my-namespace/rc", Type: "container"},
+		{Name: "c", ResourceClass: "my-namespace/rc"},
+	}}
+
+	run := func(args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--output", "csv", "--columns", "name,type"}, args...)...)
+	}
+
+	t.Run("unfiltered", func(t *testing.T) {
+		out, _, err := run()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "name,type\na,machine\nb,container\nc,\n"))
+	})
+
+	t.Run("container", func(t *testing.T) {
+		out, stderr, err := run("--type", "container")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "name,type\nb,container\n"))
+		assert.Check(t, cmp.Equal(stderr, "1 instance(s) left out as their runner type isn't known\n"))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, _, err := run("--type", "docker")
+		assert.Error(t, err, `unsupported runner type "docker", expected one of machine, container`)
+	})
+}
+
+func Test_RunnerInstanceGroupedOutputIsStable(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+	online := now.Add(-time.Minute)
+
+	instances := []runner.RunnerInstance{
+		{Name: "a", ResourceClass: "my-namespace/rc-b", Version: "1.1.0", LastConnected: &online},
+		{Name: "b", ResourceClass: "my-namespace/rc-a", Version: "1.0.0"},
+		{Name: "c", ResourceClass: "my-namespace/rc-c", Version: "1.2.0", LastConnected: &online},
+		{Name: "d", ResourceClass: "my-namespace/rc-a", Version: "1.1.0"},
+	}
+	reversed := make([]runner.RunnerInstance, len(instances))
+	for i, r := range instances {
+		reversed[len(instances)-1-i] = r
+	}
+
+	run := func(instances []runner.RunnerInstance, args ...string) string {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &runnerMock{instances: instances}}, newRunnerInstanceCommand, append([]string{"list", "my-namespace"}, args...)...)
+		assert.NilError(t, err)
+		return stdout
+	}
+
+	for _, key := range []string{"resource-class", "version", "status"} {
+		for _, output := range []string{"table", "json", "markdown"} {
+			t.Run(key+" as "+output, func(t *testing.T) {
+				args := []string{"--group-by", key, "--output", output}
+				first := run(instances, args...)
+				assert.Check(t, cmp.Equal(run(instances, args...), first))
+				assert.Check(t, cmp.Equal(run(reversed, args...), first))
+			})
+		}
+	}
+}
+
+func Test_RunnerInstanceDelete(t *testing.T) {
+	run := func(mock *runnerMock, confirm bool, args ...string) (string, []string, error) {
+		var asked []string
+		o := &runnerOpts{r: mock, confirm: func(message string) bool {
+			asked = append(asked, message)
+			return confirm
+		}}
+		stdout, _, err := executeRunnerCommand(t, o, newRunnerInstanceCommand, append([]string{"delete", "my-instance"}, args...)...)
+		return stdout, asked, err
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		mock := runnerMock{}
+
+		out, asked, err := run(&mock, true)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(asked, []string{"Are you sure you want to delete runner instance my-instance?"}))
+		assert.Check(t, cmp.DeepEqual(mock.deletedInstances, []string{"my-instance"}))
+		assert.Check(t, cmp.Equal(out, "Deleted runner instance my-instance\n"))
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, _, err := run(&mock, false)
+		assert.Error(t, err, "not deleting runner instance my-instance")
+		assert.Check(t, cmp.Len(mock.deletedInstances, 0))
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, asked, err := run(&mock, false, "--force")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(asked, 0))
+		assert.Check(t, cmp.DeepEqual(mock.deletedInstances, []string{"my-instance"}))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mock := runnerMock{}
+
+		out, asked, err := run(&mock, false, "--dry-run")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(asked, 0))
+		assert.Check(t, cmp.Len(mock.deletedInstances, 0))
+		assert.Check(t, cmp.Equal(out, "Would delete runner instance my-instance\n"))
+	})
+}
+
+func Test_RunnerInstanceDrain(t *testing.T) {
+	run := func(mock *runnerMock, confirm bool, args ...string) (string, []string, error) {
+		var asked []string
+		o := &runnerOpts{r: mock, confirm: func(message string) bool {
+			asked = append(asked, message)
+			return confirm
+		}}
+		stdout, _, err := executeRunnerCommand(t, o, newRunnerInstanceCommand, append([]string{"drain", "my-instance"}, args...)...)
+		return stdout, asked, err
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{
+			drainUnsupported: true,
+			instances: []runner.RunnerInstance{
+				{ResourceClass: "my-namespace/my-resource-class", Name: "my-instance", Hostname: "host-a"},
+				{ResourceClass: "my-namespace/my-resource-class", Name: "other-instance", Hostname: "host-b"},
+			},
+			tokens: []runner.Token{
+				{ID: "token-a", ResourceClass: "my-namespace/my-resource-class", Nickname: "a"},
+				{ID: "token-b", ResourceClass: "my-namespace/my-resource-class", Nickname: "b"},
+			},
+			usage: map[string][]runner.TokenUsage{
+				"token-a": {{Hostname: "host-a"}},
+				"token-b": {{Hostname: "host-b"}},
+			},
+		}
+	}
+
+	t.Run("drained", func(t *testing.T) {
+		mock := &runnerMock{}
+
+		out, asked, err := run(mock, false)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(asked, 0))
+		assert.Check(t, cmp.DeepEqual(mock.drainedInstances, []string{"my-instance"}))
+		assert.Check(t, cmp.Equal(out, "Draining runner instance my-instance, it will finish its current tasks but not claim new ones\n"))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		mock := newMock()
+
+		_, _, err := run(mock, true)
+		assert.Check(t, errors.Is(err, runner.ErrInstanceDrainNotSupported))
+		assert.Check(t, cmp.ErrorContains(err, "use --revoke-token"))
+		assert.Check(t, cmp.Len(mock.tokens, 2))
+	})
+
+	t.Run("revoke token", func(t *testing.T) {
+		mock := newMock()
+
+		out, asked, err := run(mock, true, "--revoke-token", "--resource-class", "my-namespace/my-resource-class")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(asked, []string{"Are you sure you want to revoke token a (token-a) used by host-a?"}))
+		assert.Check(t, cmp.DeepEqual(mock.tokens, []runner.Token{
+			{ID: "token-b", ResourceClass: "my-namespace/my-resource-class", Nickname: "b"},
+		}))
+		assert.Check(t, cmp.Equal(out, "Revoked token a (token-a) used by host-a\n"+
+			"Runner instance my-instance can't claim new tasks, create a new token to bring it back\n"))
+	})
+
+	t.Run("revoke declined", func(t *testing.T) {
+		mock := newMock()
+
+		_, _, err := run(mock, false, "--revoke-token", "--resource-class", "my-namespace/my-resource-class")
+		assert.Error(t, err, "not revoking token token-a")
+		assert.Check(t, cmp.Len(mock.tokens, 2))
+	})
+
+	t.Run("shared token", func(t *testing.T) {
+		mock := newMock()
+		mock.usage["token-a"] = append(mock.usage["token-a"], runner.TokenUsage{Hostname: "host-b"})
+
+		_, _, err := run(mock, true, "--revoke-token", "--resource-class", "my-namespace/my-resource-class", "--force")
+		assert.Error(t, err, "token a (token-a) is used by other hosts as well as host-a, not revoking it")
+		assert.Check(t, cmp.Len(mock.tokens, 2))
+	})
+
+	t.Run("revoke without resource-class", func(t *testing.T) {
+		_, _, err := run(newMock(), true, "--revoke-token")
+		assert.Error(t, err, "--revoke-token needs --resource-class, to find the token of the instance")
+	})
+}
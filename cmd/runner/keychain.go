@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keychainService names the runner tokens the CLI keeps in the OS keychain.
+// Each is stored under its token ID.
+const keychainService = "circleci-cli-runner-token"
+
+// errKeychainNotFound is returned when the keychain has no secret for a token.
+var errKeychainNotFound = errors.New("not found in the keychain")
+
+// These are overridden in tests.
+var (
+	keychainSet    = setKeychainSecret
+	keychainGet    = getKeychainSecret
+	keychainDelete = deleteKeychainSecret
+)
+
+// keychainCommandError describes a keychain tool failing, with what it printed.
+func keychainCommandError(tool string, err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, msg)
+	}
+	return fmt.Errorf("%s failed: %w", tool, err)
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// securityItemNotFound is the exit status of security(1) when there is no
+// matching keychain item.
+const securityItemNotFound = 44
+
+// setKeychainSecret adds the secret to the login keychain, replacing any that
+// is there. The secret is passed to security(1) on stdin, rather than as an
+// argument that other users could see.
+func setKeychainSecret(account, secret string) error {
+	for _, s := range []string{account, secret} {
+		if strings.ContainsAny(s, "\"\\\n") {
+			return errors.New("can't store a value containing quotes, backslashes or newlines in the keychain")
+		}
+	}
+	cmd := exec.Command("security", "-i") // #nosec
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", keychainService, account, secret))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return keychainCommandError("security", err, out)
+	}
+	return nil
+}
+
+func getKeychainSecret(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output() // #nosec
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFound {
+		return "", errKeychainNotFound
+	}
+	if err != nil {
+		return "", keychainCommandError("security", err, nil)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func deleteKeychainSecret(account string) error {
+	out, err := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account).CombinedOutput() // #nosec
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFound {
+		return errKeychainNotFound
+	}
+	if err != nil {
+		return keychainCommandError("security", err, out)
+	}
+	return nil
+}
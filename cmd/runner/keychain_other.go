@@ -0,0 +1,45 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// setKeychainSecret stores the secret with libsecret, through secret-tool(1),
+// which reads it from stdin.
+func setKeychainSecret(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=CircleCI runner token "+account,
+		"service", keychainService, "account", account) // #nosec
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return keychainCommandError("secret-tool", err, out)
+	}
+	return nil
+}
+
+func getKeychainSecret(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output() // #nosec
+	// secret-tool exits with 1 and prints nothing when there is no secret.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(out) == 0 {
+		return "", errKeychainNotFound
+	}
+	if err != nil {
+		return "", keychainCommandError("secret-tool", err, nil)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// deleteKeychainSecret removes the secret. secret-tool doesn't report whether
+// there was one, so a missing secret isn't an error.
+func deleteKeychainSecret(account string) error {
+	out, err := exec.Command("secret-tool", "clear", "service", keychainService, "account", account).CombinedOutput() // #nosec
+	if err != nil {
+		return keychainCommandError("secret-tool", err, out)
+	}
+	return nil
+}
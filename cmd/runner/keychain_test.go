@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// fakeKeychain keeps secrets in memory, in place of the OS keychain.
+type fakeKeychain struct {
+	secrets map[string]string
+	err     error
+}
+
+var testKeychain = &fakeKeychain{secrets: map[string]string{}}
+
+func (k *fakeKeychain) set(account, secret string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.secrets[account] = secret
+	return nil
+}
+
+func (k *fakeKeychain) get(account string) (string, error) {
+	if k.err != nil {
+		return "", k.err
+	}
+	secret, ok := k.secrets[account]
+	if !ok {
+		return "", errKeychainNotFound
+	}
+	return secret, nil
+}
+
+func (k *fakeKeychain) delete(account string) error {
+	if k.err != nil {
+		return k.err
+	}
+	if _, ok := k.secrets[account]; !ok {
+		return errKeychainNotFound
+	}
+	delete(k.secrets, account)
+	return nil
+}
+
+func (k *fakeKeychain) reset() {
+	k.secrets = map[string]string{}
+	k.err = nil
+}
+
+func Test_TokenKeychain(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: mock}, newTokenCommand, args...)
+	}
+	t.Cleanup(testKeychain.reset)
+
+	t.Run("save, show and delete", func(t *testing.T) {
+		testKeychain.reset()
+		mock := &runnerMock{}
+
+		_, stderr, err := run(mock, "create", "my-namespace/my-resource-class", "my-token", "--save-keychain", "--output", "json")
+		assert.NilError(t, err)
+		assert.Assert(t, cmp.Len(mock.tokens, 1))
+		id := mock.tokens[0].ID
+		assert.Check(t, cmp.DeepEqual(testKeychain.secrets, map[string]string{id: "fake-token"}))
+		assert.Check(t, cmp.Contains(stderr, "Saved token "+id+" to the keychain, show it with `circleci runner token show "+id+"`\n"))
+
+		out, _, err := run(mock, "show", id)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "fake-token\n"))
+
+		_, _, err = run(mock, "delete", id)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(testKeychain.secrets, 0))
+	})
+
+	t.Run("not saved", func(t *testing.T) {
+		testKeychain.reset()
+
+		_, _, err := run(&runnerMock{}, "show", "9e12ad09-527d-482c-b7ce-1a2fd20d1b9b")
+		assert.Error(t, err, "token 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b is not found in the keychain, "+
+			"save tokens there with `circleci runner token create --save-keychain`")
+	})
+
+	t.Run("keychain unavailable", func(t *testing.T) {
+		testKeychain.reset()
+		testKeychain.err = errors.New("secret-tool failed: exec: \"secret-tool\": executable file not found in $PATH")
+		mock := &runnerMock{}
+
+		_, _, err := run(mock, "create", "my-namespace/my-resource-class", "my-token", "--save-keychain")
+		assert.ErrorContains(t, err, "failed to save token")
+		assert.ErrorContains(t, err, "delete it with `circleci runner token delete")
+
+		mock.tokens = []runner.Token{{ID: "9e12ad09-527d-482c-b7ce-1a2fd20d1b9b", ResourceClass: "my-namespace/my-resource-class"}}
+		_, stderr, err := run(mock, "delete", "9e12ad09-527d-482c-b7ce-1a2fd20d1b9b")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stderr, "Token 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b was deleted, but not removed from the keychain"))
+	})
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// Secrets are kept in the Windows Credential Manager as generic credentials.
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32   = syscall.NewLazyDLL("advapi32.dll")
+	credWrite  = advapi32.NewProc("CredWriteW")
+	credRead   = advapi32.NewProc("CredReadW")
+	credDelete = advapi32.NewProc("CredDeleteW")
+	credFree   = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credentialTarget(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keychainService + ":" + account)
+}
+
+func setKeychainSecret(account, secret string) error {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return errors.New("can't store an empty secret in the Credential Manager")
+	}
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if ok, _, err := credWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); ok == 0 {
+		return keychainCommandError("CredWrite", err, nil)
+	}
+	return nil
+}
+
+func getKeychainSecret(account string) (string, error) {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	ok, _, err := credRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", errKeychainNotFound
+		}
+		return "", keychainCommandError("CredRead", err, nil)
+	}
+	defer credFree.Call(uintptr(unsafe.Pointer(cred))) // #nosec
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), nil
+}
+
+func deleteKeychainSecret(account string) error {
+	target, err := credentialTarget(account)
+	if err != nil {
+		return err
+	}
+	if ok, _, err := credDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); ok == 0 {
+		if errors.Is(err, errorNotFound) {
+			return errKeychainNotFound
+		}
+		return keychainCommandError("CredDelete", err, nil)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseLabels parses labels given as key=value.
+func parseLabels(kvs []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, kv := range kvs {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("expected a label in the form key=value, got %q", kv)
+		}
+		labels[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return labels, nil
+}
+
+// labelSelector is a comma-separated list of requirements that labels must
+// all meet: key=value, key!=value, or just key for the label to be set.
+type labelSelector []labelRequirement
+
+type labelRequirement struct {
+	key, value string
+	// op is one of =, != or empty for the key to be set.
+	op string
+}
+
+func parseLabelSelector(s string) (labelSelector, error) {
+	var selector labelSelector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		var r labelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			r = labelRequirement{key: kv[0], value: kv[1], op: "!="}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			r = labelRequirement{key: kv[0], value: kv[1], op: "="}
+		default:
+			r = labelRequirement{key: part}
+		}
+		r.key = strings.TrimSpace(r.key)
+		if r.key == "" {
+			return nil, fmt.Errorf("invalid selector %q, expected requirements such as team=mobile, env!=prod or owner", s)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.key]
+		switch r.op {
+		case "=":
+			if !ok || value != r.value {
+				return false
+			}
+		case "!=":
+			if ok && value == r.value {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func newLogsCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	var taskID, output string
+	var follow bool
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "logs <resource-class>",
+		Short: "Show the output of tasks run by a resource-class",
+		Long: `Show the output of tasks run by a resource-class.
+
+Prints the logs of every task of the resource-class, each line prefixed by its
+task ID, or of a single task with --task. With --follow, carries on printing
+new lines as the tasks log them until interrupted.`,
+		Example: `  circleci runner logs my-namespace/my-resource-class --follow
+  circleci runner logs my-namespace/my-resource-class --task 2f0bd2ff-1a39-4ec5-9e3d-6cf0b3a24c4d`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, expected one of text, json", output)
+			}
+			if follow && interval <= 0 {
+				return fmt.Errorf("invalid interval %s, expected a positive duration", interval)
+			}
+
+			ctx, cancel := interruptContext()
+			defer cancel()
+
+			get := func(pageToken string) (page *runner.TaskLogPage, err error) {
+				err = o.withTimeout("get task logs", o.timeout(listTimeout, 0), func(r running) (err error) {
+					page, err = r.GetTaskLogs(args[0], taskID, pageToken)
+					return err
+				})
+				return page, err
+			}
+			return readTaskLogs(ctx, get, follow, interval, func(l runner.TaskLogLine) error {
+				if output == "json" {
+					return json.NewEncoder(cmd.OutOrStdout()).Encode(l)
+				}
+				if taskID != "" {
+					_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", formatTime(l.Time), l.Message)
+					return err
+				}
+				_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s\n", formatTime(l.Time), l.TaskID, l.Message)
+				return err
+			})
+		},
+	}
+	cmd.PersistentFlags().StringVar(&taskID, "task", "", "Only show the logs of the task with this ID")
+	cmd.PersistentFlags().BoolVarP(&follow, "follow", "f", false, "Keep printing new lines until interrupted")
+	cmd.PersistentFlags().DurationVar(&interval, "interval", 2*time.Second, "How often to poll for new lines with --follow")
+	cmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("text", "json"),
+		"Output format, one of text or json (a line of JSON per log line)")
+	return cmd
+}
+
+// readTaskLogs calls emit for each line of the pages returned by get, until
+// there are no more. With follow it instead keeps polling every interval for
+// new lines until ctx is cancelled.
+func readTaskLogs(ctx context.Context, get func(pageToken string) (*runner.TaskLogPage, error),
+	follow bool, interval time.Duration, emit func(runner.TaskLogLine) error) error {
+	pageToken := ""
+	for {
+		page, err := get(pageToken)
+		if err != nil {
+			return err
+		}
+		for _, l := range page.Lines {
+			if err := emit(l); err != nil {
+				return err
+			}
+		}
+
+		// Fetch the next page straight away while there are lines to read. An
+		// empty page, or no new token, means we've caught up.
+		caughtUp := len(page.Lines) == 0 || page.NextPageToken == "" || page.NextPageToken == pageToken
+		if page.NextPageToken != "" {
+			pageToken = page.NextPageToken
+		}
+		if !caughtUp {
+			continue
+		}
+		if !follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_RunnerLogs(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	newMock := func() *runnerMock {
+		return &runnerMock{taskLogs: map[string]*runner.TaskLogPage{
+			"": {
+				Lines: []runner.TaskLogLine{
+					{Time: at, TaskID: "task-a", Message: "Starting task"},
+				},
+				NextPageToken: "page-2",
+			},
+			"page-2": {
+				Lines: []runner.TaskLogLine{
+					{Time: at.Add(time.Second), TaskID: "task-a", Message: "Task finished"},
+				},
+				NextPageToken: "page-3",
+			},
+		}}
+	}
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newLogsCommand, append([]string{"my-namespace/my-resource-class"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("all tasks", func(t *testing.T) {
+		mock := newMock()
+
+		out, err := run(mock)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "2021-06-01T12:00:00Z task-a Starting task\n2021-06-01T12:00:01Z task-a Task finished\n"))
+		assert.Check(t, cmp.DeepEqual(mock.taskLogRequests, []string{"", "page-2", "page-3"}))
+	})
+
+	t.Run("one task", func(t *testing.T) {
+		out, err := run(newMock(), "--task", "task-a")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "2021-06-01T12:00:00Z Starting task\n2021-06-01T12:00:01Z Task finished\n"))
+	})
+
+	t.Run("as json", func(t *testing.T) {
+		out, err := run(newMock(), "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, `{"time":"2021-06-01T12:00:00Z","task_id":"task-a","message":"Starting task"}`+"\n"))
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		_, err := run(newMock(), "--follow", "--interval", "0s")
+		assert.Error(t, err, "invalid interval 0s, expected a positive duration")
+	})
+}
+
+func Test_ReadTaskLogsFollow(t *testing.T) {
+	pages := []*runner.TaskLogPage{
+		{Lines: []runner.TaskLogLine{{Message: "first"}}, NextPageToken: "page-2"},
+		{NextPageToken: "page-2"},
+		{Lines: []runner.TaskLogLine{{Message: "second"}}, NextPageToken: "page-3"},
+	}
+	var tokens []string
+	get := func(pageToken string) (*runner.TaskLogPage, error) {
+		tokens = append(tokens, pageToken)
+		page := pages[0]
+		if len(pages) > 1 {
+			pages = pages[1:]
+		}
+		return page, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var messages []string
+	err := readTaskLogs(ctx, get, true, time.Millisecond, func(l runner.TaskLogLine) error {
+		messages = append(messages, l.Message)
+		if l.Message == "second" {
+			cancel()
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(messages[:2], []string{"first", "second"}))
+	assert.Check(t, cmp.DeepEqual(tokens[:3], []string{"", "page-2", "page-2"}))
+}
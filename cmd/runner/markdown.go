@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMarkdownTable renders header and rows as a GitHub-flavored Markdown
+// table, for pasting into wikis and issues.
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) error {
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	lines := []string{markdownRow(header), markdownRow(separator)}
+	for _, row := range rows {
+		lines = append(lines, markdownRow(row))
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = escapeMarkdownCell(c)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+// escapeMarkdownCell keeps a value inside its cell: pipes would start a new
+// cell and newlines a new row.
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
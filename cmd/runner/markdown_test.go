@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_MarkdownOutput(t *testing.T) {
+	t.Run("resource-class list", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ResourceClass: "my-namespace/rc-a", Description: "linux | amd64"},
+			{ResourceClass: "my-namespace/rc-b", Description: "first line\nsecond line", Type: "container"},
+		}}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newResourceClassCommand, "list", "my-namespace", "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, `| Resource Class | Description | Type |
+| --- | --- | --- |
+| my-namespace/rc-a | linux \| amd64 |  |
+| my-namespace/rc-b | first line<br>second line | container |
+`))
+	})
+
+	t.Run("token list", func(t *testing.T) {
+		defer func(f func() time.Time) { timeNow = f }(timeNow)
+		timeNow = func() time.Time { return time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC) }
+
+		mock := runnerMock{tokens: []runner.Token{
+			{ID: "my-id", ResourceClass: "my-namespace/rc-a", Nickname: "a|b", CreatedAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)},
+		}}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newTokenCommand, "list", "my-namespace/rc-a", "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, `| ID | Nickname | Created At | Age |
+| --- | --- | --- | --- |
+| my-id | a\|b | 2021-06-01T12:00:00Z | 92d |
+`))
+	})
+
+	t.Run("instance list respects columns", func(t *testing.T) {
+		mock := runnerMock{instances: []runner.RunnerInstance{
+			{Name: "a", ResourceClass: "my-namespace/rc-a", Hostname: "host|a", Version: "1.0.0"},
+		}}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--output", "markdown", "--columns", "hostname,version")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, `| Hostname | Version |
+| --- | --- |
+| host\|a | 1.0.0 |
+`))
+	})
+
+	t.Run("instance list grouped", func(t *testing.T) {
+		mock := runnerMock{instances: []runner.RunnerInstance{
+			{Name: "a", ResourceClass: "my-namespace/rc-a", Version: "1.0.0"},
+		}}
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newRunnerInstanceCommand, "list", "my-namespace", "--output", "markdown", "--group-by", "version")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout, `| Version | Count | Online | Offline |
+| --- | --- | --- | --- |
+| 1.0.0 | 1 | 0 | 1 |
+`))
+	})
+}
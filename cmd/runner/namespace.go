@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// namespaceConcurrency bounds how many resource-classes have their tokens
+// listed at once when summarising a namespace.
+const namespaceConcurrency = 4
+
+func newNamespaceCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "namespace",
+		Short: "Operate on runner namespaces",
+	}
+
+	var namespace, output string
+	describeCmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Summarise the resource-classes, tokens and instances of a namespace",
+		Example: `  circleci runner namespace describe --namespace my-namespace
+  circleci runner namespace describe --namespace my-namespace --output json`,
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if output != "table" && output != "json" && output != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", output)
+			}
+
+			var summary *namespaceSummary
+			err := o.withTimeout("describe namespace", o.timeout(listTimeout, 0), func(r running) (err error) {
+				summary, err = summariseNamespace(r, namespace)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if output != "table" {
+				return writeStructured(cmd.OutOrStdout(), output, summary)
+			}
+
+			table := newNamespaceSummaryTable(cmd.OutOrStdout())
+			defer table.Render()
+			table.Append(namespaceSummaryRow(*summary))
+			return nil
+		},
+	}
+	describeCmd.PersistentFlags().StringVar(&namespace, "namespace", "", "Namespace to describe")
+	describeCmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	_ = describeCmd.MarkPersistentFlagRequired("namespace")
+	cmd.AddCommand(describeCmd)
+
+	return cmd
+}
+
+type namespaceSummary struct {
+	Namespace       string `json:"namespace"`
+	ResourceClasses int    `json:"resource_classes"`
+	Tokens          int    `json:"tokens"`
+	Instances       int    `json:"instances"`
+	Online          int    `json:"online"`
+	Offline         int    `json:"offline"`
+}
+
+func summariseNamespace(r running, namespace string) (*namespaceSummary, error) {
+	rcs, err := r.GetResourceClassesByNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := countTokens(r, rcs)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, _, err := r.GetRunnerInstancesWithOptions(namespace, runner.InstanceListOptions{PageSize: defaultInstancePageSize})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &namespaceSummary{
+		Namespace:       namespace,
+		ResourceClasses: len(rcs),
+		Tokens:          tokens,
+		Instances:       len(instances),
+	}
+	now := timeNow()
+	for _, i := range instances {
+		if instanceStatus(i, now) == "online" {
+			summary.Online++
+		} else {
+			summary.Offline++
+		}
+	}
+	return summary, nil
+}
+
+// countTokens totals the tokens of the given resource-classes, listing at most
+// namespaceConcurrency of them at a time. If listing fails, the error for the
+// earliest resource-class is returned, whichever finished first.
+func countTokens(r running, rcs []runner.ResourceClass) (int, error) {
+	var wg sync.WaitGroup
+	counts := make([]int, len(rcs))
+	errs := make([]error, len(rcs))
+
+	sem := make(chan struct{}, namespaceConcurrency)
+	for i, rc := range rcs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, resourceClass string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			tokens, err := r.GetRunnerTokensByResourceClass(resourceClass)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to list tokens of %s: %w", resourceClass, err)
+				return
+			}
+			counts[i] = len(tokens)
+		}(i, rc.ResourceClass)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range rcs {
+		if errs[i] != nil {
+			return 0, errs[i]
+		}
+		total += counts[i]
+	}
+	return total, nil
+}
+
+func newNamespaceSummaryTable(writer io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(namespaceSummaryHeader())
+	return table
+}
+
+func namespaceSummaryHeader() []string {
+	return []string{"Namespace", "Resource Classes", "Tokens", "Instances", "Online", "Offline"}
+}
+
+func namespaceSummaryRow(s namespaceSummary) []string {
+	return []string{
+		s.Namespace,
+		strconv.Itoa(s.ResourceClasses),
+		strconv.Itoa(s.Tokens),
+		strconv.Itoa(s.Instances),
+		strconv.Itoa(s.Online),
+		strconv.Itoa(s.Offline),
+	}
+}
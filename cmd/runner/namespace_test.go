@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// failingTokensMock fails to list the tokens of some resource-classes.
+type failingTokensMock struct {
+	*runnerMock
+	failing map[string]bool
+}
+
+func (f failingTokensMock) WithContext(ctx context.Context) running {
+	f.runnerMock.WithContext(ctx)
+	return f
+}
+
+func (f failingTokensMock) GetRunnerTokensByResourceClass(resourceClass string) ([]runner.Token, error) {
+	if f.failing[resourceClass] {
+		return nil, errors.New("boom")
+	}
+	return f.runnerMock.GetRunnerTokensByResourceClass(resourceClass)
+}
+
+func Test_NamespaceDescribe(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	online := now.Add(-time.Minute)
+	offline := now.Add(-time.Hour)
+
+	newMock := func() *runnerMock {
+		return &runnerMock{
+			resourceClasses: []runner.ResourceClass{
+				{ID: "1", ResourceClass: "my-namespace/rc-a"},
+				{ID: "2", ResourceClass: "my-namespace/rc-b"},
+				{ID: "3", ResourceClass: "my-namespace/rc-c"},
+				{ID: "4", ResourceClass: "other-namespace/rc-a"},
+			},
+			tokens: []runner.Token{
+				{ID: "t1", ResourceClass: "my-namespace/rc-a"},
+				{ID: "t2", ResourceClass: "my-namespace/rc-a"},
+				{ID: "t3", ResourceClass: "my-namespace/rc-b"},
+				{ID: "t4", ResourceClass: "other-namespace/rc-a"},
+			},
+			instances: []runner.RunnerInstance{
+				{Name: "a", ResourceClass: "my-namespace/rc-a", LastConnected: &online},
+				{Name: "b", ResourceClass: "my-namespace/rc-a", LastConnected: &offline},
+				{Name: "c", ResourceClass: "my-namespace/rc-b"},
+				{Name: "d", ResourceClass: "other-namespace/rc-a", LastConnected: &online},
+			},
+		}
+	}
+
+	run := func(r running, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: r}, newNamespaceCommand, append([]string{"describe", "--namespace", "my-namespace"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("json", func(t *testing.T) {
+		out, err := run(newMock(), "--output", "json")
+		assert.NilError(t, err)
+
+		var summary namespaceSummary
+		assert.NilError(t, json.Unmarshal([]byte(out), &summary))
+		assert.Check(t, cmp.DeepEqual(summary, namespaceSummary{
+			Namespace:       "my-namespace",
+			ResourceClasses: 3,
+			Tokens:          3,
+			Instances:       3,
+			Online:          1,
+			Offline:         2,
+		}))
+	})
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run(newMock())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "RESOURCE CLASSES"))
+		assert.Check(t, cmp.Contains(out, "| my-namespace |"))
+	})
+
+	t.Run("empty namespace", func(t *testing.T) {
+		out, err := run(&runnerMock{}, "--output", "json")
+		assert.NilError(t, err)
+
+		var summary namespaceSummary
+		assert.NilError(t, json.Unmarshal([]byte(out), &summary))
+		assert.Check(t, cmp.DeepEqual(summary, namespaceSummary{Namespace: "my-namespace"}))
+	})
+
+	t.Run("token listing fails", func(t *testing.T) {
+		_, err := run(failingTokensMock{runnerMock: newMock(), failing: map[string]bool{"my-namespace/rc-b": true}})
+		assert.Error(t, err, "failed to list tokens of my-namespace/rc-b: boom")
+	})
+
+	t.Run("output is stable", func(t *testing.T) {
+		for _, output := range []string{"table", "json"} {
+			first, err := run(newMock(), "--output", output)
+			assert.NilError(t, err)
+			second, err := run(newMock(), "--output", output)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(second, first))
+		}
+
+		// Listing tokens concurrently must still report the same error every time.
+		failing := map[string]bool{"my-namespace/rc-b": true, "my-namespace/rc-c": true}
+		for i := 0; i < 10; i++ {
+			_, err := run(failingTokensMock{runnerMock: newMock(), failing: failing})
+			assert.Error(t, err, "failed to list tokens of my-namespace/rc-b: boom")
+		}
+	})
+
+	t.Run("unsupported output", func(t *testing.T) {
+		_, err := run(newMock(), "--output", "csv")
+		assert.Error(t, err, `unsupported output format "csv", expected one of table, json, yaml`)
+	})
+}
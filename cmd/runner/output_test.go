@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_WriteYAML(t *testing.T) {
+	connected := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("uses the json field names in order", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		err := writeYAML(b, []runner.RunnerInstance{
+			{ResourceClass: "my-namespace/rc-a", Hostname: "host-a", Name: "a", LastConnected: &connected, Version: "1.0"},
+		})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(b.String(), `- resource_class: my-namespace/rc-a
+  hostname: host-a
+  name: a
+  first_connected: null
+  last_connected: "2021-06-01T12:00:00Z"
+  last_used: null
+  version: "1.0"
+`))
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NilError(t, writeYAML(b, []runner.Token{}))
+		assert.Check(t, cmp.Equal(b.String(), "[]\n"))
+	})
+}
@@ -1,7 +1,12 @@
 package runner
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -16,28 +21,80 @@ func newResourceClassCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 	}
 
 	genToken := false
+	ifNotExists := false
+	var createOutput string
+	var createLabels []string
 	createCmd := &cobra.Command{
-		Use:     "create <resource-class> <description>",
-		Short:   "Create a resource-class",
+		Use:   "create <resource-class> <description>",
+		Short: "Create a resource-class",
+		Example: `  circleci runner resource-class create my-namespace/my-resource-class "Linux hosts" --generate-token
+  circleci runner resource-class create my-namespace/my-resource-class "Linux hosts" --label team=mobile --label env=prod`,
 		Args:    cobra.ExactArgs(2),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
+			if createOutput != "table" && createOutput != "json" && createOutput != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", createOutput)
+			}
+			labels, err := parseLabels(createLabels)
+			if err != nil {
+				return err
+			}
+
+			if ifNotExists {
+				var existing *runner.ResourceClass
+				err := o.withTimeout("get resource-class", o.timeout(listTimeout, 0), func(r running) (err error) {
+					existing, err = r.GetResourceClassByName(args[0])
+					return err
+				})
+				if err == nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Resource class %s already exists, not creating it\n", existing.ResourceClass)
+					if createOutput != "table" {
+						return writeStructured(cmd.OutOrStdout(), createOutput, createdResourceClass{ResourceClass: existing})
+					}
+					table := newResourceClassTable(cmd.OutOrStdout())
+					defer table.Render()
+					appendResourceClass(table, *existing)
+					return nil
+				}
+				if !errors.Is(err, runner.ErrNotFound) {
+					return err
+				}
+			}
+
 			cmd.PrintErr(terms)
 
-			rc, err := o.r.CreateResourceClass(args[0], args[1])
+			var rc *runner.ResourceClass
+			err = o.withTimeout("create resource-class", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				rc, err = r.CreateResourceClassWithOptions(args[0], args[1], runner.ResourceClassOptions{Labels: labels})
+				return err
+			})
+			if errors.Is(err, runner.ErrResourceClassLabelsNotSupported) {
+				return fmt.Errorf("%w: resource-class %s was created without its labels", err, rc.ResourceClass)
+			}
 			if err != nil {
 				return err
 			}
-			table := newResourceClassTable(cmd.OutOrStdout())
-			defer table.Render()
-			appendResourceClass(table, *rc)
 
-			if !genToken {
-				return nil
+			var token *runner.Token
+			if genToken {
+				err = o.withTimeout("create token", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+					token, err = r.CreateToken(args[0], "default")
+					return err
+				})
 			}
 
-			token, err := o.r.CreateToken(args[0], "default")
-			if err != nil {
+			if createOutput != "table" {
+				// Write out the resource-class even if creating its token failed.
+				if werr := writeStructured(cmd.OutOrStdout(), createOutput, createdResourceClass{ResourceClass: rc, Token: token}); werr != nil {
+					return werr
+				}
+				return err
+			}
+
+			table := newResourceClassTable(cmd.OutOrStdout())
+			defer table.Render()
+			appendResourceClass(table, *rc)
+			if token == nil {
 				return err
 			}
 			return generateConfig(*token, cmd.OutOrStdout())
@@ -45,56 +102,526 @@ func newResourceClassCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 	}
 	createCmd.PersistentFlags().BoolVar(&genToken, "generate-token", false,
 		"Generate a default token")
+	createCmd.PersistentFlags().BoolVar(&ifNotExists, "if-not-exists", false,
+		"Succeed without changes if the resource-class already exists")
+	createCmd.PersistentFlags().StringSliceVar(&createLabels, "label", nil,
+		"Label to attach, as key=value. Can be repeated.")
+	createCmd.PersistentFlags().StringVar(&createOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
 	cmd.AddCommand(createCmd)
 
-	cmd.AddCommand(&cobra.Command{
-		Use:     "delete <resource-class>",
-		Short:   "Delete a resource-class",
+	var deleteDryRun, deleteForce bool
+	deleteCmd := &cobra.Command{
+		Use:   "delete <resource-class>",
+		Short: "Delete a resource-class",
+		Long: `Delete a resource-class.
+
+The tokens of the resource-class stop working, and so do the runners using them.
+These are listed before asking for confirmation, unless --force is given.`,
 		Aliases: []string{"rm"},
+		Example: `  circleci runner resource-class delete my-namespace/my-resource-class
+  circleci runner resource-class delete my-namespace/my-resource-class --force
+  circleci runner resource-class delete my-namespace/my-resource-class --dry-run`,
 		Args:    cobra.ExactArgs(1),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			rc, err := o.r.GetResourceClassByName(args[0])
+			var rc *runner.ResourceClass
+			var tokens []runner.Token
+			var instances []runner.RunnerInstance
+			err := o.withTimeout("delete resource-class", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				if rc, err = r.GetResourceClassByName(args[0]); err != nil || deleteForce || deleteDryRun {
+					return err
+				}
+				if tokens, err = r.GetRunnerTokensByResourceClass(rc.ResourceClass); err != nil {
+					return err
+				}
+				instances, err = r.GetRunnerInstances(rc.ResourceClass)
+				return err
+			})
 			if err != nil {
 				return err
 			}
-			return o.r.DeleteResourceClass(rc.ID)
+
+			if deleteDryRun {
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "Would delete resource-class %s (%s)\n", rc.ResourceClass, rc.ID)
+				return err
+			}
+			if !deleteForce && !o.askToConfirm(resourceClassDeleteMessage(*rc, tokens, instances)) {
+				return fmt.Errorf("not deleting resource-class %s", rc.ResourceClass)
+			}
+
+			return o.withTimeout("delete resource-class", o.timeout(mutateTimeout, 0), func(r running) error {
+				return r.DeleteResourceClass(rc.ID)
+			})
 		},
-	})
+	}
+	deleteCmd.PersistentFlags().BoolVar(&deleteDryRun, "dry-run", false,
+		"Print the resource-class that would be deleted, without deleting it")
+	deleteCmd.PersistentFlags().BoolVarP(&deleteForce, "force", "f", false,
+		"Delete the resource-class without asking for confirmation")
+	cmd.AddCommand(deleteCmd)
 
-	cmd.AddCommand(&cobra.Command{
-		Use:     "list <namespace>",
-		Short:   "List resource-classes for a namespace",
-		Aliases: []string{"ls"},
+	var description, updateOutput string
+	var updateLabels, removeLabels []string
+	updateCmd := &cobra.Command{
+		Use:   "update <resource-class>",
+		Short: "Change the description or labels of a resource-class",
+		Long: `Change the description or labels of a resource-class.
+
+The resource-class keeps its ID, so its tokens carry on working.`,
+		Example: `  circleci runner resource-class update my-namespace/my-resource-class --description "Linux hosts in the build lab"
+  circleci runner resource-class update my-namespace/my-resource-class --label team=mobile --remove-label owner`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(c *cobra.Command, args []string) error {
+			if updateOutput != "table" && updateOutput != "json" && updateOutput != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", updateOutput)
+			}
+			updateDescription := c.Flags().Changed("description")
+			if !updateDescription && len(updateLabels) == 0 && len(removeLabels) == 0 {
+				return errors.New("nothing to update, expected --description, --label or --remove-label")
+			}
+			set, err := parseLabels(updateLabels)
+			if err != nil {
+				return err
+			}
+
+			var rc *runner.ResourceClass
+			err = o.withTimeout("update resource-class", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				if rc, err = r.GetResourceClassByName(args[0]); err != nil {
+					return err
+				}
+				if updateDescription {
+					if rc, err = r.UpdateResourceClass(rc.ID, description); err != nil {
+						return err
+					}
+				}
+				if len(set) == 0 && len(removeLabels) == 0 {
+					return nil
+				}
+
+				labels := map[string]string{}
+				for k, v := range rc.Labels {
+					labels[k] = v
+				}
+				for k, v := range set {
+					labels[k] = v
+				}
+				for _, k := range removeLabels {
+					delete(labels, k)
+				}
+				if err = r.SetResourceClassLabels(rc.ID, labels); err != nil {
+					return err
+				}
+				rc.Labels = labels
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if updateOutput != "table" {
+				return writeStructured(cmd.OutOrStdout(), updateOutput, rc)
+			}
+			table := newResourceClassTable(cmd.OutOrStdout())
+			defer table.Render()
+			appendResourceClass(table, *rc)
+			return nil
+		},
+	}
+	updateCmd.PersistentFlags().StringVar(&description, "description", "", "New description of the resource-class")
+	updateCmd.PersistentFlags().StringSliceVar(&updateLabels, "label", nil,
+		"Label to set, as key=value. Can be repeated.")
+	updateCmd.PersistentFlags().StringSliceVar(&removeLabels, "remove-label", nil,
+		"Key of a label to remove. Can be repeated.")
+	updateCmd.PersistentFlags().StringVar(&updateOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	cmd.AddCommand(updateCmd)
+
+	var ttlID, ttl string
+	setTokenTTLCmd := &cobra.Command{
+		Use:   "set-token-ttl",
+		Short: "Set how long new tokens for a resource-class are valid for by default",
+		Long: `Set how long new tokens for a resource-class are valid for by default.
+
+Tokens created without --expiry expire after this long. A TTL of 0 removes the default.`,
+		Example: "  circleci runner resource-class set-token-ttl --id d8bc155b-5e91-4765-b327-0fa256f0229e --ttl 7d",
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			d, err := parseDays(ttl)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid TTL %q, expected a duration such as 72h or 7d", ttl)
+			}
+
+			err = o.withTimeout("set token TTL", o.timeout(mutateTimeout, 0), func(r running) error {
+				return r.SetResourceClassTokenTTL(ttlID, d)
+			})
+			if err != nil {
+				return err
+			}
+
+			if d == 0 {
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "Removed the default token TTL of resource-class %s\n", ttlID)
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "New tokens for resource-class %s will expire after %s\n", ttlID, d)
+			return err
+		},
+	}
+	setTokenTTLCmd.PersistentFlags().StringVar(&ttlID, "id", "", "ID of the resource-class")
+	setTokenTTLCmd.PersistentFlags().StringVar(&ttl, "ttl", "", "Default token lifetime, such as 72h or 7d")
+	_ = setTokenTTLCmd.MarkPersistentFlagRequired("id")
+	_ = setTokenTTLCmd.MarkPersistentFlagRequired("ttl")
+	cmd.AddCommand(setTokenTTLCmd)
+
+	var describeOutput string
+	describeCmd := &cobra.Command{
+		Use:   "describe <resource-class>",
+		Short: "Show a resource-class",
+		Example: `  circleci runner resource-class describe my-namespace/my-resource-class
+  circleci runner resource-class describe my-namespace/my-resource-class --output json
+  eval "$(circleci runner resource-class describe my-namespace/my-resource-class --output env)"`,
 		Args:    cobra.ExactArgs(1),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			rcs, err := o.r.GetResourceClassesByNamespace(args[0])
+			if describeOutput != "table" && describeOutput != "json" && describeOutput != "yaml" && describeOutput != "env" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml, env", describeOutput)
+			}
+
+			var rc *runner.ResourceClass
+			err := o.withTimeout("get resource-class", o.timeout(listTimeout, 0), func(r running) (err error) {
+				rc, err = r.GetResourceClassByName(args[0])
+				return err
+			})
 			if err != nil {
 				return err
 			}
 
+			if describeOutput == "env" {
+				return writeEnv(cmd.OutOrStdout(), resourceClassEnv(*rc))
+			}
+			if describeOutput != "table" {
+				return writeStructured(cmd.OutOrStdout(), describeOutput, rc)
+			}
 			table := newResourceClassTable(cmd.OutOrStdout())
 			defer table.Render()
-			for _, rc := range rcs {
-				appendResourceClass(table, rc)
+			appendResourceClass(table, *rc)
+			return nil
+		},
+	}
+	describeCmd.PersistentFlags().StringVar(&describeOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json, yaml or env")
+	cmd.AddCommand(describeCmd)
+
+	var listTimeoutFlag time.Duration
+	var listOutput, listType, listSelector string
+	var listNamespaces []string
+	listCmd := &cobra.Command{
+		Use:   "list [<namespace>]",
+		Short: "List resource-classes for a namespace",
+		Long: `List resource-classes for a namespace, or for several at once with --namespace.
+
+Where the API reports concurrency quotas, the table and markdown output show how
+many tasks each resource-class is running against its limit, and the namespace
+against its plan's, to explain why tasks are queuing. The json and yaml output
+list the resource-classes only.`,
+		Example: `  circleci runner resource-class list my-namespace
+  circleci runner resource-class list --namespace my-namespace,other-namespace --selector team=mobile`,
+		Aliases: []string{"ls"},
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if listOutput == "env" {
+				return errEnvOutputList
+			}
+			if listOutput != "table" && listOutput != "json" && listOutput != "yaml" && listOutput != "markdown" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml, markdown", listOutput)
+			}
+			if err := validateRunnerType(listType); err != nil {
+				return err
+			}
+			var selector labelSelector
+			if listSelector != "" {
+				var err error
+				if selector, err = parseLabelSelector(listSelector); err != nil {
+					return err
+				}
+			}
+			namespaces := listNamespaces
+			if len(args) == 1 {
+				namespaces = append(namespaces, args[0])
+			}
+			if (len(args) == 1 && len(listNamespaces) > 0) || len(namespaces) == 0 {
+				return errors.New("expected either a namespace argument or --namespace")
+			}
+
+			var listed []namespaceResourceClasses
+			err := o.withTimeout("list resource-classes", o.timeout(listTimeout, listTimeoutFlag), func(r running) (err error) {
+				listed, err = listResourceClasses(r, namespaces, listOutput != "json" && listOutput != "yaml")
+				return err
+			})
+			if err != nil {
+				return err
 			}
 
+			rcs := []runner.ResourceClass{}
+			quotas := map[string]*runner.Quotas{}
+			for _, l := range listed {
+				rcs = append(rcs, l.resourceClasses...)
+				if l.quotas != nil {
+					quotas[l.namespace] = l.quotas
+				}
+			}
+			if listType != "" {
+				rcs = filterResourceClassesByType(rcs, listType, cmd.ErrOrStderr())
+			}
+			if selector != nil {
+				rcs = filterResourceClassesByLabels(rcs, selector)
+			}
+
+			if listOutput == "json" || listOutput == "yaml" {
+				return writeStructured(cmd.OutOrStdout(), listOutput, rcs)
+			}
+
+			header := resourceClassHeader
+			if len(quotas) > 0 {
+				header = append(header[:len(header):len(header)], "Concurrency")
+			}
+			rows := make([][]string, len(rcs))
+			for i, rc := range rcs {
+				rows[i] = resourceClassRow(rc)
+				if len(quotas) == 0 {
+					continue
+				}
+				q := quotas[strings.SplitN(rc.ResourceClass, "/", 2)[0]]
+				if q == nil {
+					rows[i] = append(rows[i], "")
+					continue
+				}
+				rows[i] = append(rows[i], formatResourceClassQuota(*q, rc.ResourceClass))
+			}
+
+			// The plan concurrency of each namespace follows the table.
+			var plans []string
+			for _, l := range listed {
+				if l.quotas == nil {
+					continue
+				}
+				if len(listed) == 1 {
+					plans = append(plans, formatPlanQuota(*l.quotas))
+					continue
+				}
+				plans = append(plans, fmt.Sprintf("%s: %s", l.namespace, formatPlanQuota(*l.quotas)))
+			}
+
+			if listOutput == "markdown" {
+				if err = writeMarkdownTable(cmd.OutOrStdout(), header, rows); err != nil {
+					return err
+				}
+				if len(plans) > 0 {
+					_, err = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", strings.Join(plans, "\n"))
+				}
+				return err
+			}
+
+			table := tablewriter.NewWriter(cmd.OutOrStdout())
+			table.SetHeader(header)
+			table.AppendBulk(rows)
+			table.Render()
+			for _, p := range plans {
+				if _, err = fmt.Fprintln(cmd.OutOrStdout(), p); err != nil {
+					return err
+				}
+			}
 			return nil
 		},
-	})
+	}
+	listCmd.PersistentFlags().StringSliceVar(&listNamespaces, "namespace", nil,
+		"Namespaces to list the resource-classes of, instead of the argument. Can be repeated.")
+	listCmd.PersistentFlags().DurationVar(&listTimeoutFlag, "list-timeout", 0,
+		"Time limit for listing resource-classes (default 30s)")
+	listCmd.PersistentFlags().StringVar(&listType, "type", "",
+		"Only list resource-classes for this type of runner, machine or container")
+	listCmd.PersistentFlags().StringVar(&listSelector, "selector", "",
+		"Only list resource-classes with matching labels, such as team=mobile,env!=prod")
+	listCmd.PersistentFlags().StringVar(&listOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json, yaml or markdown")
+	cmd.AddCommand(listCmd)
+
+	cmd.AddCommand(newResourceClassExportCommand(o, preRunE))
+	cmd.AddCommand(newResourceClassImportCommand(o, preRunE))
 
 	return cmd
 }
 
+// namespaceResourceClasses are the resource-classes of a namespace, with its
+// concurrency quotas if they were asked for and the API reports them.
+type namespaceResourceClasses struct {
+	namespace       string
+	resourceClasses []runner.ResourceClass
+	quotas          *runner.Quotas
+}
+
+// listResourceClasses lists the resource-classes of each namespace, at most
+// namespaceConcurrency of them at a time, in the order the namespaces were
+// given. Namespaces given twice are listed once. If listing fails, the error
+// for the earliest namespace is returned, whichever finished first.
+func listResourceClasses(r running, namespaces []string, withQuotas bool) ([]namespaceResourceClasses, error) {
+	seen := map[string]bool{}
+	var unique []string
+	for _, ns := range namespaces {
+		if !seen[ns] {
+			seen[ns] = true
+			unique = append(unique, ns)
+		}
+	}
+
+	var wg sync.WaitGroup
+	listed := make([]namespaceResourceClasses, len(unique))
+	errs := make([]error, len(unique))
+
+	sem := make(chan struct{}, namespaceConcurrency)
+	for i, ns := range unique {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, namespace string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			l := namespaceResourceClasses{namespace: namespace}
+			var err error
+			if l.resourceClasses, err = r.GetResourceClassesByNamespace(namespace); err != nil {
+				errs[i] = fmt.Errorf("failed to list resource-classes of %s: %w", namespace, err)
+				return
+			}
+			if withQuotas {
+				l.quotas, err = r.GetQuotas(namespace)
+				if err != nil && !errors.Is(err, runner.ErrQuotasNotSupported) {
+					errs[i] = fmt.Errorf("failed to get concurrency quotas of %s: %w", namespace, err)
+					return
+				}
+			}
+			listed[i] = l
+		}(i, ns)
+	}
+	wg.Wait()
+
+	for i := range unique {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+	}
+	return listed, nil
+}
+
+// createdResourceClass is what resource-class create writes as json or yaml:
+// the resource-class, and the token created for it with --generate-token.
+type createdResourceClass struct {
+	ResourceClass *runner.ResourceClass `json:"resource_class"`
+	Token         *runner.Token         `json:"token,omitempty"`
+}
+
+// filterResourceClassesByType returns the resource-classes for the given type
+// of runner, reporting any left out because the API didn't say what type they are.
+func filterResourceClassesByType(rcs []runner.ResourceClass, runnerType string, stderr io.Writer) []runner.ResourceClass {
+	matched := []runner.ResourceClass{}
+	var unknown []string
+	for _, rc := range rcs {
+		switch rc.Type {
+		case runnerType:
+			matched = append(matched, rc)
+		case "":
+			unknown = append(unknown, rc.ResourceClass)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Fprintf(stderr, "%d resource-class(es) left out as their runner type isn't known: %s\n", len(unknown), strings.Join(unknown, ", "))
+	}
+	return matched
+}
+
+func filterResourceClassesByLabels(rcs []runner.ResourceClass, selector labelSelector) []runner.ResourceClass {
+	matched := []runner.ResourceClass{}
+	for _, rc := range rcs {
+		if selector.matches(rc.Labels) {
+			matched = append(matched, rc)
+		}
+	}
+	return matched
+}
+
+// resourceClassDeleteMessage asks whether to delete a resource-class, listing
+// the tokens and instances that will stop working if it is.
+func resourceClassDeleteMessage(rc runner.ResourceClass, tokens []runner.Token, instances []runner.RunnerInstance) string {
+	var b strings.Builder
+	if len(tokens) > 0 || len(instances) > 0 {
+		fmt.Fprintf(&b, "Resource class %s has %d token(s) and %d runner instance(s) that will stop working:\n",
+			rc.ResourceClass, len(tokens), len(instances))
+		for _, t := range tokens {
+			fmt.Fprintf(&b, "  token %s (%s)\n", t.Nickname, t.ID)
+		}
+		for _, i := range instances {
+			fmt.Fprintf(&b, "  instance %s on %s\n", i.Name, i.Hostname)
+		}
+	}
+	fmt.Fprintf(&b, "Are you sure you want to delete resource-class %s?", rc.ResourceClass)
+	return b.String()
+}
+
+// formatResourceClassQuota describes how many tasks a resource-class is
+// running against its concurrency limit.
+func formatResourceClassQuota(quotas runner.Quotas, resourceClass string) string {
+	for _, q := range quotas.ResourceClasses {
+		if q.ResourceClass != resourceClass {
+			continue
+		}
+		if q.MaxConcurrency == 0 {
+			return fmt.Sprintf("%d running, no limit", q.RunningTasks)
+		}
+		if q.RunningTasks >= q.MaxConcurrency {
+			return fmt.Sprintf("%d of %d running, at limit", q.RunningTasks, q.MaxConcurrency)
+		}
+		return fmt.Sprintf("%d of %d running", q.RunningTasks, q.MaxConcurrency)
+	}
+	return "0 running, no limit"
+}
+
+// formatPlanQuota describes how many tasks the namespace is running against
+// the concurrency of its plan.
+func formatPlanQuota(quotas runner.Quotas) string {
+	switch {
+	case quotas.PlanConcurrency == 0:
+		return fmt.Sprintf("Plan concurrency: %d tasks running, no limit", quotas.RunningTasks)
+	case quotas.RunningTasks >= quotas.PlanConcurrency:
+		return fmt.Sprintf("Plan concurrency: %d of %d tasks running, at limit", quotas.RunningTasks, quotas.PlanConcurrency)
+	}
+	return fmt.Sprintf("Plan concurrency: %d of %d tasks running", quotas.RunningTasks, quotas.PlanConcurrency)
+}
+
+var resourceClassHeader = []string{"Resource Class", "Description", "Type"}
+
 func newResourceClassTable(writer io.Writer) *tablewriter.Table {
 	table := tablewriter.NewWriter(writer)
-	table.SetHeader([]string{"Resource Class", "Description"})
+	table.SetHeader(resourceClassHeader)
 	return table
 }
 
 func appendResourceClass(table *tablewriter.Table, rc runner.ResourceClass) {
-	table.Append([]string{rc.ResourceClass, rc.Description})
+	table.Append(resourceClassRow(rc))
+}
+
+func resourceClassRow(rc runner.ResourceClass) []string {
+	return []string{rc.ResourceClass, rc.Description, rc.Type}
+}
+
+func resourceClassEnv(rc runner.ResourceClass) []envVar {
+	return []envVar{
+		{"CIRCLECI_RESOURCE_CLASS", rc.ResourceClass},
+		{"CIRCLECI_RESOURCE_CLASS_ID", rc.ID},
+		{"CIRCLECI_RESOURCE_CLASS_DESCRIPTION", rc.Description},
+		{"CIRCLECI_RESOURCE_CLASS_TYPE", rc.Type},
+	}
 }
 
 const terms = "If you have not already agreed to Runner Terms in a signed Order, " +
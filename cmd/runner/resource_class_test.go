@@ -2,7 +2,10 @@ package runner
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +17,269 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/api/runner"
 )
 
+func Test_ResourceClassTokenTTL(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, append([]string{"set-token-ttl", "--id", "d8bc155b-5e91-4765-b327-0fa256f0229e"}, args...)...)
+		return stdout, err
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{resourceClasses: []runner.ResourceClass{
+			{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class"},
+		}}
+	}
+
+	t.Run("set", func(t *testing.T) {
+		mock := newMock()
+
+		out, err := run(mock, "--ttl", "7d")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.resourceClasses[0].DefaultTokenTTLSeconds, int64(7*24*60*60)))
+		assert.Check(t, cmp.Equal(out, "New tokens for resource-class d8bc155b-5e91-4765-b327-0fa256f0229e will expire after 168h0m0s\n"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mock := newMock()
+		mock.resourceClasses[0].DefaultTokenTTLSeconds = 3600
+
+		out, err := run(mock, "--ttl", "0")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.resourceClasses[0].DefaultTokenTTLSeconds, int64(0)))
+		assert.Check(t, cmp.Contains(out, "Removed the default token TTL"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := run(newMock(), "--ttl", "a week")
+		assert.Error(t, err, `invalid TTL "a week", expected a duration such as 72h or 7d`)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		mock := newMock()
+		mock.tokenTTLErr = runner.ErrTokenTTLNotSupported
+
+		_, err := run(mock, "--ttl", "7d")
+		assert.Error(t, err, "default token TTLs are not supported by this API")
+	})
+}
+
+func Test_ResourceClassDeleteDryRun(t *testing.T) {
+	mock := &runnerMock{resourceClasses: []runner.ResourceClass{
+		{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class"},
+	}}
+	stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, "delete", "my-namespace/my-resource-class", "--dry-run")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(stdout,
+		"Would delete resource-class my-namespace/my-resource-class (d8bc155b-5e91-4765-b327-0fa256f0229e)\n"))
+	assert.Check(t, cmp.Len(mock.resourceClasses, 1))
+}
+
+func Test_ResourceClassListType(t *testing.T) {
+	mock := &runnerMock{resourceClasses: []runner.ResourceClass{
+		{ResourceClass: "my-namespace/rc-a", Description: "Linux hosts", Type: "machine"},
+		{ResourceClass: "my-namespace/rc-b", Description: "Kubernetes", Type: "container"},
+		{ResourceClass: "my-namespace/rc-c", Description: "Legacy"},
+	}}
+	stdout, stderr, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, "list", "my-namespace", "--type", "machine", "--output", "markdown")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(stdout, `| Resource Class | Description | Type |
+| --- | --- | --- |
+| my-namespace/rc-a | Linux hosts | machine |
+`))
+	assert.Check(t, cmp.Equal(stderr, "1 resource-class(es) left out as their runner type isn't known: my-namespace/rc-c\n"))
+}
+
+func Test_ResourceClassListQuotas(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, append([]string{"list", "my-namespace"}, args...)...)
+		return stdout, err
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{
+			resourceClasses: []runner.ResourceClass{
+				{ResourceClass: "my-namespace/rc-a", Description: "Linux hosts", Type: "machine"},
+				{ResourceClass: "my-namespace/rc-b", Description: "Kubernetes", Type: "container"},
+				{ResourceClass: "my-namespace/rc-c", Description: "Legacy"},
+			},
+			quotas: &runner.Quotas{
+				PlanConcurrency: 30,
+				RunningTasks:    30,
+				ResourceClasses: []runner.ResourceClassQuota{
+					{ResourceClass: "my-namespace/rc-a", MaxConcurrency: 10, RunningTasks: 10},
+					{ResourceClass: "my-namespace/rc-b", MaxConcurrency: 25, RunningTasks: 20},
+				},
+			},
+		}
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		out, err := run(newMock(), "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `| Resource Class | Description | Type | Concurrency |
+| --- | --- | --- | --- |
+| my-namespace/rc-a | Linux hosts | machine | 10 of 10 running, at limit |
+| my-namespace/rc-b | Kubernetes | container | 20 of 25 running |
+| my-namespace/rc-c | Legacy |  | 0 running, no limit |
+
+Plan concurrency: 30 of 30 tasks running, at limit
+`))
+	})
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run(newMock())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "CONCURRENCY"))
+		assert.Check(t, cmp.Contains(out, "| 20 of 25 running"))
+		assert.Check(t, strings.HasSuffix(out, "\nPlan concurrency: 30 of 30 tasks running, at limit\n"))
+	})
+
+	t.Run("json lists resource-classes only", func(t *testing.T) {
+		out, err := run(newMock(), "--output", "json")
+		assert.NilError(t, err)
+		var rcs []runner.ResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &rcs))
+		assert.Check(t, cmp.Len(rcs, 3))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		mock := newMock()
+		mock.quotas = nil
+
+		out, err := run(mock, "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `| Resource Class | Description | Type |
+| --- | --- | --- |
+| my-namespace/rc-a | Linux hosts | machine |
+| my-namespace/rc-b | Kubernetes | container |
+| my-namespace/rc-c | Legacy |  |
+`))
+	})
+}
+
+func Test_ResourceClassListNamespaces(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, append([]string{"list"}, args...)...)
+		return stdout, err
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{resourceClasses: []runner.ResourceClass{
+			{ResourceClass: "ns-a/rc-1", Description: "a1"},
+			{ResourceClass: "ns-b/rc-1", Description: "b1"},
+			{ResourceClass: "ns-c/rc-1", Description: "c1"},
+			{ResourceClass: "ns-a/rc-2", Description: "a2"},
+			{ResourceClass: "ns-d/rc-1", Description: "d1"},
+		}}
+	}
+	names := func(t *testing.T, out string) []string {
+		var rcs []runner.ResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &rcs))
+		names := []string{}
+		for _, rc := range rcs {
+			names = append(names, rc.ResourceClass)
+		}
+		return names
+	}
+
+	t.Run("merged in namespace order", func(t *testing.T) {
+		out, err := run(newMock(), "--namespace", "ns-c,ns-a", "--namespace", "ns-c", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(names(t, out), []string{"ns-c/rc-1", "ns-a/rc-1", "ns-a/rc-2"}))
+	})
+
+	t.Run("listed concurrently", func(t *testing.T) {
+		mock := newMock()
+		mock.delay = 100 * time.Millisecond
+
+		start := time.Now()
+		out, err := run(mock, "--namespace", "ns-a,ns-b,ns-c,ns-d", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(names(t, out), 5))
+		assert.Check(t, time.Since(start) < 300*time.Millisecond, "took %s", time.Since(start))
+	})
+
+	t.Run("plan concurrency of each namespace", func(t *testing.T) {
+		mock := newMock()
+		mock.quotas = &runner.Quotas{PlanConcurrency: 10, RunningTasks: 4}
+
+		out, err := run(mock, "--namespace", "ns-a,ns-b", "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "\nns-a: Plan concurrency: 4 of 10 tasks running\nns-b: Plan concurrency: 4 of 10 tasks running\n"))
+	})
+
+	t.Run("argument and flag", func(t *testing.T) {
+		_, err := run(newMock(), "ns-a", "--namespace", "ns-b")
+		assert.Error(t, err, "expected either a namespace argument or --namespace")
+	})
+
+	t.Run("no namespace", func(t *testing.T) {
+		_, err := run(newMock())
+		assert.Error(t, err, "expected either a namespace argument or --namespace")
+	})
+}
+
+func Test_ResourceClassDeleteConfirm(t *testing.T) {
+	newMock := func() *runnerMock {
+		return &runnerMock{
+			resourceClasses: []runner.ResourceClass{
+				{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class"},
+			},
+			tokens: []runner.Token{
+				{ID: "token-1", ResourceClass: "my-namespace/my-resource-class", Nickname: "host-1"},
+			},
+			instances: []runner.RunnerInstance{
+				{Name: "runner-1", Hostname: "host-1", ResourceClass: "my-namespace/my-resource-class"},
+			},
+		}
+	}
+	run := func(mock *runnerMock, confirm bool, args ...string) ([]string, error) {
+		var asked []string
+		o := &runnerOpts{r: mock, confirm: func(message string) bool {
+			asked = append(asked, message)
+			return confirm
+		}}
+		_, _, err := executeRunnerCommand(t, o, newResourceClassCommand, append([]string{"delete", "my-namespace/my-resource-class"}, args...)...)
+		return asked, err
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		mock := newMock()
+
+		asked, err := run(mock, true)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(asked, []string{
+			"Resource class my-namespace/my-resource-class has 1 token(s) and 1 runner instance(s) that will stop working:\n" +
+				"  token host-1 (token-1)\n" +
+				"  instance runner-1 on host-1\n" +
+				"Are you sure you want to delete resource-class my-namespace/my-resource-class?",
+		}))
+		assert.Check(t, cmp.Len(mock.resourceClasses, 0))
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		mock := newMock()
+
+		_, err := run(mock, false)
+		assert.Error(t, err, "not deleting resource-class my-namespace/my-resource-class")
+		assert.Check(t, cmp.Len(mock.resourceClasses, 1))
+	})
+
+	t.Run("unused", func(t *testing.T) {
+		mock := newMock()
+		mock.tokens, mock.instances = nil, nil
+
+		asked, err := run(mock, true)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(asked, []string{"Are you sure you want to delete resource-class my-namespace/my-resource-class?"}))
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		mock := newMock()
+
+		asked, err := run(mock, false, "--force")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(asked, 0))
+		assert.Check(t, cmp.Len(mock.resourceClasses, 0))
+	})
+}
+
 func Test_ResourceClass(t *testing.T) {
 	runner := runnerMock{}
 	cmd := newResourceClassCommand(&runnerOpts{r: &runner}, nil)
@@ -75,34 +341,318 @@ func Test_ResourceClass(t *testing.T) {
 
 			assert.Check(t, cmp.Contains(stderr.String(), terms))
 		})
+
+		t.Run("if not exists when it exists", func(t *testing.T) {
+			defer runner.reset()
+			defer stdout.Reset()
+			defer stderr.Reset()
+
+			_, err := runner.CreateResourceClass("my-namespace/my-resource-class", "existing description")
+			assert.NilError(t, err)
+
+			cmd.SetArgs([]string{
+				"create",
+				"my-namespace/my-resource-class",
+				"my-description",
+				"--generate-token=false",
+				"--if-not-exists",
+			})
+
+			err = cmd.Execute()
+			assert.NilError(t, err)
+
+			assert.Check(t, cmp.Equal(len(runner.resourceClasses), 1))
+			assert.Check(t, cmp.Equal(runner.resourceClasses[0].Description, "existing description"))
+			assert.Check(t, cmp.Contains(stdout.String(), "existing description"))
+			assert.Check(t, cmp.Contains(stderr.String(), "already exists"))
+			assert.Check(t, !strings.Contains(stderr.String(), terms))
+		})
+
+		t.Run("if not exists when it doesn't exist", func(t *testing.T) {
+			defer runner.reset()
+			defer stdout.Reset()
+			defer stderr.Reset()
+
+			cmd.SetArgs([]string{
+				"create",
+				"my-namespace/my-resource-class",
+				"my-description",
+				"--generate-token=false",
+				"--if-not-exists",
+			})
+
+			err := cmd.Execute()
+			assert.NilError(t, err)
+
+			assert.Check(t, cmp.Equal(len(runner.resourceClasses), 1))
+			assert.Check(t, cmp.Equal(runner.resourceClasses[0].Description, "my-description"))
+			assert.Check(t, cmp.Contains(stderr.String(), terms))
+		})
+	})
+}
+
+func Test_ResourceClassUpdate(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, append([]string{"update"}, args...)...)
+		return stdout, err
+	}
+	newMock := func() *runnerMock {
+		return &runnerMock{resourceClasses: []runner.ResourceClass{
+			{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class", Description: "old description"},
+		}}
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		mock := newMock()
+
+		out, err := run(mock, "my-namespace/my-resource-class", "--description", "new description")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.resourceClasses[0].Description, "new description"))
+		assert.Check(t, cmp.Equal(mock.resourceClasses[0].ID, "d8bc155b-5e91-4765-b327-0fa256f0229e"))
+		assert.Check(t, cmp.Contains(out, "new description"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := run(newMock(), "my-namespace/other-resource-class", "--description", "new description")
+		assert.Error(t, err, `resource class "my-namespace/other-resource-class" not found`)
+	})
+
+	t.Run("nothing to update", func(t *testing.T) {
+		_, err := run(newMock(), "my-namespace/my-resource-class")
+		assert.Error(t, err, "nothing to update, expected --description, --label or --remove-label")
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		mock := newMock()
+		mock.resourceClasses[0].Labels = map[string]string{"team": "web", "owner": "alice"}
+
+		_, err := run(mock, "my-namespace/my-resource-class", "--label", "team=mobile,env=prod", "--remove-label", "owner")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.resourceClasses[0].Labels, map[string]string{"team": "mobile", "env": "prod"}))
+		assert.Check(t, cmp.Equal(mock.resourceClasses[0].Description, "old description"))
+	})
+
+	t.Run("labels unsupported", func(t *testing.T) {
+		mock := newMock()
+		mock.rcLabelsUnsupported = true
+
+		_, err := run(mock, "my-namespace/my-resource-class", "--label", "team=mobile")
+		assert.Error(t, err, runner.ErrResourceClassLabelsNotSupported.Error())
+	})
+}
+
+func Test_ResourceClassLabels(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, args...)
+		return stdout, err
+	}
+
+	t.Run("create", func(t *testing.T) {
+		mock := &runnerMock{}
+
+		_, err := run(mock, "create", "my-namespace/my-resource-class", "my-description", "--label", "team=mobile", "--label", "env=prod")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.resourceClasses[0].Labels, map[string]string{"team": "mobile", "env": "prod"}))
+	})
+
+	t.Run("create unsupported", func(t *testing.T) {
+		mock := &runnerMock{rcLabelsUnsupported: true}
+
+		_, err := run(mock, "create", "my-namespace/my-resource-class", "my-description", "--label", "team=mobile")
+		assert.Error(t, err, "resource-class labels are not supported by this API: resource-class my-namespace/my-resource-class was created without its labels")
+	})
+
+	t.Run("invalid label", func(t *testing.T) {
+		_, err := run(&runnerMock{}, "create", "my-namespace/my-resource-class", "my-description", "--label", "team")
+		assert.Error(t, err, `expected a label in the form key=value, got "team"`)
+	})
+
+	mock := &runnerMock{resourceClasses: []runner.ResourceClass{
+		{ResourceClass: "my-namespace/rc-a", Labels: map[string]string{"team": "mobile", "env": "prod"}},
+		{ResourceClass: "my-namespace/rc-b", Labels: map[string]string{"team": "mobile", "env": "staging"}},
+		{ResourceClass: "my-namespace/rc-c", Labels: map[string]string{"team": "web"}},
+		{ResourceClass: "my-namespace/rc-d"},
+	}}
+	for _, tt := range []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "team=mobile", want: []string{"my-namespace/rc-a", "my-namespace/rc-b"}},
+		{selector: "team=mobile,env!=prod", want: []string{"my-namespace/rc-b"}},
+		{selector: "env", want: []string{"my-namespace/rc-a", "my-namespace/rc-b"}},
+		{selector: "team!=mobile", want: []string{"my-namespace/rc-c", "my-namespace/rc-d"}},
+		{selector: "team=ops", want: []string{}},
+	} {
+		t.Run("selector "+tt.selector, func(t *testing.T) {
+			out, err := run(mock, "list", "my-namespace", "--selector", tt.selector, "--output", "json")
+			assert.NilError(t, err)
+			var rcs []runner.ResourceClass
+			assert.NilError(t, json.Unmarshal([]byte(out), &rcs))
+			names := []string{}
+			for _, rc := range rcs {
+				names = append(names, rc.ResourceClass)
+			}
+			assert.Check(t, cmp.DeepEqual(names, tt.want))
+		})
+	}
+
+	t.Run("invalid selector", func(t *testing.T) {
+		_, err := run(mock, "list", "my-namespace", "--selector", "team=mobile,,env")
+		assert.Error(t, err, `invalid selector "team=mobile,,env", expected requirements such as team=mobile, env!=prod or owner`)
+	})
+}
+
+func Test_ResourceClassStructuredOutput(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newResourceClassCommand, args...)
+		return stdout, err
+	}
+
+	t.Run("create with a token as json", func(t *testing.T) {
+		out, err := run(&runnerMock{}, "create", "my-namespace/my-resource-class", "my-description", "--generate-token", "--output", "json")
+		assert.NilError(t, err)
+
+		var created createdResourceClass
+		assert.NilError(t, json.Unmarshal([]byte(out), &created))
+		assert.Check(t, cmp.Equal(created.ResourceClass.ResourceClass, "my-namespace/my-resource-class"))
+		assert.Check(t, cmp.Equal(created.Token.Token, "fake-token"))
+	})
+
+	t.Run("list as yaml", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ID: "d8bc155b-5e91-4765-b327-0fa256f0229e", ResourceClass: "my-namespace/my-resource-class", Description: "my-description"},
+		}}
+
+		out, err := run(&mock, "list", "my-namespace", "--output", "yaml")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `- id: d8bc155b-5e91-4765-b327-0fa256f0229e
+  resource_class: my-namespace/my-resource-class
+  description: my-description
+`))
+	})
+
+	t.Run("describe with an unknown format", func(t *testing.T) {
+		_, err := run(&runnerMock{}, "describe", "my-namespace/my-resource-class", "--output", "csv")
+		assert.Error(t, err, `unsupported output format "csv", expected one of table, json, yaml, env`)
 	})
 }
 
 type runnerMock struct {
 	resourceClasses []runner.ResourceClass
 	tokens          []runner.Token
+	instances       []runner.RunnerInstance
+	usage           map[string][]runner.TokenUsage
+	usageErr        error
+	labels          map[string]map[string]string
+	labelsErr       error
+	// expiryUnsupported makes the mock ignore token expiries, like an older API.
+	expiryUnsupported bool
+	// pageSize and limit are the page size and limit of the last instance listing.
+	pageSize    int
+	limit       int
+	tokenTTLErr error
+	// delay makes every call sleep first, to simulate a slow API.
+	delay time.Duration
+	// ctx is the context of the latest call, which cuts the delay short.
+	ctx context.Context
+	// deletedInstances are the IDs of the instances deleted so far.
+	deletedInstances []string
+	// drainedInstances are the IDs of the instances drained so far.
+	drainedInstances []string
+	// drainUnsupported makes DrainRunnerInstance fail, like an older API.
+	drainUnsupported bool
+	// taskLogs are the pages of task logs, by the page token that fetches them.
+	taskLogs map[string]*runner.TaskLogPage
+	// taskLogRequests are the page tokens task logs were requested with.
+	taskLogRequests []string
+	tasks           []runner.Task
+	// quotas are the namespace's concurrency quotas, unsupported if nil.
+	quotas *runner.Quotas
+	// rcLabelsUnsupported makes the mock reject resource-class labels, like an older API.
+	rcLabelsUnsupported bool
+}
+
+// WithContext returns the mock itself, so that the calls made with the context
+// are recorded with the rest.
+func (r *runnerMock) WithContext(ctx context.Context) running {
+	r.ctx = ctx
+	return r
+}
+
+// wait sleeps for the delay, failing like a request would if the context the
+// call was made with is done first. Calls that aren't delayed answer at once.
+func (r *runnerMock) wait() error {
+	if r.delay == 0 {
+		return nil
+	}
+	if r.ctx == nil {
+		time.Sleep(r.delay)
+		return nil
+	}
+	select {
+	case <-time.After(r.delay):
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
 }
 
 func (r *runnerMock) CreateResourceClass(resourceClass, desc string) (*runner.ResourceClass, error) {
+	return r.CreateResourceClassWithOptions(resourceClass, desc, runner.ResourceClassOptions{})
+}
+
+func (r *runnerMock) CreateResourceClassWithOptions(resourceClass, desc string, opts runner.ResourceClassOptions) (*runner.ResourceClass, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
 	rc := runner.ResourceClass{
 		ID:            "d8bc155b-5e91-4765-b327-0fa256f0229e",
 		ResourceClass: resourceClass,
 		Description:   desc,
 	}
+	if len(opts.Labels) > 0 && r.rcLabelsUnsupported {
+		r.resourceClasses = append(r.resourceClasses, rc)
+		return &rc, runner.ErrResourceClassLabelsNotSupported
+	}
+	if len(opts.Labels) > 0 {
+		rc.Labels = opts.Labels
+	}
 	r.resourceClasses = append(r.resourceClasses, rc)
 	return &rc, nil
 }
 
+func (r *runnerMock) SetResourceClassLabels(id string, labels map[string]string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	if r.rcLabelsUnsupported {
+		return runner.ErrResourceClassLabelsNotSupported
+	}
+	for i, rc := range r.resourceClasses {
+		if rc.ID == id {
+			r.resourceClasses[i].Labels = labels
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
 func (r *runnerMock) GetResourceClassByName(resourceClass string) (*runner.ResourceClass, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
 	for _, rc := range r.resourceClasses {
 		if rc.ResourceClass == resourceClass {
 			return &rc, nil
 		}
 	}
-	return nil, errors.New("not found")
+	return nil, fmt.Errorf("resource class %q %w", resourceClass, runner.ErrNotFound)
 }
 
 func (r *runnerMock) GetNamespaceByResourceClass(resourceClass string) (string, error) {
+	if err := r.wait(); err != nil {
+		return "", err
+	}
 	s := strings.SplitN(resourceClass, "/", 2)
 	if len(s) != 2 {
 		return "", fmt.Errorf("bad resource class: %q", resourceClass)
@@ -111,6 +661,9 @@ func (r *runnerMock) GetNamespaceByResourceClass(resourceClass string) (string,
 }
 
 func (r *runnerMock) GetResourceClassesByNamespace(namespace string) ([]runner.ResourceClass, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
 	var rcs []runner.ResourceClass
 	for _, rc := range r.resourceClasses {
 		if strings.Split(rc.ResourceClass, "/")[0] == namespace {
@@ -121,6 +674,9 @@ func (r *runnerMock) GetResourceClassesByNamespace(namespace string) ([]runner.R
 }
 
 func (r *runnerMock) DeleteResourceClass(id string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
 	for i, rc := range r.resourceClasses {
 		if rc.ID == id {
 			r.resourceClasses = append(r.resourceClasses[:i], r.resourceClasses[i+1:]...)
@@ -130,7 +686,43 @@ func (r *runnerMock) DeleteResourceClass(id string) error {
 	return errors.New("not found")
 }
 
+func (r *runnerMock) UpdateResourceClass(id, desc string) (*runner.ResourceClass, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	for i, rc := range r.resourceClasses {
+		if rc.ID == id {
+			r.resourceClasses[i].Description = desc
+			return &r.resourceClasses[i], nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *runnerMock) SetResourceClassTokenTTL(id string, ttl time.Duration) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	if r.tokenTTLErr != nil {
+		return r.tokenTTLErr
+	}
+	for i, rc := range r.resourceClasses {
+		if rc.ID == id {
+			r.resourceClasses[i].DefaultTokenTTLSeconds = int64(ttl / time.Second)
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
 func (r *runnerMock) CreateToken(resourceClass, nickname string) (*runner.Token, error) {
+	return r.CreateTokenWithOptions(resourceClass, nickname, runner.TokenOptions{})
+}
+
+func (r *runnerMock) CreateTokenWithOptions(resourceClass, nickname string, opts runner.TokenOptions) (*runner.Token, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
 	token := runner.Token{
 		ID:            "987905d7-6780-4fed-a637-37277c373629",
 		Token:         "fake-token",
@@ -138,11 +730,30 @@ func (r *runnerMock) CreateToken(resourceClass, nickname string) (*runner.Token,
 		Nickname:      nickname,
 		CreatedAt:     time.Now(),
 	}
+	if !r.expiryUnsupported {
+		token.ExpiresAt = opts.ExpiresAt
+	}
 	r.tokens = append(r.tokens, token)
+	if opts.ExpiresAt != nil && r.expiryUnsupported {
+		return &token, runner.ErrTokenExpiryNotSupported
+	}
 	return &token, nil
 }
 
+func (r *runnerMock) GetQuotas(namespace string) (*runner.Quotas, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	if r.quotas == nil {
+		return nil, runner.ErrQuotasNotSupported
+	}
+	return r.quotas, nil
+}
+
 func (r *runnerMock) GetRunnerTokensByResourceClass(resourceClass string) ([]runner.Token, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
 	var tokens []runner.Token
 	for _, token := range r.tokens {
 		if token.ResourceClass == resourceClass {
@@ -153,6 +764,9 @@ func (r *runnerMock) GetRunnerTokensByResourceClass(resourceClass string) ([]run
 }
 
 func (r *runnerMock) DeleteToken(id string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
 	for i, token := range r.tokens {
 		if token.ID == id {
 			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
@@ -162,11 +776,148 @@ func (r *runnerMock) DeleteToken(id string) error {
 	return errors.New("not found")
 }
 
-func (r *runnerMock) GetRunnerInstances(_ string) ([]runner.RunnerInstance, error) {
-	return nil, nil
+func (r *runnerMock) GetTokenUsage(id string) ([]runner.TokenUsage, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	return r.usage[id], r.usageErr
+}
+
+func (r *runnerMock) GetRunnerInstances(query string) ([]runner.RunnerInstance, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	var instances []runner.RunnerInstance
+	for _, i := range r.instances {
+		if i.ResourceClass == query || strings.Split(i.ResourceClass, "/")[0] == query {
+			instances = append(instances, i)
+		}
+	}
+	return instances, nil
+}
+
+func (r *runnerMock) GetRunnerInstancesWithOptions(query string, opts runner.InstanceListOptions) ([]runner.RunnerInstance, string, error) {
+	r.pageSize = opts.PageSize
+	r.limit = opts.Limit
+	instances, err := r.GetRunnerInstances(query)
+	from, to, next := mockPage(len(instances), opts.Limit, opts.PageToken)
+	return instances[from:to], next, err
+}
+
+func (r *runnerMock) GetRunnerInstanceLabels(id string) (map[string]string, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	return r.labels[id], r.labelsErr
+}
+
+func (r *runnerMock) SetRunnerInstanceLabel(id, key, value string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	if r.labelsErr != nil {
+		return r.labelsErr
+	}
+	if r.labels == nil {
+		r.labels = map[string]map[string]string{}
+	}
+	if r.labels[id] == nil {
+		r.labels[id] = map[string]string{}
+	}
+	r.labels[id][key] = value
+	return nil
+}
+
+func (r *runnerMock) RemoveRunnerInstanceLabel(id, key string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	if r.labelsErr != nil {
+		return r.labelsErr
+	}
+	delete(r.labels[id], key)
+	return nil
+}
+
+func (r *runnerMock) DeleteRunnerInstance(id string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	r.deletedInstances = append(r.deletedInstances, id)
+	return nil
+}
+
+func (r *runnerMock) DrainRunnerInstance(id string) error {
+	if err := r.wait(); err != nil {
+		return err
+	}
+	if r.drainUnsupported {
+		return runner.ErrInstanceDrainNotSupported
+	}
+	r.drainedInstances = append(r.drainedInstances, id)
+	return nil
+}
+
+func (r *runnerMock) GetTaskLogs(resourceClass, taskID, pageToken string) (*runner.TaskLogPage, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	r.taskLogRequests = append(r.taskLogRequests, pageToken)
+	if page, ok := r.taskLogs[pageToken]; ok {
+		return page, nil
+	}
+	return &runner.TaskLogPage{NextPageToken: pageToken}, nil
+}
+
+func (r *runnerMock) GetTasks(resourceClass string, opts runner.TaskListOptions) ([]runner.Task, string, error) {
+	if err := r.wait(); err != nil {
+		return nil, "", err
+	}
+	tasks := []runner.Task{}
+	for _, t := range r.tasks {
+		if t.ResourceClass != resourceClass {
+			continue
+		}
+		if !opts.Since.IsZero() && t.QueuedAt != nil && t.QueuedAt.Before(opts.Since) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	from, to, next := mockPage(len(tasks), opts.Limit, opts.PageToken)
+	return tasks[from:to], next, nil
+}
+
+// mockPage picks the items listed from pageToken up to limit. The mock's page
+// tokens are the offsets of the items they start from.
+func mockPage(n, limit int, pageToken string) (from, to int, next string) {
+	from, _ = strconv.Atoi(pageToken)
+	if from > n {
+		from = n
+	}
+	to = n
+	if limit > 0 && from+limit < n {
+		to = from + limit
+		next = strconv.Itoa(to)
+	}
+	return from, to, next
 }
 
 func (r *runnerMock) reset() {
 	r.resourceClasses = nil
 	r.tokens = nil
+	r.instances = nil
+	r.usage = nil
+	r.usageErr = nil
+	r.labels = nil
+	r.labelsErr = nil
+	r.expiryUnsupported = false
+	r.pageSize = 0
+	r.limit = 0
+	r.tokenTTLErr = nil
+	r.delay = 0
+	r.deletedInstances = nil
+	r.drainedInstances = nil
+	r.taskLogs = nil
+	r.taskLogRequests = nil
+	r.tasks = nil
 }
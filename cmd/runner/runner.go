@@ -1,42 +1,166 @@
 package runner
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/CircleCI-Public/circleci-cli/api/rest"
 	"github.com/CircleCI-Public/circleci-cli/api/runner"
+	"github.com/CircleCI-Public/circleci-cli/prompt"
 	"github.com/CircleCI-Public/circleci-cli/settings"
 )
 
 type runnerOpts struct {
-	r running
+	r              running
+	requestTimeout time.Duration
+	defaultOutput  string
+	// httpClient downloads files from outside the API, such as agent releases.
+	httpClient *http.Client
+	// confirm asks the user to confirm a destructive operation, prompting
+	// on the terminal if nil.
+	confirm func(message string) bool
+	// auditPath is the file that changes to resource-classes and tokens are recorded in.
+	auditPath string
+	// config holds the settings applied by applySettings, if any.
+	config *settings.Config
+}
+
+// applySettings checks the default output format and sets where timestamps are
+// shown. It is needed by every command, including those that don't use the API.
+func (o *runnerOpts) applySettings() error {
+	if o.config == nil {
+		return nil
+	}
+	// The --output defaults were seeded from default_output_format before it
+	// could be reported as invalid.
+	if err := o.config.ValidateDefaultOutputFormat(); err != nil {
+		return err
+	}
+	loc, err := o.config.Location()
+	if err != nil {
+		return err
+	}
+	timeLocation = loc
+	return nil
+}
+
+// askToConfirm asks the user whether to go ahead with a destructive operation.
+func (o *runnerOpts) askToConfirm(message string) bool {
+	if o.confirm != nil {
+		return o.confirm(message)
+	}
+	return prompt.AskUserToConfirm(message)
+}
+
+// outputDefault is the default of a command's --output flag: the configured
+// default_output_format if the command supports it, otherwise fallback.
+func (o *runnerOpts) outputDefault(fallback string, supported ...string) string {
+	for _, f := range supported {
+		if f == o.defaultOutput {
+			return f
+		}
+	}
+	return fallback
 }
 
 func NewCommand(config *settings.Config, preRunE validator) *cobra.Command {
-	var opts runnerOpts
+	opts := runnerOpts{
+		defaultOutput: config.DefaultOutputFormat,
+		httpClient:    config.HTTPClient,
+		auditPath:     auditLogPath(),
+		config:        config,
+	}
+	var rc *rest.Client
 	cmd := &cobra.Command{
 		Use:   "runner",
 		Short: "Operate on runners",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			opts.r = runner.New(rest.New(config.Host, config.RestEndpoint, config.Token))
+		// Each subcommand runs preRunE itself, once the client is made.
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := config.ValidateForRunner(); err != nil {
+				return err
+			}
+			if err := opts.applySettings(); err != nil {
+				return err
+			}
+			var err error
+			rc, err = rest.NewFromConfig(config)
+			if err != nil {
+				return err
+			}
+			opts.r = newAuditedRunner(apiRunner{runner.New(rc)}, opts.auditPath, cmd.ErrOrStderr())
+			return nil
+		},
+		PersistentPostRunE: func(*cobra.Command, []string) error {
+			if rc == nil {
+				return nil
+			}
+			return rc.Close()
 		},
 	}
+	cmd.PersistentFlags().DurationVar(&opts.requestTimeout, "request-timeout", 0,
+		"Override the time limit for every runner API operation (defaults vary by operation)")
 	cmd.AddCommand(newResourceClassCommand(&opts, preRunE))
 	cmd.AddCommand(newTokenCommand(&opts, preRunE))
 	cmd.AddCommand(newRunnerInstanceCommand(&opts, preRunE))
+	cmd.AddCommand(newNamespaceCommand(&opts, preRunE))
+	cmd.AddCommand(newLogsCommand(&opts, preRunE))
+	cmd.AddCommand(newTaskCommand(&opts, preRunE))
+	cmd.AddCommand(newInstallCommand(&opts, preRunE))
+	cmd.AddCommand(newConfigCommand(&opts, preRunE))
+	cmd.AddCommand(newStatsCommand(&opts, preRunE))
+	cmd.AddCommand(newDoctorCommand(&opts))
+	cmd.AddCommand(newAuditCommand(&opts))
 	return cmd
 }
 
 type running interface {
+	// WithContext returns a runner whose API calls are cancelled once ctx is
+	// done.
+	WithContext(ctx context.Context) running
 	CreateResourceClass(resourceClass, desc string) (rc *runner.ResourceClass, err error)
+	CreateResourceClassWithOptions(resourceClass, desc string, opts runner.ResourceClassOptions) (rc *runner.ResourceClass, err error)
 	GetResourceClassByName(resourceClass string) (rc *runner.ResourceClass, err error)
 	GetNamespaceByResourceClass(resourceClass string) (ns string, err error)
 	GetResourceClassesByNamespace(namespace string) ([]runner.ResourceClass, error)
 	DeleteResourceClass(id string) error
+	UpdateResourceClass(id, desc string) (rc *runner.ResourceClass, err error)
+	SetResourceClassTokenTTL(id string, ttl time.Duration) error
+	SetResourceClassLabels(id string, labels map[string]string) error
+	GetQuotas(namespace string) (*runner.Quotas, error)
 	CreateToken(resourceClass, nickname string) (token *runner.Token, err error)
+	CreateTokenWithOptions(resourceClass, nickname string, opts runner.TokenOptions) (token *runner.Token, err error)
 	GetRunnerTokensByResourceClass(resourceClass string) ([]runner.Token, error)
 	DeleteToken(id string) error
+	GetTokenUsage(id string) ([]runner.TokenUsage, error)
 	GetRunnerInstances(query string) ([]runner.RunnerInstance, error)
+	GetRunnerInstancesWithOptions(query string, opts runner.InstanceListOptions) ([]runner.RunnerInstance, string, error)
+	GetRunnerInstanceLabels(id string) (map[string]string, error)
+	SetRunnerInstanceLabel(id, key, value string) error
+	RemoveRunnerInstanceLabel(id, key string) error
+	DeleteRunnerInstance(id string) error
+	DrainRunnerInstance(id string) error
+	GetTaskLogs(resourceClass, taskID, pageToken string) (*runner.TaskLogPage, error)
+	GetTasks(resourceClass string, opts runner.TaskListOptions) ([]runner.Task, string, error)
+}
+
+// apiRunner adapts the API's runner client to running.
+type apiRunner struct {
+	*runner.Runner
+}
+
+func (a apiRunner) WithContext(ctx context.Context) running {
+	return apiRunner{a.Runner.WithContext(ctx)}
 }
 
 type validator func(cmd *cobra.Command, args []string) error
+
+// interruptContext returns a context that is cancelled when the user interrupts
+// the command, for long-running commands that should stop cleanly on Ctrl-C.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
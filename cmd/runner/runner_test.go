@@ -0,0 +1,253 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+)
+
+// TestMain pins rendered timestamps to UTC so expectations don't depend on
+// the timezone of the machine running the tests.
+func TestMain(m *testing.M) {
+	timeLocation = time.UTC
+	// Keep tests out of the real keychain.
+	keychainSet, keychainGet, keychainDelete = testKeychain.set, testKeychain.get, testKeychain.delete
+	// Keep tests out of the real audit log.
+	dir, err := ioutil.TempDir("", "runner-audit")
+	if err != nil {
+		panic(err)
+	}
+	auditLogPath = func() string { return filepath.Join(dir, auditFilename) }
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// executeRunnerCommand runs the command made by newCmd with o and args,
+// returning what it wrote to stdout and stderr.
+func executeRunnerCommand(t *testing.T, o *runnerOpts, newCmd func(*runnerOpts, validator) *cobra.Command, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := newCmd(o, nil)
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestNewCommand_ValidatesConfig(t *testing.T) {
+	cmd := NewCommand(&settings.Config{RestEndpoint: "api/v2", Token: "fake-token"}, nil)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"instance", "list", "my-namespace"})
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "host is not set")
+}
+
+func TestNewCommand_RunsValidatorOnce(t *testing.T) {
+	calls := 0
+	validate := func(*cobra.Command, []string) error {
+		calls++
+		return errors.New("please set a token")
+	}
+	cmd := NewCommand(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Token: "fake-token"}, validate)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"instance", "list", "my-namespace"})
+
+	err := cmd.Execute()
+	assert.Error(t, err, "please set a token")
+	assert.Check(t, cmp.Equal(calls, 1))
+}
+
+func TestNewCommand_ValidatesTimezone(t *testing.T) {
+	cmd := NewCommand(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Token: "fake-token", Timezone: "mars"}, nil)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"instance", "list", "my-namespace"})
+
+	err := cmd.Execute()
+	assert.Error(t, err, `unknown timezone "mars", expected one of utc, local`)
+}
+
+func TestNewCommand_ValidatesDefaultOutputFormat(t *testing.T) {
+	cmd := NewCommand(&settings.Config{Host: "https://circleci.com", RestEndpoint: "api/v2", Token: "fake-token",
+		DefaultOutputFormat: "xml", FileUsed: "cli.yml"}, nil)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"instance", "list", "my-namespace"})
+
+	err := cmd.Execute()
+	assert.Error(t, err, `invalid default_output_format "xml" in cli.yml, expected one of table, json, yaml, csv`)
+}
+
+func TestNewCommand_AppliesSettingsWithoutToken(t *testing.T) {
+	defer func(loc *time.Location) { timeLocation = loc }(timeLocation)
+	defer func(f func() string) { auditLogPath = f }(auditLogPath)
+	auditLogPath = func() string { return filepath.Join(t.TempDir(), auditFilename) }
+
+	run := func(config *settings.Config) error {
+		cmd := NewCommand(config, nil)
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs([]string{"audit", "list"})
+		return cmd.Execute()
+	}
+
+	timeLocation = time.Local
+	assert.NilError(t, run(&settings.Config{Timezone: "utc"}))
+	assert.Check(t, cmp.Equal(timeLocation, time.UTC))
+
+	assert.Error(t, run(&settings.Config{Timezone: "mars"}), `unknown timezone "mars", expected one of utc, local`)
+	assert.Error(t, run(&settings.Config{DefaultOutputFormat: "xml", FileUsed: "cli.yml"}),
+		`invalid default_output_format "xml" in cli.yml, expected one of table, json, yaml, csv`)
+}
+
+func TestNewCommand_TraceFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id": "the-id", "token": "secret-runner-token", "resource_class": "my-namespace/my-resource-class", "nickname": "my-nickname"}`)
+	}))
+	defer server.Close()
+
+	traceFile := filepath.Join(t.TempDir(), "trace.ndjson")
+	cmd := NewCommand(&settings.Config{
+		Host:         server.URL,
+		RestEndpoint: "api/v2",
+		Token:        "secret-circle-token",
+		TraceFile:    traceFile,
+		Timezone:     "utc",
+	}, nil)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"token", "create", "my-namespace/my-resource-class", "my-nickname"})
+
+	err := cmd.Execute()
+	assert.NilError(t, err)
+
+	trace, err := ioutil.ReadFile(traceFile)
+	assert.NilError(t, err)
+	assert.Check(t, !strings.Contains(string(trace), "secret-circle-token"))
+	assert.Check(t, !strings.Contains(string(trace), "secret-runner-token"))
+
+	// The resource-class is looked up for its default token TTL before the token is created.
+	lines := strings.Split(strings.TrimSpace(string(trace)), "\n")
+	assert.Assert(t, cmp.Len(lines, 2))
+
+	var entry struct {
+		Request struct {
+			Method string
+			URL    string
+			Header http.Header
+			Body   string
+		}
+		Response struct {
+			Status int
+			Body   string
+		}
+	}
+	assert.NilError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Check(t, cmp.Equal(entry.Request.Method, "POST"))
+	assert.Check(t, cmp.Equal(entry.Request.URL, server.URL+"/api/v2/runner/token"))
+	assert.Check(t, cmp.Equal(entry.Request.Header.Get("Circle-Token"), "[REDACTED]"))
+	assert.Check(t, cmp.Contains(entry.Request.Body, `"nickname":"my-nickname"`))
+	assert.Check(t, cmp.Equal(entry.Response.Status, http.StatusOK))
+	assert.Check(t, cmp.Contains(entry.Response.Body, `"token":"[REDACTED]"`))
+	assert.Check(t, cmp.Contains(entry.Response.Body, `"id":"the-id"`))
+}
+
+func TestNewCommand_Retries(t *testing.T) {
+	run := func(retries *int) (int, error) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		cmd := NewCommand(&settings.Config{
+			Host:         server.URL,
+			RestEndpoint: "api/v2",
+			Token:        "fake-token",
+			Retries:      retries,
+		}, nil)
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs([]string{"resource-class", "list", "my-namespace", "--output", "json"})
+		err := cmd.Execute()
+		return requests, err
+	}
+
+	t.Run("retries", func(t *testing.T) {
+		one := 1
+		requests, err := run(&one)
+		assert.Error(t, err, "failed to list resource-classes of my-namespace: response 503 (Service Unavailable)")
+		assert.Check(t, cmp.Equal(requests, 2))
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		negative := -1
+		_, err := run(&negative)
+		assert.Error(t, err, "invalid retries -1, expected zero or more")
+	})
+}
+
+func TestDefaultOutputFormat(t *testing.T) {
+	t.Run("seeds supporting commands", func(t *testing.T) {
+		tests := []struct {
+			format string
+			path   []string
+			want   string
+		}{
+			{format: "json", path: []string{"instance", "list"}, want: "json"},
+			{format: "json", path: []string{"instance", "watch"}, want: "json"},
+			{format: "json", path: []string{"token", "delete-all"}, want: "json"},
+			{format: "csv", path: []string{"instance", "list"}, want: "csv"},
+			{format: "csv", path: []string{"instance", "watch"}, want: "table"},
+			{format: "yaml", path: []string{"instance", "list"}, want: "yaml"},
+			{format: "yaml", path: []string{"resource-class", "list"}, want: "yaml"},
+			{format: "csv", path: []string{"token", "list"}, want: "table"},
+			{format: "json", path: []string{"token", "create"}, want: "yaml"},
+			{format: "", path: []string{"instance", "list"}, want: "table"},
+		}
+		for _, tt := range tests {
+			cmd := NewCommand(&settings.Config{DefaultOutputFormat: tt.format}, nil)
+			sub, _, err := cmd.Find(tt.path)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(sub.Flag("output").DefValue, tt.want), "%s with %q", strings.Join(tt.path, " "), tt.format)
+		}
+	})
+
+	run := func(args ...string) string {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &runnerMock{}, defaultOutput: "json"}, newNamespaceCommand, append([]string{"describe", "--namespace", "my-namespace"}, args...)...)
+		assert.NilError(t, err)
+		return stdout
+	}
+
+	t.Run("used without a flag", func(t *testing.T) {
+		var summary namespaceSummary
+		assert.NilError(t, json.Unmarshal([]byte(run()), &summary))
+		assert.Check(t, cmp.Equal(summary.Namespace, "my-namespace"))
+	})
+
+	t.Run("flag takes precedence", func(t *testing.T) {
+		assert.Check(t, cmp.Contains(run("--output", "table"), "| my-namespace |"))
+	})
+}
@@ -0,0 +1,193 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func newStatsCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	var window, output string
+	cmd := &cobra.Command{
+		Use:   "stats <resource-class>",
+		Short: "Summarise the tasks a resource-class has run",
+		Long: `Summarise the tasks a resource-class has run.
+
+Reports how many tasks were queued over the window, how long they ran and
+waited to start, and how many ran at once, to help size a fleet of runners.`,
+		Example: `  circleci runner stats my-namespace/my-resource-class
+  circleci runner stats my-namespace/my-resource-class --window 30d --output json`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" && output != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", output)
+			}
+			d, err := parseDays(window)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid window %q, expected a positive duration such as 24h or 7d", window)
+			}
+
+			now := timeNow()
+			since := now.Add(-d)
+			var tasks []runner.Task
+			err = o.withTimeout("list tasks", o.timeout(bulkTimeout, 0), func(r running) (err error) {
+				tasks, _, err = r.GetTasks(args[0], runner.TaskListOptions{Since: since})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			stats := computeTaskStats(args[0], tasks, since, now)
+			if output != "table" {
+				return writeStructured(cmd.OutOrStdout(), output, stats)
+			}
+
+			table := tablewriter.NewWriter(cmd.OutOrStdout())
+			defer table.Render()
+			table.SetHeader([]string{"Metric", "Value"})
+			table.AppendBulk(stats.rows())
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&window, "window", "7d",
+		"How far back to look, such as 24h or 30d")
+	cmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	return cmd
+}
+
+// taskStats summarises the tasks a resource-class queued over a window.
+// Durations are in seconds, so they read the same in every output format.
+type taskStats struct {
+	ResourceClass string    `json:"resource_class"`
+	Since         time.Time `json:"since"`
+	Until         time.Time `json:"until"`
+	Tasks         int       `json:"tasks"`
+	// Statuses counts the tasks by status, such as succeeded or failed.
+	Statuses               map[string]int `json:"statuses"`
+	AverageDurationSeconds float64        `json:"average_duration_seconds"`
+	AverageQueueSeconds    float64        `json:"average_queue_seconds"`
+	MaxQueueSeconds        float64        `json:"max_queue_seconds"`
+	// PeakConcurrency is the most tasks that were running at once.
+	PeakConcurrency int `json:"peak_concurrency"`
+	// AverageConcurrency is the total time tasks ran for, divided by the window.
+	AverageConcurrency float64 `json:"average_concurrency"`
+}
+
+// computeTaskStats summarises the tasks queued between since and now. Tasks
+// that haven't finished count as running until now, and those that haven't
+// started yet have waited in the queue until now.
+func computeTaskStats(resourceClass string, tasks []runner.Task, since, now time.Time) taskStats {
+	s := taskStats{
+		ResourceClass: resourceClass,
+		Since:         since.UTC(),
+		Until:         now.UTC(),
+		Statuses:      map[string]int{},
+	}
+
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	var ran, queued, maxQueued, busy time.Duration
+	var finished, waited int
+	for _, t := range tasks {
+		if t.QueuedAt != nil && t.QueuedAt.Before(since) {
+			continue
+		}
+		s.Tasks++
+		s.Statuses[t.Status]++
+
+		if t.QueuedAt != nil {
+			startedAt := now
+			if t.StartedAt != nil {
+				startedAt = *t.StartedAt
+			}
+			wait := startedAt.Sub(*t.QueuedAt)
+			queued += wait
+			waited++
+			if wait > maxQueued {
+				maxQueued = wait
+			}
+		}
+
+		if t.StartedAt == nil {
+			continue
+		}
+		stoppedAt := now
+		if t.StoppedAt != nil {
+			stoppedAt = *t.StoppedAt
+			ran += stoppedAt.Sub(*t.StartedAt)
+			finished++
+		}
+		busy += stoppedAt.Sub(*t.StartedAt)
+		edges = append(edges, edge{*t.StartedAt, 1}, edge{stoppedAt, -1})
+	}
+
+	if finished > 0 {
+		s.AverageDurationSeconds = roundSeconds(ran / time.Duration(finished))
+	}
+	if waited > 0 {
+		s.AverageQueueSeconds = roundSeconds(queued / time.Duration(waited))
+	}
+	s.MaxQueueSeconds = roundSeconds(maxQueued)
+	if window := now.Sub(since); window > 0 {
+		s.AverageConcurrency = math.Round(float64(busy)/float64(window)*100) / 100
+	}
+
+	// A task stopping at the same moment another starts doesn't overlap it.
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	running := 0
+	for _, e := range edges {
+		running += e.delta
+		if running > s.PeakConcurrency {
+			s.PeakConcurrency = running
+		}
+	}
+	return s
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return d.Round(time.Second).Seconds()
+}
+
+func (s taskStats) rows() [][]string {
+	seconds := func(f float64) string {
+		return (time.Duration(f) * time.Second).String()
+	}
+	rows := [][]string{
+		{"Since", formatTime(s.Since)},
+		{"Until", formatTime(s.Until)},
+		{"Tasks", strconv.Itoa(s.Tasks)},
+	}
+	statuses := make([]string, 0, len(s.Statuses))
+	for status := range s.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		rows = append(rows, []string{"  " + status, strconv.Itoa(s.Statuses[status])})
+	}
+	return append(rows,
+		[]string{"Average duration", seconds(s.AverageDurationSeconds)},
+		[]string{"Average queue wait", seconds(s.AverageQueueSeconds)},
+		[]string{"Longest queue wait", seconds(s.MaxQueueSeconds)},
+		[]string{"Peak concurrency", strconv.Itoa(s.PeakConcurrency)},
+		[]string{"Average concurrency", strconv.FormatFloat(s.AverageConcurrency, 'f', 2, 64)},
+	)
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_Stats(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	rc := "my-namespace/my-resource-class"
+	mock := &runnerMock{tasks: []runner.Task{
+		// Still queued, for 2m so far.
+		{ID: "a", ResourceClass: rc, Status: "queued", QueuedAt: at(2 * time.Minute)},
+		// Running for the last 10m, after waiting 1m.
+		{ID: "b", ResourceClass: rc, Status: "running", QueuedAt: at(11 * time.Minute), StartedAt: at(10 * time.Minute)},
+		// Ran for 20m alongside c, after waiting 3m.
+		{ID: "c", ResourceClass: rc, Status: "succeeded", QueuedAt: at(63 * time.Minute), StartedAt: at(60 * time.Minute), StoppedAt: at(40 * time.Minute)},
+		// Ran for 10m, after waiting 2m.
+		{ID: "d", ResourceClass: rc, Status: "failed", QueuedAt: at(52 * time.Minute), StartedAt: at(50 * time.Minute), StoppedAt: at(40 * time.Minute)},
+		// Outside the window.
+		{ID: "e", ResourceClass: rc, Status: "succeeded", QueuedAt: at(48 * time.Hour), StartedAt: at(48 * time.Hour), StoppedAt: at(47 * time.Hour)},
+	}}
+
+	run := func(args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newStatsCommand, append([]string{rc}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run("--window", "24h")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `+---------------------+----------------------+
+|       METRIC        |        VALUE         |
++---------------------+----------------------+
+| Since               | 2021-05-31T12:00:00Z |
+| Until               | 2021-06-01T12:00:00Z |
+| Tasks               |                    4 |
+|   failed            |                    1 |
+|   queued            |                    1 |
+|   running           |                    1 |
+|   succeeded         |                    1 |
+| Average duration    | 15m0s                |
+| Average queue wait  | 2m0s                 |
+| Longest queue wait  | 3m0s                 |
+| Peak concurrency    |                    2 |
+| Average concurrency |                 0.03 |
++---------------------+----------------------+
+`))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := run("--window", "7d", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, `"tasks": 5`))
+		assert.Check(t, cmp.Contains(out, `"peak_concurrency": 2`))
+	})
+
+	t.Run("bad window", func(t *testing.T) {
+		_, err := run("--window", "forever")
+		assert.Error(t, err, `invalid window "forever", expected a positive duration such as 24h or 7d`)
+	})
+}
+
+func Test_computeTaskStatsBackToBack(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	start, middle, end := now.Add(-20*time.Minute), now.Add(-10*time.Minute), now
+	stats := computeTaskStats("ns/rc", []runner.Task{
+		{ID: "a", Status: "succeeded", StartedAt: &start, StoppedAt: &middle},
+		{ID: "b", Status: "succeeded", StartedAt: &middle, StoppedAt: &end},
+	}, now.Add(-time.Hour), now)
+	assert.Check(t, cmp.Equal(stats.PeakConcurrency, 1))
+	assert.Check(t, cmp.Equal(stats.AverageConcurrency, 0.33))
+}
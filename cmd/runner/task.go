@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// defaultTaskLimit is how many of the most recent tasks are listed by default.
+const defaultTaskLimit = 20
+
+func newTaskCommand(o *runnerOpts, preRunE validator) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Operate on tasks run by runners",
+	}
+
+	var output, pageToken string
+	var limit int
+	listCmd := &cobra.Command{
+		Use:     "list <resource-class>",
+		Short:   "List the recent tasks of a resource-class",
+		Aliases: []string{"ls"},
+		Example: `  circleci runner task list my-namespace/my-resource-class
+  circleci runner task list my-namespace/my-resource-class --limit 0 --output json
+  circleci runner task list my-namespace/my-resource-class --limit 50 --page-token <token>`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if output != "table" && output != "json" && output != "yaml" && output != "markdown" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml, markdown", output)
+			}
+			if limit < 0 {
+				return fmt.Errorf("invalid limit %d, expected a positive number or 0 for every task", limit)
+			}
+
+			var tasks []runner.Task
+			var next string
+			err := o.withTimeout("list tasks", o.timeout(listTimeout, 0), func(r running) (err error) {
+				tasks, next, err = r.GetTasks(args[0], runner.TaskListOptions{Limit: limit, PageToken: pageToken})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if next != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Stopped after %d tasks, use --page-token %s to list the next ones\n", limit, next)
+			}
+
+			if output == "json" || output == "yaml" {
+				return writeStructured(cmd.OutOrStdout(), output, tasks)
+			}
+
+			now := timeNow()
+			rows := make([][]string, len(tasks))
+			for i, t := range tasks {
+				rows[i] = taskRow(t, now)
+			}
+			if output == "markdown" {
+				return writeMarkdownTable(cmd.OutOrStdout(), taskHeader, rows)
+			}
+
+			table := newTaskTable(cmd.OutOrStdout())
+			defer table.Render()
+			table.AppendBulk(rows)
+			return nil
+		},
+	}
+	listCmd.PersistentFlags().IntVar(&limit, "limit", defaultTaskLimit,
+		"Number of recent tasks to list (0 lists every task the API has kept)")
+	listCmd.PersistentFlags().StringVar(&pageToken, "page-token", "",
+		"Carry on listing from where an earlier --limit stopped")
+	listCmd.PersistentFlags().StringVar(&output, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json, yaml or markdown")
+	cmd.AddCommand(listCmd)
+
+	return cmd
+}
+
+var taskHeader = []string{"ID", "Status", "Started At", "Duration", "Runner"}
+
+func newTaskTable(w io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(taskHeader)
+	return table
+}
+
+func taskRow(t runner.Task, now time.Time) []string {
+	duration := ""
+	if d, ok := taskDuration(t, now); ok {
+		duration = d.String()
+	}
+	return []string{t.ID, t.Status, formatOptionalTime(t.StartedAt), duration, t.RunnerName}
+}
+
+// taskDuration is how long a task ran for, or has been running for if it
+// hasn't stopped yet. It is false for tasks that haven't started.
+func taskDuration(t runner.Task, now time.Time) (time.Duration, bool) {
+	if t.StartedAt == nil {
+		return 0, false
+	}
+	end := now
+	if t.StoppedAt != nil {
+		end = *t.StoppedAt
+	}
+	return end.Sub(*t.StartedAt).Round(time.Second), true
+}
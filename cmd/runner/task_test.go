@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_TaskList(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	started := now.Add(-10 * time.Minute)
+	stopped := now.Add(-5 * time.Minute)
+	newMock := func() *runnerMock {
+		return &runnerMock{tasks: []runner.Task{
+			{ID: "task-a", ResourceClass: "my-namespace/my-resource-class", Status: "running", StartedAt: &stopped, RunnerName: "host-a"},
+			{ID: "task-b", ResourceClass: "my-namespace/my-resource-class", Status: "succeeded", StartedAt: &started, StoppedAt: &stopped, RunnerName: "host-b"},
+			{ID: "task-c", ResourceClass: "my-namespace/my-resource-class", Status: "queued"},
+		}}
+	}
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newTaskCommand, append([]string{"list", "my-namespace/my-resource-class"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		out, err := run(newMock(), "--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `| ID | Status | Started At | Duration | Runner |
+| --- | --- | --- | --- | --- |
+| task-a | running | 2021-06-01T11:55:00Z | 5m0s | host-a |
+| task-b | succeeded | 2021-06-01T11:50:00Z | 5m0s | host-b |
+| task-c | queued |  |  |  |
+`))
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		out, err := run(newMock(), "--limit", "1", "--output", "yaml")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "id: task-a"))
+		assert.Check(t, !strings.Contains(out, "task-b"))
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		stdout, stderr, err := executeRunnerCommand(t, &runnerOpts{r: newMock()}, newTaskCommand, "list", "my-namespace/my-resource-class", "--limit", "1", "--page-token", "1", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stdout, `"id": "task-b"`))
+		assert.Check(t, !strings.Contains(stdout, "task-a"))
+		assert.Check(t, cmp.Equal(stderr, "Stopped after 1 tasks, use --page-token 2 to list the next ones\n"))
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		_, err := run(newMock(), "--limit", "-1")
+		assert.Error(t, err, "invalid limit -1, expected a positive number or 0 for every task")
+	})
+}
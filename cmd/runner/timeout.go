@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Default limits for runner operations, overridden for all commands by
+// --request-timeout and for the list commands by --list-timeout.
+const (
+	listTimeout   = 30 * time.Second
+	mutateTimeout = 15 * time.Second
+	bulkTimeout   = 5 * time.Minute
+)
+
+// timeout picks the limit for an operation: a per-command override wins over
+// the global --request-timeout, which wins over the operation's default.
+func (o *runnerOpts) timeout(def, override time.Duration) time.Duration {
+	switch {
+	case override > 0:
+		return override
+	case o.requestTimeout > 0:
+		return o.requestTimeout
+	default:
+		return def
+	}
+}
+
+// withTimeout runs fn with a runner whose API requests are cancelled once limit
+// has passed. If that makes it fail, the error names the operation that timed
+// out. Requests are cancelled rather than left running, so an operation
+// reported as timed out hasn't carried on regardless.
+func (o *runnerOpts) withTimeout(operation string, limit time.Duration, fn func(r running) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+
+	err := fn(o.r.WithContext(ctx))
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s", operation, limit)
+	}
+	return err
+}
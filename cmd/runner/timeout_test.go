@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/rest"
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_timeout(t *testing.T) {
+	o := runnerOpts{}
+	assert.Check(t, cmp.Equal(o.timeout(listTimeout, 0), 30*time.Second))
+	assert.Check(t, cmp.Equal(o.timeout(mutateTimeout, 0), 15*time.Second))
+	assert.Check(t, cmp.Equal(o.timeout(bulkTimeout, 0), 5*time.Minute))
+
+	o.requestTimeout = time.Minute
+	assert.Check(t, cmp.Equal(o.timeout(listTimeout, 0), time.Minute))
+	assert.Check(t, cmp.Equal(o.timeout(listTimeout, time.Second), time.Second))
+}
+
+func Test_CommandsEnforceTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(*runnerOpts, validator) *cobra.Command
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "resource-class create",
+			command: newResourceClassCommand,
+			args:    []string{"create", "my-namespace/my-resource-class", "desc"},
+			wantErr: "create resource-class timed out after 20ms",
+		},
+		{
+			name:    "resource-class delete",
+			command: newResourceClassCommand,
+			args:    []string{"delete", "my-namespace/my-resource-class"},
+			wantErr: "delete resource-class timed out after 20ms",
+		},
+		{
+			name:    "resource-class list",
+			command: newResourceClassCommand,
+			args:    []string{"list", "my-namespace"},
+			wantErr: "list resource-classes timed out after 20ms",
+		},
+		{
+			name:    "token create",
+			command: newTokenCommand,
+			args:    []string{"create", "my-namespace/my-resource-class", "my-token"},
+			wantErr: "create token timed out after 20ms",
+		},
+		{
+			name:    "resource-class set-token-ttl",
+			command: newResourceClassCommand,
+			args:    []string{"set-token-ttl", "--id", "some-id", "--ttl", "7d"},
+			wantErr: "set token TTL timed out after 20ms",
+		},
+		{
+			name:    "token delete",
+			command: newTokenCommand,
+			args:    []string{"delete", "some-id"},
+			wantErr: "delete token timed out after 20ms",
+		},
+		{
+			name:    "token delete-all",
+			command: newTokenCommand,
+			args:    []string{"delete-all", "my-namespace/my-resource-class"},
+			wantErr: "delete tokens timed out after 20ms",
+		},
+		{
+			name:    "token list",
+			command: newTokenCommand,
+			args:    []string{"list", "my-namespace/my-resource-class"},
+			wantErr: "list tokens timed out after 20ms",
+		},
+		{
+			name:    "instance list",
+			command: newRunnerInstanceCommand,
+			args:    []string{"list", "my-namespace"},
+			wantErr: "list runner instances timed out after 20ms",
+		},
+		{
+			name:    "instance list with its own timeout",
+			command: newRunnerInstanceCommand,
+			args:    []string{"list", "my-namespace", "--list-timeout", "10ms"},
+			wantErr: "list runner instances timed out after 10ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := runnerMock{delay: time.Second}
+			_, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock, requestTimeout: 20 * time.Millisecond}, tt.command, tt.args...)
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("fast operations are unaffected", func(t *testing.T) {
+		mock := runnerMock{}
+		_, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock, requestTimeout: time.Second}, newTokenCommand, "list", "my-namespace/my-resource-class")
+		assert.NilError(t, err)
+	})
+}
+
+func Test_withTimeoutCancelsRequests(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+
+	rc := rest.New(server.URL, "api/v2", "fake-token")
+	rc.SetRetryOptions(rest.RetryOptions{})
+	o := runnerOpts{r: apiRunner{runner.New(rc)}}
+	err := o.withTimeout("delete token", 20*time.Millisecond, func(r running) error {
+		return r.DeleteToken("some-id")
+	})
+	assert.Error(t, err, "delete token timed out after 20ms")
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the request was left running after it timed out")
+	}
+}
@@ -1,10 +1,18 @@
 package runner
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
 )
 
 func newTokenCommand(o *runnerOpts, preRunE validator) *cobra.Command {
@@ -13,52 +21,568 @@ func newTokenCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 		Short: "Operate on runner tokens",
 	}
 
-	cmd.AddCommand(&cobra.Command{
-		Use:     "create <resource-class> <nickname>",
-		Short:   "Create a token for a resource-class",
+	var createOutput, expiry string
+	var printAgentConfig, saveKeychain bool
+	createCmd := &cobra.Command{
+		Use:   "create <resource-class> <nickname>",
+		Short: "Create a token for a resource-class",
+		Example: `  circleci runner token create my-namespace/my-resource-class my-token
+  circleci runner token create my-namespace/my-resource-class my-token --expiry 72h
+  circleci runner token create my-namespace/my-resource-class my-token --print-agent-config > launch-agent-config.yaml
+  circleci runner token create my-namespace/my-resource-class my-token --output json
+  eval "$(circleci runner token create my-namespace/my-resource-class my-token --output env)"
+  circleci runner token create my-namespace/my-resource-class my-token --save-keychain --output json`,
 		Args:    cobra.ExactArgs(2),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			token, err := o.r.CreateToken(args[0], args[1])
+			if createOutput != "yaml" && createOutput != "json" && createOutput != "env" {
+				return fmt.Errorf("unsupported output format %q, expected one of yaml, json, env", createOutput)
+			}
+			if printAgentConfig && createOutput != "yaml" {
+				return errors.New("--print-agent-config can only be used with --output yaml")
+			}
+
+			var opts runner.TokenOptions
+			if expiry != "" {
+				expiresAt, err := parseExpiry(expiry, timeNow())
+				if err != nil {
+					return err
+				}
+				opts.ExpiresAt = &expiresAt
+			}
+
+			var token *runner.Token
+			err := o.withTimeout("create token", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				if opts.ExpiresAt == nil {
+					if opts.ExpiresAt, err = defaultTokenExpiry(r, args[0], timeNow()); err != nil {
+						return err
+					}
+				}
+				token, err = createToken(r, args[0], args[1], opts, cmd.ErrOrStderr())
+				return err
+			})
+			if errors.Is(err, runner.ErrTokenExpiryNotSupported) {
+				return fmt.Errorf("%w: token %s was created without an expiry, delete it with `circleci runner token delete %s`",
+					err, token.ID, token.ID)
+			}
 			if err != nil {
 				return err
 			}
-			return generateConfig(*token, cmd.OutOrStdout())
+
+			if saveKeychain {
+				if err = keychainSet(token.ID, token.Token); err != nil {
+					return fmt.Errorf("failed to save token %s to the keychain, delete it with `circleci runner token delete %s`: %w",
+						token.ID, token.ID, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Saved token %s to the keychain, show it with `circleci runner token show %s`\n", token.ID, token.ID)
+			}
+			return writeCreatedToken(cmd.OutOrStdout(), cmd.ErrOrStderr(), createOutput, printAgentConfig, *token)
 		},
-	})
+	}
+	createCmd.PersistentFlags().BoolVar(&saveKeychain, "save-keychain", false,
+		"Also save the token in the OS keychain (macOS Keychain, Windows Credential Manager or libsecret)")
+	createCmd.PersistentFlags().BoolVar(&printAgentConfig, "print-agent-config", false,
+		"Print a complete launch-agent-config.yaml for the token, with placeholders to fill in for each machine")
+	createCmd.PersistentFlags().StringVar(&createOutput, "output", "yaml",
+		"Output format, one of yaml (launch-agent config), json (the token) or env")
+	createCmd.PersistentFlags().StringVar(&expiry, "expiry", "",
+		"Make the token expire after a duration, such as 72h or 7d, or at an RFC3339 time (default is the resource-class token TTL)")
+	cmd.AddCommand(createCmd)
 
-	cmd.AddCommand(&cobra.Command{
+	var deleteDryRun bool
+	deleteCmd := &cobra.Command{
 		Use:     "delete <token-id>",
 		Short:   "Delete a token",
 		Aliases: []string{"rm"},
+		Example: `  circleci runner token delete 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b
+  circleci runner token delete 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b --dry-run`,
 		Args:    cobra.ExactArgs(1),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			return o.r.DeleteToken(args[0])
+			if deleteDryRun {
+				_, err := fmt.Fprintf(cmd.OutOrStdout(), "Would delete token %s\n", args[0])
+				return err
+			}
+			err := o.withTimeout("delete token", o.timeout(mutateTimeout, 0), func(r running) error {
+				return r.DeleteToken(args[0])
+			})
+			if err != nil {
+				return err
+			}
+			// A token saved with --save-keychain is no use once deleted.
+			if err = keychainDelete(args[0]); err != nil && !errors.Is(err, errKeychainNotFound) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Token %s was deleted, but not removed from the keychain: %s\n", args[0], err)
+			}
+			return nil
 		},
-	})
+	}
+	deleteCmd.PersistentFlags().BoolVar(&deleteDryRun, "dry-run", false,
+		"Print the token that would be deleted, without deleting it")
+	cmd.AddCommand(deleteCmd)
+
+	showCmd := &cobra.Command{
+		Use:   "show <token-id>",
+		Short: "Print a token saved in the OS keychain",
+		Long: `Print a token saved in the OS keychain by token create --save-keychain.
 
-	cmd.AddCommand(&cobra.Command{
+The token is read from this machine's keychain, so no CircleCI API token is needed.`,
+		Example: `  circleci runner token show 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b`,
+		Args:    cobra.ExactArgs(1),
+		// The token comes from the keychain rather than the API.
+		PersistentPreRunE: func(*cobra.Command, []string) error { return o.applySettings() },
+		RunE: func(_ *cobra.Command, args []string) error {
+			secret, err := keychainGet(args[0])
+			if errors.Is(err, errKeychainNotFound) {
+				return fmt.Errorf("token %s is %w, save tokens there with `circleci runner token create --save-keychain`", args[0], err)
+			}
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), secret)
+			return err
+		},
+	}
+	cmd.AddCommand(showCmd)
+
+	var rotateOutput string
+	rotateCmd := &cobra.Command{
+		Use:   "rotate <resource-class> <nickname>",
+		Short: "Replace the token with a nickname by a new one",
+		Long: `Replace the token with a nickname by a new one.
+
+Creates a new token with the same nickname, prints it, then deletes the tokens
+it replaces. If deleting them fails, the new token is kept so it can still be used.`,
+		Example: `  circleci runner token rotate my-namespace/my-resource-class my-token
+  eval "$(circleci runner token rotate my-namespace/my-resource-class my-token --output env)"`,
+		Args:    cobra.ExactArgs(2),
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if rotateOutput != "yaml" && rotateOutput != "json" && rotateOutput != "env" {
+				return fmt.Errorf("unsupported output format %q, expected one of yaml, json, env", rotateOutput)
+			}
+
+			var old []runner.Token
+			err := o.withTimeout("list tokens", o.timeout(listTimeout, 0), func(r running) error {
+				tokens, err := r.GetRunnerTokensByResourceClass(args[0])
+				for _, t := range tokens {
+					if t.Nickname == args[1] {
+						old = append(old, t)
+					}
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if len(old) == 0 {
+				return fmt.Errorf("no token %s found for resource-class %s, create one with `circleci runner token create`", args[1], args[0])
+			}
+
+			var token *runner.Token
+			err = o.withTimeout("create token", o.timeout(mutateTimeout, 0), func(r running) (err error) {
+				var opts runner.TokenOptions
+				if opts.ExpiresAt, err = defaultTokenExpiry(r, args[0], timeNow()); err != nil {
+					return err
+				}
+				token, err = createToken(r, args[0], args[1], opts, cmd.ErrOrStderr())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("%w, the existing token was kept", err)
+			}
+
+			if err = writeCreatedToken(cmd.OutOrStdout(), cmd.ErrOrStderr(), rotateOutput, false, *token); err != nil {
+				return err
+			}
+
+			for _, t := range old {
+				err = o.withTimeout("delete token", o.timeout(mutateTimeout, 0), func(r running) error {
+					return r.DeleteToken(t.ID)
+				})
+				if err != nil {
+					return fmt.Errorf("created token %s but failed to delete the token %s it replaces, "+
+						"delete it with `circleci runner token delete %s`: %w", token.ID, t.ID, t.ID, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Deleted token %s\n", t.ID)
+			}
+			return nil
+		},
+	}
+	rotateCmd.PersistentFlags().StringVar(&rotateOutput, "output", "yaml",
+		"Output format, one of yaml (launch-agent config), json (the token) or env")
+	cmd.AddCommand(rotateCmd)
+
+	var deleteAll bulkOptions
+	var deleteAllForce, deleteAllDryRun bool
+	deleteAllCmd := &cobra.Command{
+		Use:   "delete-all <resource-class>",
+		Short: "Delete every token of a resource-class",
+		Long: `Delete every token of a resource-class.
+
+Runners using the tokens can no longer connect once they are deleted. The
+number of tokens is shown before asking for confirmation, unless --force is
+given.`,
+		Example: `  circleci runner token delete-all my-namespace/my-resource-class
+  circleci runner token delete-all my-namespace/my-resource-class --dry-run
+  circleci runner token delete-all my-namespace/my-resource-class --force --continue-on-error --output json`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := deleteAll.validate(); err != nil {
+				return err
+			}
+
+			var tokens []runner.Token
+			err := o.withTimeout("delete tokens", o.timeout(bulkTimeout, 0), func(r running) (err error) {
+				tokens, err = r.GetRunnerTokensByResourceClass(args[0])
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if deleteAllDryRun {
+				for _, token := range tokens {
+					if _, err = fmt.Fprintf(cmd.OutOrStdout(), "Would delete token %s (%s)\n", token.Nickname, token.ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			if len(tokens) > 0 && !deleteAllForce &&
+				!o.askToConfirm(fmt.Sprintf("Are you sure you want to delete all %d tokens of resource-class %s?", len(tokens), args[0])) {
+				return fmt.Errorf("not deleting the tokens of resource-class %s", args[0])
+			}
+
+			nicknames := map[string]string{}
+			ids := make([]string, len(tokens))
+			for i, token := range tokens {
+				ids[i] = token.ID
+				nicknames[token.ID] = token.Nickname
+			}
+			var results []bulkResult
+			err = o.withTimeout("delete tokens", o.timeout(bulkTimeout, 0), func(r running) error {
+				results = deleteAll.run(ids, func(id string) (string, error) {
+					if err := r.DeleteToken(id); err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("deleted token %s", nicknames[id]), nil
+				})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return deleteAll.report(cmd.OutOrStdout(), "delete tokens", len(tokens), results)
+		},
+	}
+	deleteAllCmd.PersistentFlags().BoolVar(&deleteAllDryRun, "dry-run", false,
+		"Print the tokens that would be deleted, without deleting them")
+	deleteAllCmd.PersistentFlags().BoolVarP(&deleteAllForce, "force", "f", false,
+		"Delete the tokens without asking for confirmation")
+	deleteAll.addFlags(deleteAllCmd, o)
+	cmd.AddCommand(deleteAllCmd)
+
+	var listTimeoutFlag time.Duration
+	var listOutput, olderThan string
+	var exitCode bool
+	listCmd := &cobra.Command{
 		Use:     "list <resource-class>",
 		Aliases: []string{"ls"},
 		Short:   "List tokens for a resource-class",
+		Example: `  circleci runner token list my-namespace/my-resource-class
+  circleci runner token list my-namespace/my-resource-class --older-than 90d --exit-code`,
 		Args:    cobra.ExactArgs(1),
 		PreRunE: preRunE,
 		RunE: func(_ *cobra.Command, args []string) error {
-			tokens, err := o.r.GetRunnerTokensByResourceClass(args[0])
+			if listOutput == "env" {
+				return errEnvOutputList
+			}
+			if listOutput != "table" && listOutput != "json" && listOutput != "yaml" && listOutput != "markdown" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml, markdown", listOutput)
+			}
+
+			var minAge time.Duration
+			if olderThan != "" {
+				var err error
+				if minAge, err = parseDays(olderThan); err != nil || minAge <= 0 {
+					return fmt.Errorf("invalid --older-than age %q, expected a positive duration such as 90d", olderThan)
+				}
+			}
+
+			var tokens []runner.Token
+			err := o.withTimeout("list tokens", o.timeout(listTimeout, listTimeoutFlag), func(r running) (err error) {
+				tokens, err = r.GetRunnerTokensByResourceClass(args[0])
+				return err
+			})
 			if err != nil {
 				return err
 			}
 
+			now := timeNow()
+			if minAge > 0 {
+				old := []runner.Token{}
+				for _, t := range tokens {
+					if now.Sub(t.CreatedAt) >= minAge {
+						old = append(old, t)
+					}
+				}
+				tokens = old
+			}
+
+			if err = writeTokenList(cmd.OutOrStdout(), listOutput, tokens, now); err != nil {
+				return err
+			}
+			if exitCode && len(tokens) > 0 {
+				if minAge > 0 {
+					return fmt.Errorf("found %d token(s) older than %s", len(tokens), olderThan)
+				}
+				return fmt.Errorf("found %d token(s)", len(tokens))
+			}
+			return nil
+		},
+	}
+	listCmd.PersistentFlags().DurationVar(&listTimeoutFlag, "list-timeout", 0,
+		"Time limit for listing tokens (default 30s)")
+	listCmd.PersistentFlags().StringVar(&listOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json, yaml or markdown")
+	listCmd.PersistentFlags().StringVar(&olderThan, "older-than", "",
+		"Only list tokens created at least this long ago, such as 90d")
+	listCmd.PersistentFlags().BoolVar(&exitCode, "exit-code", false,
+		"Exit with a non-zero status if any tokens are listed, to alert on tokens due for rotation")
+	cmd.AddCommand(listCmd)
+
+	var usageID, usageOutput string
+	usageCmd := &cobra.Command{
+		Use:     "usage",
+		Aliases: []string{"describe"},
+		Short:   "Show which hosts recently used a token",
+		Example: "  circleci runner token usage --id 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b",
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if usageOutput != "table" && usageOutput != "json" && usageOutput != "yaml" {
+				return fmt.Errorf("unsupported output format %q, expected one of table, json, yaml", usageOutput)
+			}
+
+			var usage []runner.TokenUsage
+			err := o.withTimeout("get token usage", o.timeout(listTimeout, 0), func(r running) (err error) {
+				usage, err = r.GetTokenUsage(usageID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if usageOutput != "table" {
+				if usage == nil {
+					usage = []runner.TokenUsage{}
+				}
+				return writeStructured(cmd.OutOrStdout(), usageOutput, usage)
+			}
+
+			if len(usage) == 0 {
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "No recent usage found for token %s\n", usageID)
+				return err
+			}
+
 			table := tablewriter.NewWriter(cmd.OutOrStdout())
 			defer table.Render()
-			table.SetHeader([]string{"ID", "Nickname", "Created At"})
-			for _, token := range tokens {
-				table.Append([]string{token.ID, token.Nickname, token.CreatedAt.Format(time.RFC3339)})
+			table.SetHeader([]string{"Hostname", "IP", "Last Used"})
+			for _, u := range usage {
+				table.Append([]string{u.Hostname, u.IP, formatTime(u.LastUsed)})
 			}
 			return nil
 		},
-	})
+	}
+	usageCmd.PersistentFlags().StringVar(&usageID, "id", "", "ID of the token")
+	usageCmd.PersistentFlags().StringVar(&usageOutput, "output", o.outputDefault("table", "table", "json", "yaml"),
+		"Output format, one of table, json or yaml")
+	_ = usageCmd.MarkPersistentFlagRequired("id")
+	cmd.AddCommand(usageCmd)
 
 	return cmd
 }
+
+// createTokenAttempts is how many times creating a token is tried when the
+// response is lost on the way back.
+const createTokenAttempts = 2
+
+// createdClockSkew is how far the API's clock may be behind ours when telling
+// whether a token was created by an attempt whose response was lost.
+const createdClockSkew = time.Minute
+
+// createToken creates a token, trying again if the response was lost. Before
+// trying again it checks whether the lost attempt created the token anyway,
+// and if so gives up rather than create a duplicate.
+func createToken(r running, resourceClass, nickname string, opts runner.TokenOptions, stderr io.Writer) (*runner.Token, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		started := timeNow()
+
+		var token *runner.Token
+		token, err = r.CreateTokenWithOptions(resourceClass, nickname, opts)
+		if !isLostResponse(err) {
+			return token, err
+		}
+
+		tokens, listErr := r.GetRunnerTokensByResourceClass(resourceClass)
+		if listErr != nil {
+			return nil, fmt.Errorf("%w (couldn't check whether the token was created anyway: %v)", err, listErr)
+		}
+		if created := findCreatedToken(tokens, nickname, started.Add(-createdClockSkew)); created != nil {
+			return nil, fmt.Errorf("token %s was created as %s but the response was lost, so its secret can't be shown. "+
+				"Not retrying, to avoid a duplicate token: delete it with `circleci runner token delete %s` and create it again",
+				nickname, created.ID, created.ID)
+		}
+
+		if attempt == createTokenAttempts {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "Creating token %s failed, retrying: %s\n", nickname, err)
+	}
+}
+
+// isLostResponse reports whether err means the API may have handled a request
+// without us hearing back, rather than having rejected it.
+func isLostResponse(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// findCreatedToken returns the most recent token with the nickname created no
+// earlier than since, or nil if there isn't one.
+func findCreatedToken(tokens []runner.Token, nickname string, since time.Time) *runner.Token {
+	var found *runner.Token
+	for i, t := range tokens {
+		if t.Nickname != nickname || t.CreatedAt.Before(since) {
+			continue
+		}
+		if found == nil || t.CreatedAt.After(found.CreatedAt) {
+			found = &tokens[i]
+		}
+	}
+	return found
+}
+
+// writeCreatedToken prints a new token in output, one of yaml, json or env.
+// yaml prints the launch-agent config, in full with agentConfig.
+func writeCreatedToken(stdout, stderr io.Writer, output string, agentConfig bool, token runner.Token) error {
+	switch output {
+	case "env":
+		return writeEnv(stdout, tokenEnv(token))
+	case "json":
+		return writeJSON(stdout, token)
+	}
+
+	if token.ExpiresAt != nil {
+		fmt.Fprintf(stderr, "Token expires at %s\n", formatTime(*token.ExpiresAt))
+	}
+	if agentConfig {
+		return generateAgentConfig(token, stdout)
+	}
+	return generateConfig(token, stdout)
+}
+
+func tokenEnv(t runner.Token) []envVar {
+	vars := []envVar{
+		{"CIRCLECI_RUNNER_TOKEN", t.Token},
+		{"CIRCLECI_RUNNER_TOKEN_ID", t.ID},
+		{"CIRCLECI_RUNNER_TOKEN_NICKNAME", t.Nickname},
+		{"CIRCLECI_RESOURCE_CLASS", t.ResourceClass},
+	}
+	if t.ExpiresAt != nil {
+		vars = append(vars, envVar{"CIRCLECI_RUNNER_TOKEN_EXPIRES_AT", formatTime(*t.ExpiresAt)})
+	}
+	return vars
+}
+
+// parseExpiry accepts either a duration from now or an absolute RFC3339 time,
+// which must be in the future.
+func parseExpiry(expiry string, now time.Time) (time.Time, error) {
+	expiresAt, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		d, derr := parseDays(expiry)
+		if derr != nil {
+			return time.Time{}, fmt.Errorf("invalid expiry %q, expected a duration such as 72h or an RFC3339 time", expiry)
+		}
+		expiresAt = now.Add(d)
+	}
+
+	if !expiresAt.After(now) {
+		return time.Time{}, fmt.Errorf("expiry %s is in the past", expiresAt.Format(time.RFC3339))
+	}
+	return expiresAt.UTC(), nil
+}
+
+// writeTokenList prints tokens in the given output format, with their age as
+// of now in the table formats.
+func writeTokenList(w io.Writer, output string, tokens []runner.Token, now time.Time) error {
+	if output == "json" || output == "yaml" {
+		if tokens == nil {
+			tokens = []runner.Token{}
+		}
+		return writeStructured(w, output, tokens)
+	}
+
+	header := []string{"ID", "Nickname", "Created At", "Age"}
+	rows := make([][]string, len(tokens))
+	for i, token := range tokens {
+		rows[i] = []string{token.ID, token.Nickname, formatTime(token.CreatedAt), formatAge(now.Sub(token.CreatedAt))}
+	}
+
+	if output == "markdown" {
+		return writeMarkdownTable(w, header, rows)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.AppendBulk(rows)
+	table.Render()
+	return nil
+}
+
+// formatAge renders an age in whole days, as token lifetimes are given in
+// days, or in hours and minutes for anything younger than a day.
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	if d < time.Minute {
+		return "0m"
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// parseDays parses a duration like time.ParseDuration, also accepting a whole
+// number of days such as 7d, as token lifetimes are usually given in days.
+func parseDays(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// defaultTokenExpiry returns when a token created now should expire under the
+// resource-class's default token TTL, or nil if it has none.
+func defaultTokenExpiry(r running, resourceClass string, now time.Time) (*time.Time, error) {
+	rc, err := r.GetResourceClassByName(resourceClass)
+	// Creating the token will report a missing resource-class.
+	if errors.Is(err, runner.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.DefaultTokenTTLSeconds <= 0 {
+		return nil, nil
+	}
+	expiresAt := now.Add(time.Duration(rc.DefaultTokenTTLSeconds) * time.Second).UTC()
+	return &expiresAt, nil
+}
@@ -0,0 +1,409 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_TokenUsage(t *testing.T) {
+	mock := runnerMock{}
+	cmd := newTokenCommand(&runnerOpts{r: &mock}, nil)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+
+	t.Run("populated", func(t *testing.T) {
+		defer mock.reset()
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		mock.usage = map[string][]runner.TokenUsage{
+			"my-token-id": {
+				{Hostname: "host-a", IP: "192.0.2.1", LastUsed: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)},
+				{Hostname: "host-b", LastUsed: time.Date(2021, 6, 1, 11, 0, 0, 0, time.UTC)},
+			},
+		}
+
+		cmd.SetArgs([]string{"usage", "--id", "my-token-id"})
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stdout.String(), "HOSTNAME"))
+		assert.Check(t, cmp.Contains(stdout.String(), "host-a"))
+		assert.Check(t, cmp.Contains(stdout.String(), "192.0.2.1"))
+		assert.Check(t, cmp.Contains(stdout.String(), "2021-06-01T11:00:00Z"))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		defer mock.reset()
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		cmd.SetArgs([]string{"usage", "--id", "unused-token-id"})
+		err := cmd.Execute()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(stdout.String(), "No recent usage found for token unused-token-id\n"))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		defer mock.reset()
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		mock.usageErr = runner.ErrTokenUsageNotSupported
+
+		cmd.SetArgs([]string{"usage", "--id", "my-token-id"})
+		err := cmd.Execute()
+		assert.Error(t, err, "token usage is not supported by this API")
+	})
+}
+
+func Test_TokenCreateExpiry(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	run := func(mock *runnerMock, args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: mock}, newTokenCommand, append([]string{"create", "my-namespace/my-resource-class", "my-token"}, args...)...)
+	}
+
+	t.Run("duration", func(t *testing.T) {
+		mock := runnerMock{}
+
+		stdout, _, err := run(&mock, "--expiry", "72h", "--output", "env")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.tokens[0].ExpiresAt, timePtr(now.Add(72*time.Hour))))
+		assert.Check(t, cmp.Contains(stdout, "CIRCLECI_RUNNER_TOKEN_EXPIRES_AT=2021-06-04T12:00:00Z\n"))
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		mock := runnerMock{}
+
+		stdout, stderr, err := run(&mock, "--expiry", "2021-07-01T00:00:00Z")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.tokens[0].ExpiresAt, timePtr(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC))))
+		assert.Check(t, cmp.Equal(stderr, "Token expires at 2021-07-01T00:00:00Z\n"))
+		assert.Check(t, cmp.Contains(stdout, "auth_token: fake-token"))
+	})
+
+	t.Run("days", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, _, err := run(&mock, "--expiry", "7d")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.tokens[0].ExpiresAt, timePtr(now.Add(7*24*time.Hour))))
+	})
+
+	t.Run("inherits the resource-class default", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ResourceClass: "my-namespace/my-resource-class", DefaultTokenTTLSeconds: 3600},
+		}}
+
+		_, stderr, err := run(&mock)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.tokens[0].ExpiresAt, timePtr(now.Add(time.Hour))))
+		assert.Check(t, cmp.Equal(stderr, "Token expires at 2021-06-01T13:00:00Z\n"))
+	})
+
+	t.Run("expiry overrides the resource-class default", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ResourceClass: "my-namespace/my-resource-class", DefaultTokenTTLSeconds: 3600},
+		}}
+
+		_, _, err := run(&mock, "--expiry", "72h")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(mock.tokens[0].ExpiresAt, timePtr(now.Add(72*time.Hour))))
+	})
+
+	t.Run("no default", func(t *testing.T) {
+		mock := runnerMock{resourceClasses: []runner.ResourceClass{
+			{ResourceClass: "my-namespace/my-resource-class"},
+		}}
+
+		_, stderr, err := run(&mock)
+		assert.NilError(t, err)
+		assert.Check(t, mock.tokens[0].ExpiresAt == nil)
+		assert.Check(t, cmp.Equal(stderr, ""))
+	})
+
+	t.Run("in the past", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, _, err := run(&mock, "--expiry", "2021-05-01T00:00:00Z")
+		assert.Error(t, err, "expiry 2021-05-01T00:00:00Z is in the past")
+		assert.Check(t, cmp.Len(mock.tokens, 0))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, _, err := run(&mock, "--expiry", "next week")
+		assert.Error(t, err, `invalid expiry "next week", expected a duration such as 72h or an RFC3339 time`)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		mock := runnerMock{expiryUnsupported: true}
+
+		_, _, err := run(&mock, "--expiry", "72h")
+		assert.Check(t, errors.Is(err, runner.ErrTokenExpiryNotSupported))
+		assert.ErrorContains(t, err, "circleci runner token delete 987905d7-6780-4fed-a637-37277c373629")
+	})
+}
+
+func Test_TokenCreateAgentConfig(t *testing.T) {
+	run := func(args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &runnerMock{}}, newTokenCommand, append([]string{"create", "my-namespace/my-resource-class", "my-token"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("printed", func(t *testing.T) {
+		out, err := run("--print-agent-config")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "resource-class my-namespace/my-resource-class"))
+
+		var config agentConfig
+		assert.NilError(t, yaml.Unmarshal([]byte(out), &config))
+		assert.Check(t, cmp.Equal(config.API.AuthToken, "fake-token"))
+		assert.Assert(t, config.Runner != nil)
+		assert.Check(t, cmp.Equal(config.Runner.WorkingDirectory, "/var/opt/circleci/workdir/%s"))
+	})
+
+	t.Run("with env output", func(t *testing.T) {
+		_, err := run("--print-agent-config", "--output", "env")
+		assert.Error(t, err, "--print-agent-config can only be used with --output yaml")
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// lostResponseMock fails the first creates with a network error, after the
+// token has been created when reached is set, as when the response is lost.
+type lostResponseMock struct {
+	*runnerMock
+	lose    int
+	reached bool
+	creates int
+}
+
+func (l *lostResponseMock) WithContext(ctx context.Context) running {
+	l.runnerMock.WithContext(ctx)
+	return l
+}
+
+func (l *lostResponseMock) CreateTokenWithOptions(resourceClass, nickname string, opts runner.TokenOptions) (*runner.Token, error) {
+	l.creates++
+	if l.creates > l.lose {
+		return l.runnerMock.CreateTokenWithOptions(resourceClass, nickname, opts)
+	}
+	if l.reached {
+		_, _ = l.runnerMock.CreateTokenWithOptions(resourceClass, nickname, opts)
+	}
+	return nil, &url.Error{Op: "Post", URL: "https://circleci.com/api/v2/runner/token", Err: io.ErrUnexpectedEOF}
+}
+
+func Test_TokenCreateLostResponse(t *testing.T) {
+	run := func(r running) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: r}, newTokenCommand, "create", "my-namespace/my-resource-class", "my-token")
+	}
+
+	t.Run("created before the response was lost", func(t *testing.T) {
+		mock := &lostResponseMock{runnerMock: &runnerMock{}, lose: 1, reached: true}
+
+		_, _, err := run(mock)
+		assert.Error(t, err, "token my-token was created as 987905d7-6780-4fed-a637-37277c373629 but the response was lost, "+
+			"so its secret can't be shown. Not retrying, to avoid a duplicate token: "+
+			"delete it with `circleci runner token delete 987905d7-6780-4fed-a637-37277c373629` and create it again")
+		assert.Check(t, cmp.Equal(mock.creates, 1))
+		assert.Check(t, cmp.Len(mock.tokens, 1))
+	})
+
+	t.Run("not created", func(t *testing.T) {
+		mock := &lostResponseMock{runnerMock: &runnerMock{}, lose: 1}
+
+		stdout, stderr, err := run(mock)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(stderr, "Creating token my-token failed, retrying: "))
+		assert.Check(t, cmp.Contains(stdout, "auth_token: fake-token"))
+		assert.Check(t, cmp.Equal(mock.creates, 2))
+		assert.Check(t, cmp.Len(mock.tokens, 1))
+	})
+
+	t.Run("older token with the same nickname", func(t *testing.T) {
+		mock := &lostResponseMock{runnerMock: &runnerMock{tokens: []runner.Token{
+			{ID: "old", ResourceClass: "my-namespace/my-resource-class", Nickname: "my-token", CreatedAt: time.Now().Add(-time.Hour)},
+		}}, lose: 1}
+
+		_, _, err := run(mock)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(mock.creates, 2))
+		assert.Check(t, cmp.Len(mock.tokens, 2))
+	})
+
+	t.Run("gives up after retrying", func(t *testing.T) {
+		mock := &lostResponseMock{runnerMock: &runnerMock{}, lose: 2}
+
+		_, _, err := run(mock)
+		assert.ErrorContains(t, err, "unexpected EOF")
+		assert.Check(t, cmp.Equal(mock.creates, 2))
+		assert.Check(t, cmp.Len(mock.tokens, 0))
+	})
+}
+
+func Test_TokenStructuredOutput(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: mock}, newTokenCommand, args...)
+		return stdout, err
+	}
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("list as yaml", func(t *testing.T) {
+		mock := runnerMock{tokens: []runner.Token{
+			{ID: "my-token-id", ResourceClass: "my-namespace/my-resource-class", Nickname: "my-token", CreatedAt: created},
+		}}
+
+		out, err := run(&mock, "list", "my-namespace/my-resource-class", "--output", "yaml")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `- id: my-token-id
+  token: ""
+  resource_class: my-namespace/my-resource-class
+  nickname: my-token
+  created_at: "2021-06-01T12:00:00Z"
+`))
+	})
+
+	t.Run("list empty as json", func(t *testing.T) {
+		out, err := run(&runnerMock{}, "list", "my-namespace/my-resource-class", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "[]\n"))
+	})
+
+	t.Run("create as json", func(t *testing.T) {
+		out, err := run(&runnerMock{}, "create", "my-namespace/my-resource-class", "my-token", "--output", "json")
+		assert.NilError(t, err)
+
+		var token runner.Token
+		assert.NilError(t, json.Unmarshal([]byte(out), &token))
+		assert.Check(t, cmp.Equal(token.Token, "fake-token"))
+		assert.Check(t, cmp.Equal(token.Nickname, "my-token"))
+	})
+
+	t.Run("usage as json", func(t *testing.T) {
+		mock := runnerMock{usage: map[string][]runner.TokenUsage{
+			"my-token-id": {{Hostname: "host-a", LastUsed: created}},
+		}}
+
+		out, err := run(&mock, "usage", "--id", "my-token-id", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, `"hostname": "host-a"`))
+	})
+}
+
+func Test_TokenRotate(t *testing.T) {
+	run := func(mock *runnerMock, args ...string) (string, string, error) {
+		return executeRunnerCommand(t, &runnerOpts{r: mock}, newTokenCommand, append([]string{"rotate", "my-namespace/my-resource-class", "my-token"}, args...)...)
+	}
+
+	t.Run("replaces the token", func(t *testing.T) {
+		mock := runnerMock{tokens: []runner.Token{
+			{ID: "old-token-id", ResourceClass: "my-namespace/my-resource-class", Nickname: "my-token"},
+			{ID: "other-token-id", ResourceClass: "my-namespace/my-resource-class", Nickname: "other-token"},
+		}}
+
+		out, stderr, err := run(&mock, "--output", "env")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(out, "CIRCLECI_RUNNER_TOKEN=fake-token\n"))
+		assert.Check(t, cmp.Equal(stderr, "Deleted token old-token-id\n"))
+
+		ids := []string{}
+		for _, token := range mock.tokens {
+			ids = append(ids, token.ID)
+		}
+		assert.Check(t, cmp.DeepEqual(ids, []string{"other-token-id", "987905d7-6780-4fed-a637-37277c373629"}))
+	})
+
+	t.Run("no token to rotate", func(t *testing.T) {
+		mock := runnerMock{}
+
+		_, _, err := run(&mock)
+		assert.Error(t, err, "no token my-token found for resource-class my-namespace/my-resource-class, create one with `circleci runner token create`")
+		assert.Check(t, cmp.Len(mock.tokens, 0))
+	})
+}
+
+func Test_TokenListOlderThan(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	mock := runnerMock{tokens: []runner.Token{
+		{ID: "old", ResourceClass: "my-namespace/my-resource-class", Nickname: "old", CreatedAt: now.Add(-120 * 24 * time.Hour)},
+		{ID: "recent", ResourceClass: "my-namespace/my-resource-class", Nickname: "recent", CreatedAt: now.Add(-10 * 24 * time.Hour)},
+		{ID: "new", ResourceClass: "my-namespace/my-resource-class", Nickname: "new", CreatedAt: now.Add(-90 * time.Minute)},
+	}}
+
+	run := func(args ...string) (string, error) {
+		stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newTokenCommand, append([]string{"list", "my-namespace/my-resource-class"}, args...)...)
+		return stdout, err
+	}
+
+	t.Run("shows ages", func(t *testing.T) {
+		out, err := run("--output", "markdown")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, `| ID | Nickname | Created At | Age |
+| --- | --- | --- | --- |
+| old | old | 2021-05-04T12:00:00Z | 120d |
+| recent | recent | 2021-08-22T12:00:00Z | 10d |
+| new | new | 2021-09-01T10:30:00Z | 1h30m |
+`))
+	})
+
+	t.Run("filters by age", func(t *testing.T) {
+		out, err := run("--older-than", "90d", "--output", "json")
+		assert.NilError(t, err)
+		var tokens []runner.Token
+		assert.NilError(t, json.Unmarshal([]byte(out), &tokens))
+		assert.Assert(t, cmp.Len(tokens, 1))
+		assert.Check(t, cmp.Equal(tokens[0].ID, "old"))
+	})
+
+	t.Run("exit code when stale tokens are found", func(t *testing.T) {
+		out, err := run("--older-than", "7d", "--exit-code", "--output", "markdown")
+		assert.Check(t, cmp.Error(err, "found 2 token(s) older than 7d"))
+		assert.Check(t, cmp.Contains(out, "| old |"))
+	})
+
+	t.Run("no exit code when none are found", func(t *testing.T) {
+		out, err := run("--older-than", "365d", "--exit-code", "--output", "json")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(out, "[]\n"))
+	})
+
+	t.Run("bad age", func(t *testing.T) {
+		_, err := run("--older-than", "old")
+		assert.Error(t, err, `invalid --older-than age "old", expected a positive duration such as 90d`)
+	})
+}
+
+func Test_TokenDeleteDryRun(t *testing.T) {
+	mock := runnerMock{tokens: []runner.Token{
+		{ID: "9e12ad09-527d-482c-b7ce-1a2fd20d1b9b", ResourceClass: "my-namespace/my-resource-class", Nickname: "my-token"},
+	}}
+	stdout, _, err := executeRunnerCommand(t, &runnerOpts{r: &mock}, newTokenCommand, "delete", "9e12ad09-527d-482c-b7ce-1a2fd20d1b9b", "--dry-run")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(stdout, "Would delete token 9e12ad09-527d-482c-b7ce-1a2fd20d1b9b\n"))
+	assert.Check(t, cmp.Len(mock.tokens, 1))
+}
@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// instanceEvent describes a change to a runner instance seen between two polls.
+type instanceEvent struct {
+	Time          time.Time `json:"time"`
+	Event         string    `json:"event"`
+	ResourceClass string    `json:"resource_class"`
+	Name          string    `json:"name"`
+	Hostname      string    `json:"hostname"`
+	Status        string    `json:"status"`
+}
+
+func (e instanceEvent) String() string {
+	return fmt.Sprintf("%s %s %s %s %s %s",
+		formatTime(e.Time), e.Event, e.ResourceClass, e.Name, e.Hostname, e.Status)
+}
+
+// watchRunnerInstances polls for the instances matching query and calls emit for
+// every change until ctx is cancelled. Instances present on the first poll are
+// reported as having appeared.
+func watchRunnerInstances(ctx context.Context, r running, query string, interval time.Duration, emit func(instanceEvent) error) error {
+	var prev []runner.RunnerInstance
+	for {
+		instances, err := r.GetRunnerInstances(query)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range diffRunnerInstances(prev, instances, timeNow()) {
+			if err := emit(e); err != nil {
+				return err
+			}
+		}
+		prev = instances
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// clearScreen moves the cursor to the top left of the terminal and clears it,
+// so each frame of a watched table replaces the last.
+const clearScreen = "\033[H\033[2J"
+
+// isTerminal reports whether w is a terminal. It is overridden in tests.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+// instanceEventColors are the terminal colours used to highlight each kind of
+// change in a watched table.
+var instanceEventColors = map[string]int{
+	"appeared":    tablewriter.FgGreenColor,
+	"online":      tablewriter.FgGreenColor,
+	"offline":     tablewriter.FgYellowColor,
+	"disappeared": tablewriter.FgRedColor,
+}
+
+// watchInstanceTable renders the instances returned by list every interval
+// until ctx is cancelled, under a header with the refresh time and status
+// totals, and lists what changed since the previous frame under the table.
+// On a terminal each frame replaces the last and changed rows are
+// highlighted; otherwise frames are appended, separated by a blank line.
+func watchInstanceTable(ctx context.Context, w io.Writer, interval time.Duration,
+	list func() ([]runner.RunnerInstance, error), render func(w io.Writer, instances []runner.RunnerInstance, changed map[string]string)) error {
+	tty := isTerminal(w)
+	var prev []runner.RunnerInstance
+	for frame := 0; ; frame++ {
+		instances, err := list()
+		if err != nil {
+			return err
+		}
+
+		now := timeNow()
+		online := 0
+		for _, r := range instances {
+			if instanceStatus(r, now) == "online" {
+				online++
+			}
+		}
+
+		// Everything appears on the first frame, which isn't worth highlighting.
+		var events []instanceEvent
+		if frame > 0 {
+			events = diffRunnerInstances(prev, instances, now)
+		}
+		prev = instances
+		changed := map[string]string{}
+		if tty {
+			for _, e := range events {
+				changed[e.ResourceClass+"/"+e.Name] = e.Event
+			}
+		}
+
+		switch {
+		case tty:
+			fmt.Fprint(w, clearScreen)
+		case frame > 0:
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Refreshed at %s: %d online, %d offline\n", formatTime(now), online, len(instances)-online)
+		render(w, instances, changed)
+		for _, e := range events {
+			line := fmt.Sprintf("%-11s %s/%s", e.Event, e.ResourceClass, e.Name)
+			if color, ok := instanceEventColors[e.Event]; ok && tty {
+				line = fmt.Sprintf("\033[%dm%s\033[0m", color, line)
+			}
+			fmt.Fprintln(w, line)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// appendWatchedRunnerInstance appends an instance to a watched table, in the
+// colour of the change it went through since the last frame, if any.
+func appendWatchedRunnerInstance(table *tablewriter.Table, fields []instanceField, r runner.RunnerInstance, event string) {
+	color, ok := instanceEventColors[event]
+	if !ok {
+		appendRunnerInstance(table, fields, r)
+		return
+	}
+	colors := make([]tablewriter.Colors, len(fields))
+	for i := range colors {
+		colors[i] = tablewriter.Colors{color}
+	}
+	table.Rich(runnerInstanceRow(fields, r), colors)
+}
+
+func diffRunnerInstances(prev, next []runner.RunnerInstance, now time.Time) []instanceEvent {
+	key := func(r runner.RunnerInstance) string { return r.ResourceClass + "/" + r.Name }
+	event := func(name string, r runner.RunnerInstance) instanceEvent {
+		return instanceEvent{
+			Time:          now,
+			Event:         name,
+			ResourceClass: r.ResourceClass,
+			Name:          r.Name,
+			Hostname:      r.Hostname,
+			Status:        instanceStatus(r, now),
+		}
+	}
+
+	before := map[string]runner.RunnerInstance{}
+	for _, r := range prev {
+		before[key(r)] = r
+	}
+	after := map[string]bool{}
+
+	var events []instanceEvent
+	for _, r := range next {
+		after[key(r)] = true
+		old, ok := before[key(r)]
+		switch {
+		case !ok:
+			events = append(events, event("appeared", r))
+		case instanceStatus(old, now) != instanceStatus(r, now):
+			events = append(events, event(instanceStatus(r, now), r))
+		}
+	}
+	for _, r := range prev {
+		if !after[key(r)] {
+			events = append(events, event("disappeared", r))
+		}
+	}
+	return events
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+// snapshotRunner returns each of its snapshots in turn from GetRunnerInstances,
+// cancelling the watch once they have all been seen.
+type snapshotRunner struct {
+	runnerMock
+	snapshots [][]runner.RunnerInstance
+	cancel    func()
+}
+
+func (s *snapshotRunner) GetRunnerInstances(_ string) ([]runner.RunnerInstance, error) {
+	snapshot := s.snapshots[0]
+	if len(s.snapshots) > 1 {
+		s.snapshots = s.snapshots[1:]
+	} else {
+		s.cancel()
+	}
+	return snapshot, nil
+}
+
+func Test_watchRunnerInstances(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	online := now.Add(-time.Minute)
+	offline := now.Add(-time.Hour)
+
+	a := runner.RunnerInstance{ResourceClass: "ns/rc", Name: "a", Hostname: "host-a", LastConnected: &online}
+	b := runner.RunnerInstance{ResourceClass: "ns/rc", Name: "b", Hostname: "host-b", LastConnected: &online}
+	aOffline := a
+	aOffline.LastConnected = &offline
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &snapshotRunner{
+		snapshots: [][]runner.RunnerInstance{
+			{a},
+			{a, b},
+			{aOffline, b},
+			{aOffline, b},
+			{b},
+		},
+		cancel: cancel,
+	}
+
+	var events []string
+	err := watchRunnerInstances(ctx, r, "ns", time.Millisecond, func(e instanceEvent) error {
+		events = append(events, e.String())
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(events, []string{
+		"2021-06-01T12:00:00Z appeared ns/rc a host-a online",
+		"2021-06-01T12:00:00Z appeared ns/rc b host-b online",
+		"2021-06-01T12:00:00Z offline ns/rc a host-a offline",
+		"2021-06-01T12:00:00Z disappeared ns/rc a host-a offline",
+	}))
+}
+
+func Test_watchInstanceTable(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	online := now.Add(-time.Minute)
+	a := runner.RunnerInstance{ResourceClass: "ns/rc", Name: "a", LastConnected: &online}
+	b := runner.RunnerInstance{ResourceClass: "ns/rc", Name: "b"}
+	c := runner.RunnerInstance{ResourceClass: "ns/rc", Name: "c"}
+
+	fields, err := instanceColumns([]string{"name", "resource_class"}, "")
+	assert.NilError(t, err)
+
+	watch := func(tty bool) string {
+		defer func(f func(io.Writer) bool) { isTerminal = f }(isTerminal)
+		isTerminal = func(io.Writer) bool { return tty }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		r := &snapshotRunner{snapshots: [][]runner.RunnerInstance{{a, c}, {a, b}}, cancel: cancel}
+
+		out := new(bytes.Buffer)
+		err := watchInstanceTable(ctx, out, time.Millisecond, func() ([]runner.RunnerInstance, error) {
+			return r.GetRunnerInstances("ns")
+		}, func(w io.Writer, runners []runner.RunnerInstance, changed map[string]string) {
+			table := newRunnerInstanceTable(w, fields)
+			for _, r := range runners {
+				appendWatchedRunnerInstance(table, fields, r, changed[r.ResourceClass+"/"+r.Name])
+			}
+			table.Render()
+		})
+		assert.NilError(t, err)
+		return out.String()
+	}
+
+	first := `Refreshed at 2021-06-01T12:00:00Z: 1 online, 1 offline
++------+----------------+
+| NAME | RESOURCE CLASS |
++------+----------------+
+| a    | ns/rc          |
+| c    | ns/rc          |
++------+----------------+
+`
+	second := `Refreshed at 2021-06-01T12:00:00Z: 1 online, 1 offline
++------+----------------+
+| NAME | RESOURCE CLASS |
++------+----------------+
+| a    | ns/rc          |
+| b    | ns/rc          |
++------+----------------+
+appeared    ns/rc/b
+disappeared ns/rc/c
+`
+	secondHighlighted := "Refreshed at 2021-06-01T12:00:00Z: 1 online, 1 offline\n" +
+		"+------+----------------+\n" +
+		"| NAME | RESOURCE CLASS |\n" +
+		"+------+----------------+\n" +
+		"| a    | ns/rc          |\n" +
+		"| \033[32mb\033[0m    | \033[32mns/rc\033[0m          |\n" +
+		"+------+----------------+\n" +
+		"\033[32mappeared    ns/rc/b\033[0m\n" +
+		"\033[31mdisappeared ns/rc/c\033[0m\n"
+
+	t.Run("appends frames when not a terminal", func(t *testing.T) {
+		assert.Check(t, cmp.Equal(watch(false), first+"\n"+second))
+	})
+
+	t.Run("redraws frames on a terminal, highlighting changes", func(t *testing.T) {
+		assert.Check(t, cmp.Equal(watch(true), clearScreen+first+clearScreen+secondHighlighted))
+	})
+}
+
+func Test_RunnerInstanceListWatchFlags(t *testing.T) {
+	run := func(args ...string) error {
+		_, _, err := executeRunnerCommand(t, &runnerOpts{r: &runnerMock{}}, newRunnerInstanceCommand, append([]string{"list", "my-namespace", "--watch"}, args...)...)
+		return err
+	}
+
+	assert.Check(t, cmp.Error(run("--output", "json"), "--watch can only be used with --output table and without --group-by"))
+	assert.Check(t, cmp.Error(run("--group-by", "status"), "--watch can only be used with --output table and without --group-by"))
+	assert.Check(t, cmp.Error(run("--watch-interval", "0s"), "invalid watch interval 0s, expected a positive duration"))
+}
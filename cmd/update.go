@@ -1,22 +1,40 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/update"
 	"github.com/CircleCI-Public/circleci-cli/version"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/briandowns/spinner"
 )
 
 type updateCommandOptions struct {
-	cfg    *settings.Config
-	dryRun bool
-	args   []string
+	cfg                *settings.Config
+	dryRun             bool
+	resultTemplateFile string
+	resultFile         string
+	cacheDir           string
+	onlyIfOutdated     bool
+	checkUpstream      bool
+	format             string
+	channel            string
+	prerelease         bool
+	notes              bool
+	lockWait           time.Duration
+	skipVerify         bool
+	targetVersion      string
+	diagnosticReport   bool
+	args               []string
 }
 
 func newUpdateCommand(config *settings.Config) *cobra.Command {
@@ -39,7 +57,7 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 		},
 	}
 
-	update.AddCommand(&cobra.Command{
+	check := &cobra.Command{
 		Use:    "check",
 		Hidden: true,
 		Short:  "Check if there are any updates available",
@@ -51,11 +69,20 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 			opts.dryRun = true
 		},
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.format == "json" {
+				return checkUpdateJSON(opts)
+			}
+			if opts.format != "text" {
+				return fmt.Errorf("unsupported format %q, expected one of text, json", opts.format)
+			}
 			return updateCLI(opts)
 		},
-	})
+	}
+	check.Flags().StringVar(&opts.format, "format", "text", "Output format, one of text or json")
+	check.Flags().BoolVar(&opts.notes, "notes", false, "Print the full release notes of the new release")
+	update.AddCommand(check)
 
-	update.AddCommand(&cobra.Command{
+	install := &cobra.Command{
 		Use:    "install",
 		Hidden: true,
 		Short:  "Update the tool to the latest version",
@@ -68,8 +95,132 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return updateCLI(opts)
 		},
+	}
+	install.Flags().StringVar(&opts.resultTemplateFile, "result-template-file", "", "Render the install result using the Go template in this file")
+	install.Flags().StringVar(&opts.resultFile, "result-file", "", "Write the rendered install result to this file instead of stdout")
+	install.Flags().BoolVar(&opts.onlyIfOutdated, "only-if-outdated", false, "Do nothing, and print nothing, unless a newer version is available")
+	install.Flags().StringVar(&opts.targetVersion, "version", "", "Install this exact version, such as 0.1.29936, instead of the latest, even if it is older")
+	update.AddCommand(install)
+
+	fetch := &cobra.Command{
+		Use:   "fetch",
+		Short: "Download the latest version into a cache without installing it",
+		Long: `Download and verify the latest version into a cache directory without
+replacing the running binary. Use "circleci update activate" to install it.`,
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			opts.cfg.SkipUpdateCheck = true
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			opts.args = args
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fetchUpdate(opts)
+		},
+	}
+	update.AddCommand(fetch)
+
+	activate := &cobra.Command{
+		Use:   "activate",
+		Short: "Install a version previously downloaded with \"circleci update fetch\"",
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			opts.cfg.SkipUpdateCheck = true
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			opts.args = args
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return activateUpdate(opts)
+		},
+	}
+	update.AddCommand(activate)
+
+	update.AddCommand(&cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the version that was installed before the last update",
+		Long: `Restore the version that was installed before the last update.
+
+Every "circleci update install" and "circleci update activate" saves the
+binary it replaces, and rollback puts it back. Only the most recent version is kept.`,
+		Args: cobra.NoArgs,
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			opts.cfg.SkipUpdateCheck = true
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return rollbackUpdate()
+		},
 	})
 
+	for _, c := range []*cobra.Command{update, check, install, fetch} {
+		c.Flags().StringVar(&opts.channel, "channel", "", "Release channel to check, one of stable, beta or nightly, instead of the one saved with \"circleci update channel\"")
+	}
+
+	for _, c := range []*cobra.Command{update, check, install, fetch} {
+		c.Flags().BoolVar(&opts.prerelease, "prerelease", false, "Also follow prereleases, such as release candidates, as if update_prerelease were set")
+	}
+
+	for _, c := range []*cobra.Command{update, install, activate} {
+		c.Flags().DurationVar(&opts.lockWait, "lock-wait", 5*time.Second, "How long to wait for another update of this binary to finish, such as one run by a concurrent CI job; 0 fails at once")
+	}
+
+	for _, c := range []*cobra.Command{update, install, fetch} {
+		c.Flags().BoolVar(&opts.skipVerify, "skip-verify", false, "Install the release even if its signature or checksums file is missing, or its signature doesn't match")
+	}
+
+	for _, c := range []*cobra.Command{fetch, activate} {
+		c.Flags().StringVar(&opts.cacheDir, "cache-dir", filepath.Join(settings.SettingsPath(), "update-cache"), "Directory fetched versions are stored in")
+	}
+
+	var statusOutput string
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Show when the CLI last checked for updates and when it will check again",
+		Example: `  circleci update status
+  circleci update status --output json`,
+		Args: cobra.NoArgs,
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			opts.cfg.SkipUpdateCheck = true
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return updateStatus(opts.cfg, statusOutput)
+		},
+	}
+	status.Flags().StringVar(&statusOutput, "output", "table", "Output format, one of table or json")
+	update.AddCommand(status)
+
+	var showChannel bool
+	channel := &cobra.Command{
+		Use:   "channel [stable|beta|nightly]",
+		Short: "Show or change the release channel updates are installed from",
+		Long: `Show or change the release channel updates are installed from.
+
+The stable channel only follows published releases, beta also follows prereleases
+and nightly also follows nightly builds. The chosen channel is saved in your
+settings and used by every later update check, including the automatic one.
+
+Setting update_prerelease to true, or passing --prerelease, makes the stable
+channel follow prereleases too.`,
+		Example: `  circleci update channel beta
+  circleci update channel --show`,
+		Args: cobra.MaximumNArgs(1),
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			opts.cfg.SkipUpdateCheck = true
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if err := setUpdateChannel(opts.cfg, args[0]); err != nil {
+					return err
+				}
+				fmt.Printf("Update channel set to %s\n", args[0])
+			}
+			if showChannel || len(args) == 0 {
+				fmt.Println(updateChannel(opts.cfg))
+			}
+			return nil
+		},
+	}
+	channel.Flags().BoolVar(&showChannel, "show", false, "Print the release channel being followed")
+	update.AddCommand(channel)
+
 	update.AddCommand(&cobra.Command{
 		Use:    "build-agent",
 		Hidden: true,
@@ -86,53 +237,327 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 	})
 
 	update.PersistentFlags().BoolVar(&opts.dryRun, "check", false, "Check if there are any updates available without installing")
+	update.PersistentFlags().BoolVar(&opts.checkUpstream, "check-upstream", false, "Also check GitHub for releases your package manager doesn't offer yet")
+	update.PersistentFlags().BoolVar(&opts.diagnosticReport, "diagnostic-report", false, "If the update check fails, print a report to attach to a bug report, without any secrets")
 
 	return update
 }
 
-func updateCLI(opts updateCommandOptions) error {
+// findUpdate checks for a newer release, returning nil if there isn't one.
+func findUpdate(opts updateCommandOptions, spr *spinner.Spinner) (*update.Options, error) {
+	check, err := checkForUpdate(opts, spr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !check.Found || update.IsLatestVersion(check) {
+		if opts.onlyIfOutdated {
+			return nil, nil
+		}
+		if !check.Found {
+			fmt.Println("No updates found.")
+		} else {
+			fmt.Println("Already up-to-date.")
+		}
+		if upstream := update.UpstreamNotice(check); upstream != "" {
+			fmt.Println(upstream)
+		}
+		return nil, nil
+	}
+
+	if opts.cfg.Debug {
+		fmt.Println(update.DebugVersion(check))
+	}
+
+	return check, nil
+}
+
+// checkForUpdate looks up the latest release on the chosen channel.
+func checkForUpdate(opts updateCommandOptions, spr *spinner.Spinner) (*update.Options, error) {
 	slug := "CircleCI-Public/circleci-cli"
 
-	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	loc, err := opts.cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := opts.cfg.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
 	spr.Suffix = " Checking for updates..."
 	spr.Start()
 
-	check, err := update.CheckForUpdates(opts.cfg.GitHubAPI, slug, version.Version, version.PackageManager())
+	channel := opts.cfg.UpdateChannel
+	if opts.channel != "" {
+		channel = opts.channel
+	}
+
+	options := []update.CheckOption{update.WithChannel(channel), update.WithLocation(loc), update.WithProxy(proxy),
+		update.WithReleaseMirror(opts.cfg.ReleaseURL), update.WithCredentialHelpers()}
+	if opts.checkUpstream {
+		options = append(options, update.WithUpstreamCheck())
+	}
+	if opts.skipVerify {
+		options = append(options, update.WithoutSignatureVerification())
+	}
+	if opts.prerelease || opts.cfg.UpdatePrerelease {
+		options = append(options, update.WithPrereleases())
+	}
+	if opts.targetVersion != "" {
+		options = append(options, update.WithTargetVersion(opts.targetVersion))
+	}
+
+	check, err := update.CheckForUpdates(opts.cfg.GitHubAPI, slug, version.Version, version.InstalledPackageManager(), options...)
 	spr.Stop()
 
+	if err != nil && opts.diagnosticReport {
+		fmt.Fprintf(os.Stderr, "%s\n\n", update.BuildUpdateDiagnosticReport(check, err))
+	}
+	return check, err
+}
+
+// checkUpdateJSON prints the result of an update check as JSON, for scripts.
+func checkUpdateJSON(opts updateCommandOptions) error {
+	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	spr.Writer = ioutil.Discard
+
+	check, err := checkForUpdate(opts, spr)
 	if err != nil {
 		return err
 	}
 
-	if !check.Found {
-		fmt.Println("No updates found.")
-		return nil
+	loc, err := opts.cfg.Location()
+	if err != nil {
+		return err
 	}
 
-	if update.IsLatestVersion(check) {
-		fmt.Println("Already up-to-date.")
-		return nil
+	result := struct {
+		Current        string  `json:"current"`
+		Latest         *string `json:"latest"`
+		Found          bool    `json:"found"`
+		PackageManager string  `json:"package_manager"`
+		PublishedAt    *string `json:"published_at"`
+		ReleaseNotes   *string `json:"release_notes,omitempty"`
+	}{
+		Current:        check.Current.String(),
+		Found:          check.Found && !update.IsLatestVersion(check),
+		PackageManager: check.PackageManager,
+	}
+	if check.Latest != nil {
+		latest := check.Latest.Version.String()
+		result.Latest = &latest
+		if check.Latest.PublishedAt != nil {
+			published := check.Latest.PublishedAt.In(loc).Format(time.RFC3339)
+			result.PublishedAt = &published
+		}
+		if opts.notes {
+			notes := update.ReleaseNotes(check)
+			result.ReleaseNotes = &notes
+		}
 	}
 
-	if opts.cfg.Debug {
-		fmt.Println(update.DebugVersion(check))
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// updateStatus prints when the last automatic update check was made and when
+// the next one is due.
+func updateStatus(cfg *settings.Config, output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected one of table, json", output)
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+
+	updateCheck := &settings.UpdateCheck{}
+	if err = updateCheck.Load(); err != nil {
+		return err
+	}
+	status := update.UpdateCheckStatus(updateCheck, update.CheckInterval(updateCheck))
+
+	result := struct {
+		LastChecked *string `json:"last_checked"`
+		NextCheck   string  `json:"next_check"`
+		Interval    string  `json:"interval"`
+	}{
+		NextCheck: status.NextCheck.In(loc).Format(time.RFC3339),
+		Interval:  status.Interval.String(),
+	}
+	if status.LastChecked != nil {
+		last := status.LastChecked.In(loc).Format(time.RFC3339)
+		result.LastChecked = &last
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	lastChecked := "never"
+	if result.LastChecked != nil {
+		lastChecked = *result.LastChecked
+	}
+	fmt.Printf("Last checked: %s\n", lastChecked)
+	fmt.Printf("Next check: %s\n", result.NextCheck)
+	fmt.Printf("Interval: %s\n", result.Interval)
+	return nil
+}
+
+// updateChannel returns the release channel being followed.
+func updateChannel(cfg *settings.Config) string {
+	if cfg.UpdateChannel == "" {
+		return update.ChannelStable
+	}
+	return cfg.UpdateChannel
+}
+
+// setUpdateChannel saves the release channel to the settings file.
+func setUpdateChannel(cfg *settings.Config, channel string) error {
+	if err := update.ValidateChannel(channel); err != nil {
+		return err
+	}
+
+	if err := settings.SaveSetting("update_channel", channel); err != nil {
+		return errors.Wrap(err, "failed to save update channel")
+	}
+
+	cfg.UpdateChannel = channel
+	return nil
+}
+
+func updateCLI(opts updateCommandOptions) error {
+	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	if opts.onlyIfOutdated {
+		spr.Writer = ioutil.Discard
+	}
+
+	check, err := findUpdate(opts, spr)
+	if err != nil || check == nil {
+		return err
 	}
-	fmt.Println(update.ReportVersion(check))
 
 	if opts.dryRun {
+		// An explicit check always reports the update, however small.
+		cfg := updateNoticeConfig(opts.cfg)
+		cfg.NagLevel = ""
+		fmt.Println(update.UpdateNotice(check, cfg))
+		if notes := update.ReleaseNotes(check); opts.notes && notes != "" {
+			fmt.Printf("\nRelease notes for %s:\n\n%s\n", check.Latest.Version, notes)
+		}
+		return nil
+	}
+
+	if !isUpdateIncluded(check.PackageManager) {
+		// Distro packages are only recognised once an update is looked for,
+		// as doing so runs their package manager.
+		fmt.Printf("`update` is not available because this tool was installed using `%s`.\n", check.PackageManager)
 		fmt.Println(update.HowToUpdate(check))
 		return nil
 	}
 
+	fmt.Println(update.ReportVersion(check))
+
 	spr.Suffix = " Installing update..."
 	spr.Restart()
-	message, err := update.InstallLatest(check)
+	check.OnlyIfOutdated = opts.onlyIfOutdated
+	check.LockWait = opts.lockWait
+	if opts.lockWait <= 0 {
+		// Zero means the library default, but --lock-wait=0 asks not to wait.
+		check.LockWait = -1
+	}
+	result, err := update.InstallLatestWithResult(check)
 	spr.Stop()
+	if err != nil || result.Skipped {
+		return err
+	}
+
+	fmt.Printf("Updated to %s\n", result.Version)
+
+	if opts.resultTemplateFile != "" {
+		return writeInstallResult(opts, result)
+	}
+
+	return nil
+}
+
+func fetchUpdate(opts updateCommandOptions) error {
+	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+
+	check, err := findUpdate(opts, spr)
+	if err != nil || check == nil {
+		return err
+	}
+
+	spr.Suffix = " Fetching update..."
+	spr.Restart()
+	fetched, err := update.FetchLatest(check, opts.cacheDir)
+	spr.Stop()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fetched %s to %s\n", fetched.Version, fetched.Path)
+	fmt.Println("You can install it with `circleci update activate`")
+	return nil
+}
+
+func activateUpdate(opts updateCommandOptions) error {
+	path, err := update.ExecutablePath()
+	if err != nil {
+		return errors.Wrap(err, "failed to activate update")
+	}
+
+	current, err := update.SemverScheme{}.Parse(version.Version)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse current version")
+	}
+
+	activate := &update.Options{Current: current, LockWait: opts.lockWait}
+	if opts.lockWait <= 0 {
+		// Zero means the library default, but --lock-wait=0 asks not to wait.
+		activate.LockWait = -1
+	}
+	result, err := update.ActivateFetched(activate, opts.cacheDir, path)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(message)
+	fmt.Printf("Updated to %s\n", result.Version)
+	return nil
+}
 
+func rollbackUpdate() error {
+	result, err := update.RollbackPrevious()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rolled back to %s\n", result.Version)
 	return nil
 }
+
+func writeInstallResult(opts updateCommandOptions, result *update.InstallResult) error {
+	tmpl, err := ioutil.ReadFile(opts.resultTemplateFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read result template")
+	}
+
+	if opts.resultFile == "" {
+		return result.Render(os.Stdout, string(tmpl))
+	}
+
+	f, err := os.Create(opts.resultFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to create result file")
+	}
+	defer f.Close()
+
+	return result.Render(f, string(tmpl))
+}
@@ -31,6 +31,8 @@ var _ = Describe("Update", func() {
     "tag_name": "v1.0.0",
     "name": "v1.0.0",
     "published_at": "2013-02-27T19:35:32Z",
+    "html_url": "https://github.com/CircleCI-Public/circleci-cli/releases/tag/v1.0.0",
+    "body": "## Changes\r\n\r\n* Show release notes with update check --notes",
     "assets": [
       {
         "id": 1,
@@ -115,6 +117,65 @@ var _ = Describe("Update", func() {
 			Eventually(session.Err.Contents()).Should(BeEmpty())
 			Eventually(session).Should(gexec.Exit(0))
 		})
+
+		It("with --format json should print the result for scripts", func() {
+			command.Args = append(command.Args, "--format", "json", "--timezone", "utc")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`{
+				"current": "0.0.0-dev",
+				"latest": "1.0.0",
+				"found": true,
+				"package_manager": "source",
+				"published_at": "2013-02-27T19:35:32Z"
+			}`))
+		})
+
+		It("should summarise the release notes", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Out).Should(gbytes.Say("A new release is available"))
+			Eventually(session.Out).Should(gbytes.Say("What's new:\n  ## Changes\n  \\* Show release notes with update check --notes\n"))
+			Eventually(session.Out).Should(gbytes.Say("Full release notes: https://github.com/CircleCI-Public/circleci-cli/releases/tag/v1.0.0"))
+			Eventually(session).Should(gexec.Exit(0))
+		})
+
+		It("with --notes should print the full release notes", func() {
+			command.Args = append(command.Args, "--notes")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(HaveSuffix("\nRelease notes for 1.0.0:\n\n## Changes\n\n* Show release notes with update check --notes\n"))
+		})
+
+		It("with --notes and --format json should include the release notes", func() {
+			command.Args = append(command.Args, "--notes", "--format", "json", "--timezone", "utc")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`{
+				"current": "0.0.0-dev",
+				"latest": "1.0.0",
+				"found": true,
+				"package_manager": "source",
+				"published_at": "2013-02-27T19:35:32Z",
+				"release_notes": "## Changes\n\n* Show release notes with update check --notes"
+			}`))
+		})
+
+		It("should reject an unknown format", func() {
+			command.Args = append(command.Args, "--format", "xml")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(string(session.Err.Contents())).To(ContainSubstring(`unsupported format "xml", expected one of text, json`))
+		})
 	})
 
 	Describe("update", func() {
@@ -125,16 +186,15 @@ var _ = Describe("Update", func() {
 			command = exec.Command(updateCLI,
 				"update",
 				"--github-api", tempSettings.TestServer.URL(),
+				"--skip-verify",
 			)
 
 			assetBytes := golden.Get(GinkgoT(), filepath.FromSlash("update/foo.zip"))
 			assetResponse := string(assetBytes)
 
+			// The releases listed by the check are installed from, without
+			// listing them again.
 			tempSettings.TestServer.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
-					ghttp.RespondWith(http.StatusOK, response),
-				),
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
 					ghttp.RespondWith(http.StatusOK, assetResponse),
@@ -154,6 +214,115 @@ var _ = Describe("Update", func() {
 			Eventually(session.Err.Contents()).Should(BeEmpty())
 			Eventually(session).Should(gexec.Exit(0))
 		})
+
+		It("should refuse to install a release it can't verify", func() {
+			command.Args = command.Args[:len(command.Args)-1]
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(string(session.Err.Contents())).To(ContainSubstring("release 1.0.0 has no checksums file, refusing to install it unverified"))
+		})
+	})
+
+	Describe("update channel", func() {
+		run := func(args ...string) *gexec.Session {
+			command := commandWithHome(pathCLI, tempSettings.Home, append([]string{"update"}, args...)...)
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			return session
+		}
+
+		BeforeEach(func() {
+			tempSettings.Config.Write([]byte("host: https://example.com\n"))
+
+			tempSettings.TestServer.Reset()
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+					ghttp.RespondWith(http.StatusOK, `[
+  {"tag_name": "v1.0.0", "assets": [{"id": 1, "name": "circleci-cli_linux_amd64.tar.gz"}, {"id": 2, "name": "circleci-cli_darwin_amd64.tar.gz"}, {"id": 3, "name": "circleci-cli_windows_amd64.zip"}]},
+  {"tag_name": "v1.1.0-beta.1", "prerelease": true, "assets": [{"id": 4, "name": "circleci-cli_linux_amd64.tar.gz"}, {"id": 5, "name": "circleci-cli_darwin_amd64.tar.gz"}, {"id": 6, "name": "circleci-cli_windows_amd64.zip"}]}
+]`),
+				),
+			)
+		})
+
+		It("should show the stable channel by default", func() {
+			session := run("channel", "--show")
+			Expect(string(session.Out.Contents())).To(Equal("stable\n"))
+		})
+
+		It("should persist the chosen channel", func() {
+			session := run("channel", "beta")
+			Expect(string(session.Out.Contents())).To(Equal("Update channel set to beta\n"))
+			tempSettings.AssertConfigRereadMatches("host: https://example.com\nupdate_channel: beta\n")
+
+			session = run("channel", "--show")
+			Expect(string(session.Out.Contents())).To(Equal("beta\n"))
+		})
+
+		It("should check for updates on the persisted channel", func() {
+			run("channel", "beta")
+
+			session := run("check", "--github-api", tempSettings.TestServer.URL())
+			Expect(session.Out).To(gbytes.Say(`A new release is available \(1.1.0-beta.1\)`))
+		})
+
+		It("should check another channel for one run without saving it", func() {
+			session := run("check", "--channel", "beta", "--github-api", tempSettings.TestServer.URL())
+			Expect(session.Out).To(gbytes.Say(`A new release is available \(1.1.0-beta.1\)`))
+			tempSettings.AssertConfigRereadMatches("host: https://example.com\n")
+		})
+
+		It("should reject unknown channels", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home, "update", "channel", "edge")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(string(session.Err.Contents())).To(ContainSubstring(`unknown update channel "edge", expected one of stable, beta, nightly`))
+		})
+	})
+
+	Describe("update status", func() {
+		run := func(args ...string) *gexec.Session {
+			command := commandWithHome(pathCLI, tempSettings.Home, append([]string{"update", "status", "--timezone", "utc"}, args...)...)
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			return session
+		}
+
+		It("should show when the next check is due", func() {
+			tempSettings.Update.Write([]byte("last_checked_at: 2999-01-01T00:00:00Z\n"))
+
+			session := run()
+			Expect(string(session.Out.Contents())).To(Equal(`Last checked: 2999-01-01T00:00:00Z
+Next check: 2999-01-02T04:00:00Z
+Interval: 28h0m0s
+`))
+
+			session = run("--output", "json")
+			Expect(session.Out.Contents()).To(MatchJSON(`{
+				"last_checked": "2999-01-01T00:00:00Z",
+				"next_check": "2999-01-02T04:00:00Z",
+				"interval": "28h0m0s"
+			}`))
+		})
+
+		It("should show that it has never checked", func() {
+			session := run()
+			Expect(session.Out).To(gbytes.Say("Last checked: never\n"))
+		})
+
+		It("should use the configured interval", func() {
+			tempSettings.Update.Write([]byte("last_checked_at: 2999-01-01T00:00:00Z\nupdate_check_interval: 168h\n"))
+
+			session := run()
+			Expect(session.Out).To(gbytes.Say("Next check: 2999-01-08T00:00:00Z\nInterval: 168h0m0s\n"))
+		})
 	})
 
 	Describe("When Github returns a 403 error", func() {
@@ -164,6 +333,8 @@ var _ = Describe("Update", func() {
 			)
 
 			tempSettings.TestServer.Reset()
+			// The failed listing makes the check ask whether the GitHub API can be reached.
+			tempSettings.TestServer.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, `{}`))
 			tempSettings.TestServer.AppendHandlers(
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
@@ -194,11 +365,26 @@ To have the circleci-cli make authenticated requests please:
   2. Set the token by either adding it to your ~/.gitconfig or
      setting the GITHUB_TOKEN environment variable.
 
+Tokens saved by logging in with ` + "`gh auth login`" + ` or by git's credential
+helper are used as well.
+
 Instructions for generating a token can be found at:
 https://help.github.com/articles/creating-a-personal-access-token-for-the-command-line/
 
 We call the GitHub releases API to look for new releases.
 More information about that API can be found here: https://developer.github.com/v3/repos/releases/`))
 		})
+
+		It("should print a diagnostic report with --diagnostic-report", func() {
+			command.Args = append(command.Args, "--diagnostic-report")
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+
+			stderr := string(session.Wait().Err.Contents())
+			Expect(stderr).To(ContainSubstring("CircleCI CLI update diagnostic report\n"))
+			Expect(stderr).To(ContainSubstring("GitHub API: " + tempSettings.TestServer.URL()))
+			Expect(stderr).To(ContainSubstring("Error: Failed to query the GitHub API for updates."))
+		})
 	})
 })
@@ -7,33 +7,71 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/CircleCI-Public/circleci-cli/api/redact"
 	"github.com/CircleCI-Public/circleci-cli/data"
 	yaml "gopkg.in/yaml.v3"
 )
 
 // Config is used to represent the current state of a CLI instance.
 type Config struct {
-	Host            string            `yaml:"host"`
-	Endpoint        string            `yaml:"endpoint"`
-	Token           string            `yaml:"token"`
-	RestEndpoint    string            `yaml:"rest_endpoint"`
-	TLSCert         string            `yaml:"tls_cert"`
-	TLSInsecure     bool              `yaml:"tls_insecure"`
-	HTTPClient      *http.Client      `yaml:"-"`
-	Data            *data.YML         `yaml:"-"`
-	Debug           bool              `yaml:"-"`
-	Address         string            `yaml:"-"`
-	FileUsed        string            `yaml:"-"`
-	GitHubAPI       string            `yaml:"-"`
-	SkipUpdateCheck bool              `yaml:"-"`
-	OrbPublishing   OrbPublishingInfo `yaml:"orb_publishing"`
+	Host                       string            `yaml:"host"`
+	Endpoint                   string            `yaml:"endpoint"`
+	Token                      string            `yaml:"token"`
+	RestEndpoint               string            `yaml:"rest_endpoint"`
+	TLSCert                    string            `yaml:"tls_cert"`
+	TLSInsecure                bool              `yaml:"tls_insecure"`
+	TLSClientCert              string            `yaml:"tls_client_cert,omitempty"`
+	TLSClientKey               string            `yaml:"tls_client_key,omitempty"`
+	Proxy                      string            `yaml:"proxy,omitempty"`
+	ReleaseURL                 string            `yaml:"release_url,omitempty"`
+	ExtraHeaders               map[string]string `yaml:"extra_headers,omitempty"`
+	AllowReservedHeaders       bool              `yaml:"allow_reserved_headers,omitempty"`
+	SuppressUpdateInstructions bool              `yaml:"suppress_update_instructions,omitempty"`
+	CustomUpdateMessage        string            `yaml:"custom_update_message,omitempty"`
+	UpdateNagLevel             string            `yaml:"update_nag_level,omitempty"`
+	UpdateChannel              string            `yaml:"update_channel,omitempty"`
+	UpdatePrerelease           bool              `yaml:"update_prerelease,omitempty"`
+	Timezone                   string            `yaml:"timezone,omitempty"`
+	DefaultOutputFormat        string            `yaml:"default_output_format,omitempty"`
+	RedactHeaders              []string          `yaml:"redact_headers,omitempty"`
+	RedactPatterns             []string          `yaml:"redact_patterns,omitempty"`
+	Retries                    *int              `yaml:"retries,omitempty"`
+	Timeout                    string            `yaml:"timeout,omitempty"`
+	HTTPClient                 *http.Client      `yaml:"-"`
+	Data                       *data.YML         `yaml:"-"`
+	Debug                      bool              `yaml:"-"`
+	Address                    string            `yaml:"-"`
+	FileUsed                   string            `yaml:"-"`
+	GitHubAPI                  string            `yaml:"-"`
+	SkipUpdateCheck            bool              `yaml:"-"`
+	TraceFile                  string            `yaml:"-"`
+	OrbPublishing              OrbPublishingInfo `yaml:"orb_publishing"`
+	Sources                    map[string]string `yaml:"-"`
+}
+
+// Where a setting's value came from, as recorded in Config.Sources.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting is a single resolved setting along with where its value came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
 }
 
 type OrbPublishingInfo struct {
@@ -44,8 +82,31 @@ type OrbPublishingInfo struct {
 
 // UpdateCheck is used to represent settings for checking for updates of the CLI.
 type UpdateCheck struct {
+	LastUpdateCheck time.Time `yaml:"last_checked_at"`
+	// Notice is the update notice found by the last background check, to be
+	// printed by the next invocation of the CLI.
+	Notice string `yaml:"notice,omitempty"`
+	// NoticeVersion is the version of the CLI the notice was found for, so it
+	// isn't printed once that version has been replaced.
+	NoticeVersion string `yaml:"notice_version,omitempty"`
+	// CheckInterval is how long to wait between automatic update checks, as
+	// a duration such as 168h, instead of the default.
+	CheckInterval string `yaml:"update_check_interval,omitempty"`
+	// Interval is CheckInterval, or CIRCLECI_CLI_UPDATE_CHECK_INTERVAL if set,
+	// as resolved by Load. Zero means the default interval.
+	Interval time.Duration `yaml:"-"`
+	FileUsed string        `yaml:"-"`
+}
+
+// updateCheckFile is the update check settings as found on disk, including
+// keys written by older versions of the CLI.
+type updateCheckFile struct {
+	LastCheckedAt time.Time `yaml:"last_checked_at"`
+	// LastUpdateCheck is the legacy name for LastCheckedAt.
 	LastUpdateCheck time.Time `yaml:"last_update_check"`
-	FileUsed        string    `yaml:"-"`
+	Notice          string    `yaml:"notice"`
+	NoticeVersion   string    `yaml:"notice_version"`
+	CheckInterval   string    `yaml:"update_check_interval"`
 }
 
 // Load will read the update check settings from the user's disk and then deserialize it into the current instance.
@@ -63,8 +124,42 @@ func (upd *UpdateCheck) Load() error {
 		return err
 	}
 
-	err = yaml.Unmarshal(content, &upd)
-	return err
+	var file updateCheckFile
+	if err = yaml.Unmarshal(content, &file); err != nil {
+		return err
+	}
+
+	// Files written before the key was renamed only have the legacy key. It is
+	// replaced by the new one the next time the file is written.
+	upd.LastUpdateCheck = file.LastCheckedAt
+	if upd.LastUpdateCheck.IsZero() {
+		upd.LastUpdateCheck = file.LastUpdateCheck
+	}
+	upd.Notice = file.Notice
+	upd.NoticeVersion = file.NoticeVersion
+	upd.CheckInterval = file.CheckInterval
+
+	return upd.resolveInterval()
+}
+
+// resolveInterval sets Interval from the environment, or from the file.
+func (upd *UpdateCheck) resolveInterval() error {
+	name, value := "update_check_interval in "+upd.FileUsed, upd.CheckInterval
+	if env := ReadFromEnv("circleci_cli", "update_check_interval"); env != "" {
+		name, value = "CIRCLECI_CLI_UPDATE_CHECK_INTERVAL", env
+	}
+
+	upd.Interval = 0
+	if value == "" {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid %s: %q, expected a positive duration such as 168h", name, value)
+	}
+	upd.Interval = interval
+	return nil
 }
 
 // WriteToDisk will write the last update check to disk by serializing the YAML
@@ -109,6 +204,13 @@ func (cfg *Config) LoadFromDisk() error {
 		return nil
 	}
 
+	present := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &present); err == nil {
+		for key := range present {
+			cfg.SetSource(key, SourceFile)
+		}
+	}
+
 	return cfg.WithHTTPClient()
 }
 
@@ -123,23 +225,162 @@ func (cfg *Config) WriteToDisk() error {
 	return err
 }
 
+// SaveSetting sets a single top-level key in the settings file. The rest of the
+// file is left as it is, so settings given by flags or the environment are not
+// saved along with it.
+func SaveSetting(key, value string) error {
+	path := filepath.Join(SettingsPath(), configFilename())
+	if err := ensureSettingsFileExists(path); err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update %s: expected a YAML mapping", path)
+	}
+
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1].SetString(value)
+			found = true
+		}
+	}
+	if !found {
+		k, v := &yaml.Node{}, &yaml.Node{}
+		k.SetString(key)
+		v.SetString(value)
+		root.Content = append(root.Content, k, v)
+	}
+
+	enc, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, enc, 0600)
+}
+
 // LoadFromEnv will read from environment variables of the given prefix for host, endpoint, and token specifically.
 func (cfg *Config) LoadFromEnv(prefix string) {
 	if host := ReadFromEnv(prefix, "host"); host != "" {
 		cfg.Host = host
+		cfg.SetSource("host", SourceEnv)
 	}
 
 	if restEndpoint := ReadFromEnv(prefix, "rest_endpoint"); restEndpoint != "" {
 		cfg.RestEndpoint = restEndpoint
+		cfg.SetSource("rest_endpoint", SourceEnv)
 	}
 
 	if endpoint := ReadFromEnv(prefix, "endpoint"); endpoint != "" {
 		cfg.Endpoint = endpoint
+		cfg.SetSource("endpoint", SourceEnv)
 	}
 
 	if token := ReadFromEnv(prefix, "token"); token != "" {
 		cfg.Token = token
+		cfg.SetSource("token", SourceEnv)
+	}
+}
+
+// SetSource records where the setting with the given YAML key came from.
+func (cfg *Config) SetSource(key, source string) {
+	if cfg.Sources == nil {
+		cfg.Sources = map[string]string{}
+	}
+	cfg.Sources[key] = source
+}
+
+// Redactor returns the redactor for the configured redact_headers and redact_patterns.
+func (cfg *Config) Redactor() (*redact.Redactor, error) {
+	return redact.New(cfg.RedactHeaders, cfg.RedactPatterns)
+}
+
+// Settings returns the resolved settings in a stable order for display, with secrets redacted.
+func (cfg *Config) Settings() ([]Setting, error) {
+	redactor, err := cfg.Redactor()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []string{}
+	for name, value := range cfg.ExtraHeaders {
+		headers = append(headers, fmt.Sprintf("%s=%s", name, redactor.Value(name, value)))
+	}
+	sort.Strings(headers)
+
+	values := []struct {
+		key   string
+		value string
+	}{
+		{"host", cfg.Host},
+		{"endpoint", cfg.Endpoint},
+		{"rest_endpoint", cfg.RestEndpoint},
+		{"token", redactToken(cfg.Token)},
+		{"tls_cert", cfg.TLSCert},
+		{"tls_insecure", strconv.FormatBool(cfg.TLSInsecure)},
+		{"tls_client_cert", cfg.TLSClientCert},
+		{"tls_client_key", cfg.TLSClientKey},
+		{"proxy", redactProxy(cfg.Proxy)},
+		{"extra_headers", strings.Join(headers, ",")},
+		{"allow_reserved_headers", strconv.FormatBool(cfg.AllowReservedHeaders)},
+		{"redact_headers", strings.Join(cfg.RedactHeaders, ",")},
+		{"redact_patterns", strings.Join(cfg.RedactPatterns, ",")},
+		{"suppress_update_instructions", strconv.FormatBool(cfg.SuppressUpdateInstructions)},
+		{"custom_update_message", cfg.CustomUpdateMessage},
+		{"update_nag_level", cfg.UpdateNagLevel},
+		{"update_channel", cfg.UpdateChannel},
+		{"update_prerelease", strconv.FormatBool(cfg.UpdatePrerelease)},
+		{"release_url", cfg.ReleaseURL},
+		{"timezone", cfg.Timezone},
+		{"default_output_format", cfg.DefaultOutputFormat},
+		{"retries", formatOptionalInt(cfg.Retries)},
+		{"timeout", cfg.Timeout},
+		{"github_api", cfg.GitHubAPI},
+		{"debug", strconv.FormatBool(cfg.Debug)},
+		{"skip_update_check", strconv.FormatBool(cfg.SkipUpdateCheck)},
+	}
+
+	settings := make([]Setting, len(values))
+	for i, v := range values {
+		source, ok := cfg.Sources[v.key]
+		if !ok {
+			source = SourceDefault
+		}
+		settings[i] = Setting{Key: v.key, Value: v.value, Source: source}
+	}
+	return settings, nil
+}
+
+func formatOptionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+// redactToken hides all but the last few characters of a token, which is
+// enough to tell tokens apart without revealing them.
+func redactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 8 {
+		return "********"
 	}
+	return "********" + token[len(token)-4:]
 }
 
 // ReadFromEnv takes a prefix and field to search the environment for after capitalizing and joining them with an underscore.
@@ -198,7 +439,151 @@ func ensureSettingsFileExists(path string) error {
 	return err
 }
 
+// Location returns where absolute timestamps should be rendered, based on the
+// timezone setting: utc, or local which is the default.
+func (cfg *Config) Location() (*time.Location, error) {
+	switch cfg.Timezone {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return nil, fmt.Errorf("unknown timezone %q, expected one of utc, local", cfg.Timezone)
+}
+
+// HTTPTimeout returns the time limit for each API request from the timeout
+// setting, or zero to leave it to each client's default.
+func (cfg *Config) HTTPTimeout() (time.Duration, error) {
+	if cfg.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(cfg.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q, expected a positive duration such as 30s", cfg.Timeout)
+	}
+	return d, nil
+}
+
+// GraphQLHTTPClient returns the HTTP client for the GraphQL API. Its requests,
+// such as publishing an orb, have no time limit unless the timeout setting
+// gives one, so unlike HTTPClient it has no 30s default.
+func (cfg *Config) GraphQLHTTPClient() *http.Client {
+	if cfg.HTTPClient == nil {
+		return nil
+	}
+	client := *cfg.HTTPClient
+	client.Timeout, _ = cfg.HTTPTimeout()
+	return &client
+}
+
+// ProxyFunc returns how requests find their proxy: the proxy setting if there
+// is one, otherwise the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func (cfg *Config) ProxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if cfg.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxy, err := url.Parse(cfg.Proxy)
+	if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q, expected a URL such as http://proxy.example.com:3128", redactProxy(cfg.Proxy))
+	}
+	return http.ProxyURL(proxy), nil
+}
+
+// redactProxy hides any password in a proxy URL.
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+// OutputFormats are the formats that default_output_format can be set to.
+// Commands that don't support the configured format keep their own default.
+var OutputFormats = []string{"table", "json", "yaml", "csv"}
+
+// ValidateDefaultOutputFormat checks default_output_format is one of
+// OutputFormats. It is left to the commands that use it, so a bad setting
+// doesn't stop setup from running to fix it.
+func (cfg *Config) ValidateDefaultOutputFormat() error {
+	if cfg.DefaultOutputFormat == "" {
+		return nil
+	}
+	for _, f := range OutputFormats {
+		if cfg.DefaultOutputFormat == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid default_output_format %q in %s, expected one of %s",
+		cfg.DefaultOutputFormat, cfg.FileUsed, strings.Join(OutputFormats, ", "))
+}
+
+// ValidateForRunner checks that the config has everything needed to talk to the runner REST API.
+// The returned error names the first missing or invalid field.
+func (cfg *Config) ValidateForRunner() error {
+	if cfg.Host == "" {
+		return errors.New("host is not set, use --host or CIRCLECI_CLI_HOST")
+	}
+
+	host, err := url.Parse(cfg.Host)
+	if err != nil || host.Scheme == "" || host.Host == "" {
+		return fmt.Errorf("host %q is not a valid URL, expected something like https://circleci.com", cfg.Host)
+	}
+
+	if cfg.RestEndpoint == "" {
+		return errors.New("rest_endpoint is not set, use CIRCLECI_CLI_REST_ENDPOINT")
+	}
+
+	endpoint, err := url.Parse(cfg.RestEndpoint)
+	if err != nil || endpoint.IsAbs() {
+		return fmt.Errorf("rest_endpoint %q is not a valid URL path, expected something like api/v2", cfg.RestEndpoint)
+	}
+
+	if cfg.Token == "" {
+		return errors.New("token is not set, use 'circleci setup', --token or CIRCLECI_CLI_TOKEN")
+	}
+
+	return nil
+}
+
 func (cfg *Config) WithHTTPClient() error {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	proxy, err := cfg.ProxyFunc()
+	if err != nil {
+		return err
+	}
+
+	// An invalid timeout is reported by the commands that make requests, so
+	// it doesn't stop the ones that don't, such as setup, from running.
+	timeout, err := cfg.HTTPTimeout()
+	if err != nil || timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	cfg.HTTPClient = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 proxy,
+			ExpectContinueTimeout: 1 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          10,
+			TLSHandshakeTimeout:   10 * time.Second,
+			TLSClientConfig:       tlsConfig,
+		},
+	}
+
+	return nil
+}
+
+// TLSConfig returns the TLS config for API requests: the CA certificates from
+// tls_cert, and the client certificate from tls_client_cert and tls_client_key
+// for servers that require one.
+func (cfg *Config) TLSConfig() (*tls.Config, error) {
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: cfg.TLSInsecure,
 	}
@@ -206,34 +591,40 @@ func (cfg *Config) WithHTTPClient() error {
 	if cfg.TLSCert != "" {
 		err := validateTLSCertPath(cfg.TLSCert)
 		if err != nil {
-			return fmt.Errorf("invalid tls cert provided: %s", err.Error())
+			return nil, fmt.Errorf("invalid tls cert provided: %s", err.Error())
 		}
 
 		pemData, err := ioutil.ReadFile(cfg.TLSCert)
 		if err != nil {
-			return fmt.Errorf("unable to read tls cert: %s", err.Error())
+			return nil, fmt.Errorf("unable to read tls cert: %s", err.Error())
 		}
 
 		pool := x509.NewCertPool()
 		if !pool.AppendCertsFromPEM(pemData) {
-			return errors.New("unable to parse certificates")
+			return nil, errors.New("unable to parse certificates")
 		}
 
 		tlsConfig.RootCAs = pool
 	}
 
-	cfg.HTTPClient = &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			ExpectContinueTimeout: 1 * time.Second,
-			IdleConnTimeout:       90 * time.Second,
-			MaxIdleConns:          10,
-			TLSHandshakeTimeout:   10 * time.Second,
-			TLSClientConfig:       tlsConfig,
-		},
+	if cfg.TLSClientCert != "" || cfg.TLSClientKey != "" {
+		if cfg.TLSClientCert == "" || cfg.TLSClientKey == "" {
+			return nil, errors.New("tls_client_cert and tls_client_key must be set together")
+		}
+		for _, path := range []string{cfg.TLSClientCert, cfg.TLSClientKey} {
+			if err := validateTLSCertPath(path); err != nil {
+				return nil, fmt.Errorf("invalid tls client certificate provided: %s", err.Error())
+			}
+		}
+
+		cert, err := tls.LoadX509KeyPair(cfg.TLSClientCert, cfg.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls client certificate: %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	return nil
+	return tlsConfig, nil
 }
 
 func validateTLSCertPath(path string) error {
@@ -0,0 +1,124 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// backupManifest is the file in the backup directory recording the binary
+// that was replaced by the last install.
+const backupManifest = "backup.json"
+
+// Backup describes the binary saved before an install, which Rollback can
+// restore.
+type Backup struct {
+	Version  semver.Version `json:"version"`
+	Path     string         `json:"path"`
+	Checksum string         `json:"checksum"`
+}
+
+func defaultBackupDir() string {
+	return filepath.Join(settings.SettingsPath(), "update-backup")
+}
+
+// backupBinary copies the binary at cmdPath, which is the given version, into
+// dir, replacing any earlier backup.
+func backupBinary(dir, cmdPath string, version semver.Version) error {
+	if dir == "" {
+		dir = defaultBackupDir()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(cmdPath)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, filepath.Base(cmdPath))
+	if err = copyFile(cmdPath, path, info.Mode()); err != nil {
+		return err
+	}
+
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(&Backup{Version: version, Path: path, Checksum: checksum})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, backupManifest), manifest, 0600)
+}
+
+// Rollback replaces the binary at cmdPath with the one saved in backupDir by
+// the last install, the default backup directory if empty.
+func Rollback(backupDir, cmdPath string) (*InstallResult, error) {
+	start := time.Now()
+
+	if backupDir == "" {
+		backupDir = defaultBackupDir()
+	}
+
+	manifest, err := ioutil.ReadFile(filepath.Join(backupDir, backupManifest)) // #nosec
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no previous version found in %s, nothing to roll back to", backupDir)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read backup")
+	}
+
+	var backup Backup
+	if err = json.Unmarshal(manifest, &backup); err != nil {
+		return nil, errors.Wrap(err, "failed to read backup")
+	}
+
+	checksum, err := fileChecksum(backup.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify backup")
+	}
+	if checksum != backup.Checksum {
+		return nil, fmt.Errorf("backup %s has changed since it was saved, not rolling back to it", backup.Path)
+	}
+
+	release, err := lockUpdate("", 0)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err = swapBinary(backup.Path, cmdPath); err != nil {
+		return nil, errors.Wrap(err, "failed to roll back")
+	}
+
+	// The backup is now installed; rolling back again would be a no-op.
+	_ = os.Remove(filepath.Join(backupDir, backupManifest))
+	_ = os.Remove(backup.Path)
+
+	return &InstallResult{
+		Version:  backup.Version,
+		Path:     cmdPath,
+		Checksum: checksum,
+		Duration: time.Since(start),
+	}, nil
+}
+
+// RollbackPrevious restores the running binary to the version saved in the
+// default backup directory by the last install.
+func RollbackPrevious() (*InstallResult, error) {
+	path, err := ExecutablePath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to roll back")
+	}
+	return Rollback("", path)
+}
@@ -0,0 +1,101 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rollback", func() {
+	var backupDir, installDir, cmdPath string
+
+	BeforeEach(func() {
+		var err error
+		backupDir, err = ioutil.TempDir("", "circleci-cli-backup")
+		Expect(err).ToNot(HaveOccurred())
+		installDir, err = ioutil.TempDir("", "circleci-cli-install")
+		Expect(err).ToNot(HaveOccurred())
+
+		cmdPath = filepath.Join(installDir, "circleci")
+		Expect(ioutil.WriteFile(cmdPath, []byte("circleci 0.1.0"), 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(backupDir)
+		os.RemoveAll(installDir)
+	})
+
+	It("Should restore the backed up binary and report its version", func() {
+		Expect(update.BackupBinary(backupDir, cmdPath, semver.MustParse("0.1.0"))).To(Succeed())
+		Expect(ioutil.WriteFile(cmdPath, []byte("circleci 0.2.0"), 0755)).To(Succeed())
+
+		result, err := update.Rollback(backupDir, cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Version.String()).To(Equal("0.1.0"))
+		Expect(result.Path).To(Equal(cmdPath))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+
+		_, err = update.Rollback(backupDir, cmdPath)
+		Expect(err).To(MatchError("no previous version found in " + backupDir + ", nothing to roll back to"))
+	})
+
+	It("Should error when there is no backup", func() {
+		_, err := update.Rollback(backupDir, cmdPath)
+		Expect(err).To(MatchError("no previous version found in " + backupDir + ", nothing to roll back to"))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+	})
+
+	It("Should refuse a backup that changed since it was saved", func() {
+		Expect(update.BackupBinary(backupDir, cmdPath, semver.MustParse("0.1.0"))).To(Succeed())
+		backup := filepath.Join(backupDir, "circleci")
+		Expect(ioutil.WriteFile(backup, []byte("tampered"), 0755)).To(Succeed())
+
+		_, err := update.Rollback(backupDir, cmdPath)
+		Expect(err).To(MatchError("backup " + backup + " has changed since it was saved, not rolling back to it"))
+	})
+
+	Describe("RollbackPrevious", func() {
+		var home string
+		var restore func()
+
+		BeforeEach(func() {
+			home = os.Getenv("HOME")
+			Expect(os.Setenv("HOME", backupDir)).To(Succeed())
+			restore = update.SetExecutable(func() (string, error) { return cmdPath, nil })
+		})
+
+		AfterEach(func() {
+			restore()
+			Expect(os.Setenv("HOME", home)).To(Succeed())
+		})
+
+		It("Should restore the running binary from the default backup directory", func() {
+			if runtime.GOOS == "windows" {
+				Skip("the home directory comes from USERPROFILE")
+			}
+
+			Expect(update.BackupBinary("", cmdPath, semver.MustParse("0.1.0"))).To(Succeed())
+			Expect(ioutil.WriteFile(cmdPath, []byte("circleci 0.2.0"), 0755)).To(Succeed())
+
+			result, err := update.RollbackPrevious()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Version.String()).To(Equal("0.1.0"))
+
+			installed, err := ioutil.ReadFile(cmdPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(installed)).To(Equal("circleci 0.1.0"))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Release channels", func() {
+	beta := platformRelease("v1.1.0-beta.1")
+	beta.Prerelease = true
+
+	nightly := platformRelease("v1.2.0-nightly.20210601")
+	nightly.Prerelease = true
+
+	releases := []update.Release{
+		platformRelease("v1.0.0"),
+		beta,
+		nightly,
+	}
+
+	check := func(options ...update.CheckOption) string {
+		options = append(options, update.WithUpdater(&fakeUpdater{releases: releases}))
+		opts, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release", options...)
+		Expect(err).ShouldNot(HaveOccurred())
+		return opts.Latest.Version.String()
+	}
+
+	It("Should follow stable releases by default", func() {
+		Expect(check()).To(Equal("1.0.0"))
+		Expect(check(update.WithChannel(""))).To(Equal("1.0.0"))
+		Expect(check(update.WithChannel(update.ChannelStable))).To(Equal("1.0.0"))
+	})
+
+	It("Should include prereleases on the beta channel", func() {
+		Expect(check(update.WithChannel(update.ChannelBeta))).To(Equal("1.1.0-beta.1"))
+	})
+
+	It("Should include prereleases when opted in to them", func() {
+		Expect(check(update.WithPrereleases())).To(Equal("1.1.0-beta.1"))
+		Expect(check(update.WithPrereleases(), update.WithChannel(update.ChannelStable))).To(Equal("1.1.0-beta.1"))
+		Expect(check(update.WithPrereleases(), update.WithChannel(update.ChannelNightly))).To(Equal("1.2.0-nightly.20210601"))
+	})
+
+	It("Should not consider a stable release newer than a prerelease opted in to", func() {
+		opts, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "1.1.0-beta.1", "release",
+			update.WithUpdater(&fakeUpdater{releases: releases}), update.WithPrereleases())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(update.IsLatestVersion(opts)).To(BeTrue())
+	})
+
+	It("Should include nightly builds on the nightly channel", func() {
+		Expect(check(update.WithChannel(update.ChannelNightly))).To(Equal("1.2.0-nightly.20210601"))
+	})
+
+	It("Should reject unknown channels", func() {
+		_, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithUpdater(&fakeUpdater{releases: releases}), update.WithChannel("edge"))
+		Expect(err).To(MatchError(`unknown update channel "edge", expected one of stable, beta, nightly`))
+	})
+})
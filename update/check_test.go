@@ -0,0 +1,74 @@
+package update_test
+
+import (
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Should check for updates", func() {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	var restore func()
+
+	BeforeEach(func() {
+		restore = update.SetNowFunc(func() time.Time { return now })
+	})
+
+	AfterEach(func() {
+		restore()
+	})
+
+	DescribeTable("Should check once 28 hours have passed",
+		func(sinceLastCheck time.Duration, expected bool) {
+			upd := &settings.UpdateCheck{LastUpdateCheck: now.Add(-sinceLastCheck)}
+			Expect(update.ShouldCheckForUpdates(upd)).To(Equal(expected))
+		},
+		Entry("just checked", time.Duration(0), false),
+		Entry("a moment before the delay", 28*time.Hour-time.Nanosecond, false),
+		Entry("exactly at the delay", 28*time.Hour, true),
+		Entry("after the delay", 29*time.Hour, true),
+		Entry("checked in the future", -time.Hour, false),
+	)
+
+	DescribeTable("Should check once the configured interval has passed",
+		func(sinceLastCheck time.Duration, expected bool) {
+			upd := &settings.UpdateCheck{LastUpdateCheck: now.Add(-sinceLastCheck), Interval: 168 * time.Hour}
+			Expect(update.ShouldCheckForUpdates(upd)).To(Equal(expected))
+			Expect(update.CheckInterval(upd)).To(Equal(168 * time.Hour))
+		},
+		Entry("after the default delay", 29*time.Hour, false),
+		Entry("a moment before the interval", 168*time.Hour-time.Nanosecond, false),
+		Entry("exactly at the interval", 168*time.Hour, true),
+	)
+
+	It("Should check if it has never checked before", func() {
+		Expect(update.ShouldCheckForUpdates(&settings.UpdateCheck{})).To(BeTrue())
+	})
+
+	DescribeTable("Should work out when the next check is due",
+		func(sinceLastCheck, interval, untilNext time.Duration) {
+			upd := &settings.UpdateCheck{LastUpdateCheck: now.Add(-sinceLastCheck)}
+			status := update.UpdateCheckStatus(upd, interval)
+			Expect(*status.LastChecked).To(Equal(now.Add(-sinceLastCheck)))
+			Expect(status.NextCheck).To(Equal(now.Add(untilNext)))
+			Expect(status.Interval).To(Equal(interval))
+		},
+		Entry("just checked, default interval", time.Duration(0), 28*time.Hour, 28*time.Hour),
+		Entry("partway through the default interval", 10*time.Hour, 28*time.Hour, 18*time.Hour),
+		Entry("partway through an hour", 15*time.Minute, time.Hour, 45*time.Minute),
+		Entry("partway through a week", 24*time.Hour, 7*24*time.Hour, 6*24*time.Hour),
+		Entry("due exactly now", time.Hour, time.Hour, time.Duration(0)),
+		Entry("overdue", 30*time.Hour, 28*time.Hour, time.Duration(0)),
+	)
+
+	It("Should be due now if it has never checked before", func() {
+		status := update.UpdateCheckStatus(&settings.UpdateCheck{}, update.CheckInterval(&settings.UpdateCheck{}))
+		Expect(status.LastChecked).To(BeNil())
+		Expect(status.NextCheck).To(Equal(now))
+		Expect(status.Interval).To(Equal(28 * time.Hour))
+	})
+})
@@ -0,0 +1,71 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// checksumsSuffix ends the name of the file goreleaser publishes with each
+// release, listing the SHA256 of every other asset.
+const checksumsSuffix = "checksums.txt"
+
+// checksummedAsset is an asset listed in the checksums file of its release.
+type checksummedAsset struct {
+	name      string
+	checksums ReleaseAsset
+}
+
+// checksumAssets maps the ID of each asset in releases that was published
+// with a checksums file to that file.
+func checksumAssets(releases []Release) map[int64]checksummedAsset {
+	checksums := map[int64]checksummedAsset{}
+	for _, rel := range releases {
+		var file *ReleaseAsset
+		for i, asset := range rel.Assets {
+			if strings.HasSuffix(asset.Name, checksumsSuffix) {
+				file = &rel.Assets[i]
+				break
+			}
+		}
+		if file == nil {
+			continue
+		}
+		for _, asset := range rel.Assets {
+			if asset.ID != file.ID {
+				checksums[asset.ID] = checksummedAsset{name: asset.Name, checksums: *file}
+			}
+		}
+	}
+	return checksums
+}
+
+// parseChecksums parses a checksums file as written by sha256sum, a line of
+// `<hex digest>  <file name>` for each file, into digests by file name.
+func parseChecksums(data string) map[string]string {
+	digests := map[string]string{}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks files read in binary mode with a leading '*'.
+		digests[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return digests
+}
+
+// verifyChecksum checks that blob is the file called name in the checksums file.
+func verifyChecksum(checksums, name string, blob []byte) error {
+	want, ok := parseChecksums(checksums)[name]
+	if !ok {
+		return fmt.Errorf("%s is not listed in the release checksums", name)
+	}
+
+	sum := sha256.Sum256(blob)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum of %s doesn't match the release checksums: got %s, expected %s", name, got, want)
+	}
+	return nil
+}
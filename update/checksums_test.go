@@ -0,0 +1,114 @@
+package update_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Verifying release checksums", func() {
+	const binary = "circleci 0.2.0 for real"
+
+	var (
+		server   *ghttp.Server
+		cacheDir string
+		asset    string
+	)
+
+	// serve publishes a release of binary along with the given checksums file.
+	serve := func(checksums string) {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{"tag_name": "v0.2.0", "assets": [
+					{"id": 1, "name": "%s", "size": %d},
+					{"id": 3, "name": "circleci-cli_0.2.0_checksums.txt"}
+				]}]`, asset, len(binary))),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				ghttp.RespondWith(http.StatusOK, binary),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/3"),
+				ghttp.RespondWith(http.StatusOK, checksums),
+			),
+		)
+	}
+
+	fetch := func() (*update.FetchedRelease, error) {
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithoutPreflight(), update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		return update.FetchLatest(opts, cacheDir)
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		asset = fmt.Sprintf("circleci-cli_0.2.0_%s_%s", runtime.GOOS, runtime.GOARCH)
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("Should install a release matching its checksums", func() {
+		sum := sha256.Sum256([]byte(binary))
+		serve(fmt.Sprintf("%s  other_file.tar.gz\n%s  %s\n", hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(sum[:]), asset))
+
+		fetched, err := fetch()
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(fetched.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(binary))
+	})
+
+	It("Should refuse a release that doesn't match its checksums", func() {
+		wrong := hex.EncodeToString(make([]byte, 32))
+		serve(fmt.Sprintf("%s  %s\n", wrong, asset))
+
+		sum := sha256.Sum256([]byte(binary))
+		_, err := fetch()
+		Expect(err).To(MatchError(fmt.Sprintf(
+			"failed to fetch update: failed to verify release 0.2.0, refusing to install it: checksum of %s doesn't match the release checksums: got %s, expected %s",
+			asset, hex.EncodeToString(sum[:]), wrong)))
+	})
+
+	It("Should refuse a release without a checksums file", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+			ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{"tag_name": "v0.2.0", "assets": [{"id": 1, "name": "%s", "size": %d}]}]`, asset, len(binary))),
+		), ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+			ghttp.RespondWith(http.StatusOK, binary),
+		))
+
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithoutPreflight())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = update.FetchLatest(opts, cacheDir)
+		Expect(err).To(MatchError("failed to fetch update: release 0.2.0 has no checksums file, refusing to install it unverified"))
+	})
+
+	It("Should refuse a release missing from its checksums", func() {
+		serve("")
+
+		_, err := fetch()
+		Expect(err).To(MatchError(fmt.Sprintf(
+			"failed to fetch update: failed to verify release 0.2.0, refusing to install it: %s is not listed in the release checksums", asset)))
+	})
+})
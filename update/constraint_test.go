@@ -0,0 +1,63 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version constraints", func() {
+	prerelease := platformRelease("v1.5.0-rc1")
+	prerelease.Prerelease = true
+
+	releases := []update.Release{
+		platformRelease("v0.9.0"),
+		platformRelease("v1.0.0"),
+		platformRelease("v1.4.2"),
+		prerelease,
+		platformRelease("v2.0.0"),
+		platformRelease("v2.1.0"),
+	}
+
+	DescribeTable("Should pick the newest release satisfying the constraint",
+		func(constraint, expected string) {
+			check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+				update.WithUpdater(&fakeUpdater{releases: releases}), update.WithVersionConstraint(constraint))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(check.Found).To(BeTrue())
+			Expect(check.Latest.Version.String()).To(Equal(expected))
+		},
+		Entry("no constraint", "", "2.1.0"),
+		Entry("latest 1.x", ">=1.0.0 <2.0.0", "1.4.2"),
+		Entry("before 1.0", "<1.0.0", "0.9.0"),
+		Entry("exact version", "=2.0.0", "2.0.0"),
+		Entry("either major", "<1.0.0 || >=2.0.0 <2.1.0", "2.0.0"),
+	)
+
+	It("Should find nothing when no release satisfies the constraint", func() {
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithUpdater(&fakeUpdater{releases: releases}), update.WithVersionConstraint(">=3.0.0"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(check.Found).To(BeFalse())
+	})
+
+	It("Should reject an invalid constraint", func() {
+		_, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithUpdater(&fakeUpdater{releases: releases}), update.WithVersionConstraint("1.x or so"))
+		Expect(err).To(MatchError(ContainSubstring(`Invalid version constraint "1.x or so"`)))
+	})
+
+	It("Should mention the constraint when reporting the update", func() {
+		opts := &update.Options{
+			Current:           semver.MustParse("1.0.0"),
+			Latest:            releaseWithVersion(semver.MustParse("1.4.2")),
+			PackageManager:    "release",
+			VersionConstraint: ">=1.0.0 <2.0.0",
+		}
+
+		Expect(update.ReportVersion(opts)).To(Equal("You are running 1.0.0\nA new release matching >=1.0.0 <2.0.0 is available (1.4.2)"))
+		Expect(update.HowToUpdate(opts)).To(Equal("You can update with `circleci update install`\nUpdates are limited to versions matching >=1.0.0 <2.0.0"))
+	})
+})
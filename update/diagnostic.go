@@ -0,0 +1,48 @@
+package update
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BuildUpdateDiagnosticReport assembles a report about a failed update check
+// that users can paste into a bug report. It makes no network calls and runs
+// nothing, and the GitHub token is never included, only whether one was used.
+func BuildUpdateDiagnosticReport(opts *Options, err error) string {
+	var current, packageManager, githubAPI, token string
+	if opts != nil {
+		current = opts.Current.String()
+		packageManager = opts.PackageManager
+		githubAPI = opts.githubAPI
+		if updater, ok := opts.updater.(*githubUpdater); ok {
+			token = updater.token
+		} else {
+			token = environmentToken(githubHost(githubAPI))
+		}
+	}
+
+	lines := []string{
+		"CircleCI CLI update diagnostic report",
+		fmt.Sprintf("OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH),
+		fmt.Sprintf("CLI version: %s", current),
+		fmt.Sprintf("Package manager: %s", packageManager),
+		fmt.Sprintf("GitHub API: %s", githubAPI),
+		fmt.Sprintf("GitHub token present: %t", token != ""),
+	}
+
+	if err != nil {
+		message := err.Error()
+		if token != "" {
+			message = strings.ReplaceAll(message, token, "[REDACTED]")
+		}
+		lines = append(lines,
+			fmt.Sprintf("Error class: %T", errors.Cause(err)),
+			fmt.Sprintf("Error: %s", message),
+		)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,45 @@
+package update_test
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Update diagnostic report", func() {
+	const token = "ghp_supersecrettoken"
+
+	BeforeEach(func() {
+		os.Setenv("GITHUB_TOKEN", token)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("GITHUB_TOKEN")
+	})
+
+	It("Should describe the environment and the failure without the token", func() {
+		updater := &fakeUpdater{err: fmt.Errorf("401 Bad credentials for token %s", token)}
+		opts, err := update.CheckForUpdates("https://github.example.com/api/v3/", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).To(HaveOccurred())
+
+		report := update.BuildUpdateDiagnosticReport(opts, err)
+		Expect(report).To(ContainSubstring(fmt.Sprintf("OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)))
+		Expect(report).To(ContainSubstring("CLI version: 0.1.0"))
+		Expect(report).To(ContainSubstring("Package manager: release"))
+		Expect(report).To(ContainSubstring("GitHub API: https://github.example.com/api/v3/"))
+		Expect(report).To(ContainSubstring("GitHub token present: true"))
+		Expect(report).To(ContainSubstring("Error class: *errors.errorString"))
+		Expect(report).To(ContainSubstring("401 Bad credentials for token [REDACTED]"))
+		Expect(report).NotTo(ContainSubstring(token))
+	})
+
+	It("Should cope with a failure before any options were built", func() {
+		report := update.BuildUpdateDiagnosticReport(nil, fmt.Errorf("Failed to parse current version"))
+		Expect(report).To(ContainSubstring("CLI version: \n"))
+		Expect(report).To(ContainSubstring("Error: Failed to parse current version"))
+	})
+})
@@ -0,0 +1,29 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Distro package installs", func() {
+	DescribeTable("Should only explain how to update through the package manager",
+		func(packageManager, instructions string) {
+			updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+			check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", packageManager,
+				update.WithUpdater(updater))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(check.Found).To(BeTrue())
+			Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+			Expect(update.HowToUpdate(check)).To(Equal(instructions))
+
+			_, err = update.InstallLatestWithResult(check)
+			Expect(err).To(MatchError("failed to install update: not supported for " + packageManager + " installs"))
+			Expect(updater.installed).To(BeEmpty())
+		},
+		Entry("apt", "apt", "You can update with `sudo apt-get update && sudo apt-get install --only-upgrade circleci`"),
+		Entry("yum", "yum", "You can update with `sudo yum update circleci`"),
+		Entry("nix", "nix", "You can update with `nix-env --upgrade circleci-cli`, or by updating the Nix configuration that installs it"),
+	)
+})
@@ -0,0 +1,142 @@
+package update_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Downloading releases", func() {
+	const binary = "circleci 0.2.0 for real"
+
+	var (
+		server   *ghttp.Server
+		cacheDir string
+	)
+
+	// shortDelivery promises the whole binary but sends only part of it.
+	shortDelivery := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(binary)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(binary[:8]))
+	}
+
+	fetch := func() (*update.FetchedRelease, error) {
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithoutPreflight(), update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		return update.FetchLatest(opts, cacheDir)
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		server = ghttp.NewServer()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{
+					"tag_name": "v0.2.0",
+					"assets": [{"id": 1, "name": "circleci-cli_%s_%s", "size": %d}]
+				}]`, runtime.GOOS, runtime.GOARCH, len(binary))),
+			),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("Should retry a download that is cut short", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				ghttp.VerifyHeaderKV("Accept", "application/octet-stream"),
+				shortDelivery,
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				ghttp.RespondWith(http.StatusOK, binary),
+			),
+		)
+
+		fetched, err := fetch()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+
+		contents, err := ioutil.ReadFile(fetched.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(binary))
+	})
+
+	It("Should replace the binary in place without leaving files behind", func() {
+		// Installing picks from the releases the check listed.
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				ghttp.RespondWith(http.StatusOK, binary),
+			),
+		)
+
+		installDir := filepath.Join(cacheDir, "bin")
+		Expect(os.Mkdir(installDir, 0700)).To(Succeed())
+		cmdPath := filepath.Join(installDir, "circleci")
+		Expect(ioutil.WriteFile(cmdPath, []byte("circleci 0.1.0"), 0750)).To(Succeed())
+		restore := update.SetExecutable(func() (string, error) { return cmdPath, nil })
+		defer restore()
+
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithoutPreflight(), update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		opts.BackupDir = filepath.Join(cacheDir, "backup")
+		opts.LockFile = filepath.Join(cacheDir, "update.lock")
+
+		_, err = update.InstallLatestWithResult(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(binary))
+
+		info, err := os.Stat(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		if runtime.GOOS != "windows" {
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0750)))
+		}
+
+		files, err := ioutil.ReadDir(installDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("Should give up when the retry is cut short too", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				shortDelivery,
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				shortDelivery,
+			),
+		)
+
+		_, err := fetch()
+		Expect(err).To(MatchError(fmt.Sprintf(
+			"failed to fetch update: download of %s/repos/CircleCI-Public/circleci-cli/releases/assets/1 was truncated: got 8 of %d bytes",
+			server.URL(), len(binary))))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+})
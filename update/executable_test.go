@@ -0,0 +1,62 @@
+package update_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Finding the running binary", func() {
+	const advice = "install the latest version from https://github.com/CircleCI-Public/circleci-cli/releases instead"
+
+	var restore func()
+
+	AfterEach(func() {
+		restore()
+	})
+
+	It("Should explain how to recover when the binary can't be found", func() {
+		restore = update.SetExecutable(func() (string, error) {
+			return "", errors.New("executable not found")
+		})
+
+		_, err := update.ExecutablePath()
+		Expect(err).To(MatchError("cannot find the running circleci binary to replace (executable not found), " + advice))
+	})
+
+	It("Should explain how to recover when the binary was deleted", func() {
+		dir, err := ioutil.TempDir("", "circleci-cli-install")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		deleted := filepath.Join(dir, "circleci")
+		restore = update.SetExecutable(func() (string, error) { return deleted, nil })
+
+		_, err = update.ExecutablePath()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("cannot find the running circleci binary to replace ("))
+		Expect(err.Error()).To(HaveSuffix(advice))
+	})
+
+	It("Should fail an install before downloading anything", func() {
+		restore = update.SetExecutable(func() (string, error) {
+			return "", errors.New("executable not found")
+		})
+
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+		opts, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = update.InstallLatestWithResult(opts)
+		Expect(err).To(MatchError("failed to install update: cannot find the running circleci binary to replace (executable not found), " + advice))
+		Expect(updater.installed).To(BeEmpty())
+
+		_, err = update.FetchLatest(opts, os.TempDir())
+		Expect(err).To(MatchError("failed to fetch update: cannot find the running circleci binary to replace (executable not found), " + advice))
+	})
+})
@@ -0,0 +1,64 @@
+package update
+
+import (
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// SetNowFunc pins the clock used by the package, returning a function that restores it.
+func SetNowFunc(now func() time.Time) (restore func()) {
+	old := nowFunc
+	nowFunc = now
+	return func() { nowFunc = old }
+}
+
+// BackupBinary saves the binary at cmdPath into dir as install does before replacing it.
+func BackupBinary(dir, cmdPath string, version semver.Version) error {
+	return backupBinary(dir, cmdPath, version)
+}
+
+// SetExecutable replaces how the running binary is found, returning a function that restores it.
+func SetExecutable(fn func() (string, error)) (restore func()) {
+	old := executable
+	executable = fn
+	return func() { executable = old }
+}
+
+// SetSnapStoreURL points snap update checks at another store, returning a function that restores it.
+func SetSnapStoreURL(url string) (restore func()) {
+	old := snapStoreURL
+	snapStoreURL = url
+	return func() { snapStoreURL = old }
+}
+
+// SnapArchitecture names the running architecture the way the snap store does.
+func SnapArchitecture() string {
+	return snapArchitecture()
+}
+
+// SetSigningKey replaces the key releases are verified with, returning a function that restores it.
+func SetSigningKey(key []byte) (restore func()) {
+	old := signingKey
+	signingKey = key
+	return func() { signingKey = old }
+}
+
+// SetSignedSince replaces the first release that must be signed, returning a function that restores it.
+func SetSignedSince(version string) (restore func()) {
+	old := signedSince
+	signedSince = semver.MustParse(version)
+	return func() { signedSince = old }
+}
+
+// SetLinuxbrewPrefixes replaces where Homebrew on Linux is looked for, returning a function that restores it.
+func SetLinuxbrewPrefixes(prefixes ...string) (restore func()) {
+	old := linuxbrewPrefixes
+	linuxbrewPrefixes = prefixes
+	return func() { linuxbrewPrefixes = old }
+}
+
+// RemoveStaleLock removes the lock at path as lockUpdate does once it has found it to be stale.
+func RemoveStaleLock(path string) {
+	removeStaleLock(path)
+}
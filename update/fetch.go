@@ -0,0 +1,168 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// fetchedManifest is the file in the cache directory recording the release
+// waiting to be activated.
+const fetchedManifest = "fetched.json"
+
+// FetchedRelease describes a binary downloaded by FetchLatest that can later be
+// swapped in with ActivateFetched.
+type FetchedRelease struct {
+	Version  semver.Version `json:"version"`
+	Path     string         `json:"path"`
+	Checksum string         `json:"checksum"`
+}
+
+// FetchLatest downloads the latest release into cacheDir without touching the
+// running binary, and records it so ActivateFetched can install it later.
+func FetchLatest(opts *Options, cacheDir string) (*FetchedRelease, error) {
+	if !opts.canInstall() {
+		return nil, fmt.Errorf("failed to fetch update: not supported for %s installs", opts.PackageManager)
+	}
+	if opts.Latest == nil {
+		return nil, errors.New("failed to fetch update: no release found")
+	}
+	if !hasAsset(opts.Latest) {
+		return nil, fmt.Errorf("failed to fetch update: release %s has no download for this platform", opts.Latest.Version)
+	}
+
+	exe, err := ExecutablePath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch update")
+	}
+
+	dir := filepath.Join(cacheDir, opts.Latest.Version.String())
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create update cache")
+	}
+
+	// The updater replaces an existing file, so give it one to replace. The
+	// name must match the running binary as it is used to find the binary
+	// inside the release archive.
+	path := filepath.Join(dir, filepath.Base(exe))
+	if err = ioutil.WriteFile(path, nil, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create update cache")
+	}
+
+	if err = opts.updater.UpdateTo(opts.Latest, path); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch update")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify fetched binary")
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("failed to verify fetched binary: %s is empty", path)
+	}
+
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to checksum fetched binary")
+	}
+
+	fetched := &FetchedRelease{
+		Version:  opts.Latest.Version,
+		Path:     path,
+		Checksum: checksum,
+	}
+
+	manifest, err := json.Marshal(fetched)
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(filepath.Join(cacheDir, fetchedManifest), manifest, 0600); err != nil {
+		return nil, errors.Wrap(err, "failed to record fetched binary")
+	}
+
+	return fetched, nil
+}
+
+// ActivateFetched replaces the binary at cmdPath with the release previously
+// downloaded into cacheDir by FetchLatest, after checking it is unchanged. The
+// binary being replaced, opts.Current, is backed up first so Rollback can
+// restore it, and opts.LockFile is held as InstallLatestWithResult holds it.
+func ActivateFetched(opts *Options, cacheDir, cmdPath string) (*InstallResult, error) {
+	start := time.Now()
+
+	manifest, err := ioutil.ReadFile(filepath.Join(cacheDir, fetchedManifest)) // #nosec
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no fetched update found in %s, run `circleci update fetch` first", cacheDir)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fetched update")
+	}
+
+	var fetched FetchedRelease
+	if err = json.Unmarshal(manifest, &fetched); err != nil {
+		return nil, errors.Wrap(err, "failed to read fetched update")
+	}
+
+	checksum, err := fileChecksum(fetched.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify fetched binary")
+	}
+	if checksum != fetched.Checksum {
+		return nil, fmt.Errorf("fetched binary %s has changed since it was fetched, run `circleci update fetch` again", fetched.Path)
+	}
+
+	release, err := lockUpdate(opts.LockFile, opts.LockWait)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err = backupBinary(opts.BackupDir, cmdPath, opts.Current); err != nil {
+		return nil, errors.Wrap(err, "failed to back up the current binary")
+	}
+
+	if err = swapBinary(fetched.Path, cmdPath); err != nil {
+		return nil, errors.Wrap(err, "failed to activate update")
+	}
+
+	// The fetched release has been used; don't activate it twice.
+	_ = os.Remove(filepath.Join(cacheDir, fetchedManifest))
+
+	return &InstallResult{
+		PreviousVersion: opts.Current,
+		Version:         fetched.Version,
+		Path:            cmdPath,
+		Checksum:        checksum,
+		Duration:        time.Since(start),
+	}, nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src) // #nosec
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,122 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fetch and activate", func() {
+	var (
+		cacheDir, installDir, cmdPath string
+		opts                          *update.Options
+	)
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+		installDir, err = ioutil.TempDir("", "circleci-cli-install")
+		Expect(err).ToNot(HaveOccurred())
+
+		cmdPath = filepath.Join(installDir, "circleci")
+		Expect(ioutil.WriteFile(cmdPath, []byte("circleci 0.1.0"), 0755)).To(Succeed())
+
+		updater := &fakeUpdater{writeBinary: true, releases: []update.Release{platformRelease("v0.2.0")}}
+		opts, err = update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ToNot(HaveOccurred())
+		opts.LockFile = filepath.Join(cacheDir, "update.lock")
+		opts.BackupDir = filepath.Join(cacheDir, "backup")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(cacheDir)
+		os.RemoveAll(installDir)
+	})
+
+	It("Should fetch into the cache without touching the installed binary, then activate it", func() {
+		fetched, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Version.String()).To(Equal("0.2.0"))
+		Expect(fetched.Path).To(HavePrefix(filepath.Join(cacheDir, "0.2.0")))
+		Expect(fetched.Checksum).To(HaveLen(64))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+
+		result, err := update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Version.String()).To(Equal("0.2.0"))
+		Expect(result.Path).To(Equal(cmdPath))
+		Expect(result.Checksum).To(Equal(fetched.Checksum))
+
+		installed, err = ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.2.0"))
+
+		_, err = update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).To(MatchError(ContainSubstring("no fetched update found")))
+	})
+
+	It("Should back up the replaced binary so it can be rolled back to", func() {
+		_, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.PreviousVersion.String()).To(Equal("0.1.0"))
+
+		result, err = update.Rollback(opts.BackupDir, cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Version.String()).To(Equal("0.1.0"))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+	})
+
+	It("Should wait for the lock file given in the options", func() {
+		_, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(opts.LockFile, nil, 0600)).To(Succeed())
+		opts.LockWait = -1
+
+		_, err = update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).To(MatchError(ContainSubstring(opts.LockFile)))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+	})
+
+	It("Should refuse to activate a binary that changed after it was fetched", func() {
+		fetched, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(fetched.Path, []byte("tampered"), 0700)).To(Succeed())
+
+		_, err = update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).To(MatchError(ContainSubstring("has changed since it was fetched")))
+
+		installed, err := ioutil.ReadFile(cmdPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(installed)).To(Equal("circleci 0.1.0"))
+	})
+
+	It("Should report when nothing has been fetched", func() {
+		_, err := update.ActivateFetched(opts, cacheDir, cmdPath)
+		Expect(err).To(MatchError(ContainSubstring("run `circleci update fetch` first")))
+	})
+
+	It("Should refuse to fetch a release without a download", func() {
+		opts.Latest.AssetID = 0
+		opts.Latest.AssetURL = ""
+
+		_, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).To(MatchError("failed to fetch update: release 0.2.0 has no download for this platform"))
+	})
+})
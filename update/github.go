@@ -0,0 +1,472 @@
+package update
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+// Updater is used to discover and install releases of the CLI. The default
+// implementation talks to the GitHub releases API; tests can provide their own
+// with WithUpdater.
+type Updater interface {
+	// Releases returns all the published releases of the repository identified by slug.
+	Releases(slug string) ([]Release, error)
+	// UpdateTo replaces the binary at cmdPath with the given release.
+	UpdateTo(rel *selfupdate.Release, cmdPath string) error
+}
+
+// Release is a release as listed by the GitHub releases API.
+type Release struct {
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	Body        string         `json:"body"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	PublishedAt *time.Time     `json:"published_at"`
+	HTMLURL     string         `json:"html_url"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a Release.
+type ReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int    `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// downloadAttempts is how many times a release asset is downloaded before a
+// truncated download is reported as an error.
+const downloadAttempts = 2
+
+type githubUpdater struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	// download is used for release assets, which can take much longer to
+	// transfer than the API calls client is meant for.
+	download *http.Client
+	// signingKey verifies the signature of every asset before it is
+	// installed. Verification is skipped when it is nil.
+	signingKey []byte
+	// current is the version being replaced. Releases older than it are
+	// only installed unsigned if verification is skipped.
+	current semver.Version
+	// signatures are the signature assets of the assets listed by Releases.
+	signatures map[int64]ReleaseAsset
+	// checksums are the checksums files of the assets listed by Releases.
+	checksums map[int64]checksummedAsset
+	// cacheFile is where the last list of releases is cached, or empty to
+	// always fetch them.
+	cacheFile string
+	// mirror is set when baseURL is a release mirror rather than a GitHub API.
+	mirror bool
+	// enough reports whether a page of releases goes back far enough that
+	// the pages after it don't matter, so they aren't fetched. Every page is
+	// fetched if it is nil.
+	enough func(page []Release) bool
+}
+
+func newGitHubUpdater(githubAPI string, transport http.RoundTripper, credentialHelpers bool) (*githubUpdater, error) {
+	if !strings.HasSuffix(githubAPI, "/") {
+		githubAPI += "/"
+	}
+
+	return &githubUpdater{
+		baseURL:    githubAPI,
+		token:      githubToken(githubAPI, credentialHelpers),
+		client:     &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		download:   &http.Client{Transport: transport},
+		signingKey: signingKey,
+	}, nil
+}
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func (g *githubUpdater) Releases(slug string) ([]Release, error) {
+	releases, err := g.listReleases(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.mirror {
+		if err = resolveMirrorAssets(g.baseURL, releases); err != nil {
+			return nil, err
+		}
+	}
+
+	g.signatures = signatureAssets(releases)
+	g.checksums = checksumAssets(releases)
+	return releases, nil
+}
+
+// listReleases fetches pages of releases until one is enough, unless the
+// first page is unchanged since the releases were cached.
+func (g *githubUpdater) listReleases(slug string) ([]Release, error) {
+	var (
+		releases []Release
+		etag     string
+		partial  bool
+	)
+
+	first := fmt.Sprintf("%srepos/%s/releases?per_page=100", g.baseURL, slug)
+	if g.mirror {
+		first = g.baseURL + mirrorListing
+	}
+	cache := loadReleasesCache(g.cacheFile, first)
+
+	url := first
+	for url != "" {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		if g.token != "" {
+			req.Header.Set("Authorization", "token "+g.token)
+		}
+		if url == first && cache != nil {
+			req.Header.Set("If-None-Match", cache.ETag)
+		}
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if url == first {
+			if resp.StatusCode == http.StatusNotModified && cache != nil {
+				resp.Body.Close()
+				if !cache.Partial || g.enough == nil || g.enough(cache.Releases) {
+					return cache.Releases, nil
+				}
+				// The cache stops short of the release being looked for.
+				cache = nil
+				continue
+			}
+			etag = resp.Header.Get("ETag")
+		}
+
+		// A missing repository simply has no releases.
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			break
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+
+		var page []Release
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page...)
+
+		url = ""
+		if m := linkNextPattern.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			url = m[1]
+		}
+		if url != "" && g.enough != nil && g.enough(page) {
+			partial = true
+			break
+		}
+	}
+
+	if etag != "" {
+		(&releasesCache{URL: first, ETag: etag, Releases: releases, Partial: partial}).save(g.cacheFile)
+	}
+	return releases, nil
+}
+
+// preflightTimeout bounds the request made to check the GitHub API can be reached.
+const preflightTimeout = 10 * time.Second
+
+// preflight checks that the base URL answers like the root of a GitHub API.
+// Any response other than a 404 counts, so errors such as rate limiting are
+// left to be reported by the request that hits them.
+func (g *githubUpdater) preflight() error {
+	req, err := http.NewRequest("GET", g.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("cannot reach GitHub API at %s: %w", g.baseURL, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	client := *g.client
+	client.Timeout = preflightTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach GitHub API at %s: %w", g.baseURL, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("cannot reach GitHub API at %s: got 404 Not Found, for GitHub Enterprise the URL should end in /api/v3/", g.baseURL)
+	}
+	return nil
+}
+
+func (g *githubUpdater) UpdateTo(rel *selfupdate.Release, cmdPath string) error {
+	url := g.assetURL(rel, rel.AssetID, rel.AssetURL)
+
+	asset, err := g.downloadWithRetry(url)
+	if err != nil {
+		return err
+	}
+
+	if err = g.verifyChecksum(rel, asset); err != nil {
+		return err
+	}
+
+	if g.signingKey != nil {
+		if err = g.verify(rel, asset); err != nil {
+			return err
+		}
+	}
+
+	cmd, err := selfupdate.UncompressCommand(bytes.NewReader(asset), rel.AssetURL, filepath.Base(cmdPath))
+	if err != nil {
+		return err
+	}
+
+	return replaceBinary(cmd, cmdPath)
+}
+
+// assetURL is where the asset of rel with the given ID is downloaded from:
+// through the GitHub API, or straight from a mirror.
+func (g *githubUpdater) assetURL(rel *selfupdate.Release, id int64, downloadURL string) string {
+	if g.mirror {
+		return downloadURL
+	}
+	return fmt.Sprintf("%srepos/%s/%s/releases/assets/%d", g.baseURL, rel.RepoOwner, rel.RepoName, id)
+}
+
+// verify checks asset against the signature published with it, refusing
+// unsigned releases unless they were published before releases were signed.
+func (g *githubUpdater) verify(rel *selfupdate.Release, asset []byte) error {
+	sigAsset, ok := g.signatures[rel.AssetID]
+	if !ok {
+		// Going back to a release made before releases were signed would
+		// otherwise be a way around verification.
+		if rel.Version.LT(signedSince) && rel.Version.LT(g.current) {
+			return fmt.Errorf("release %s is not signed and is older than %s, refusing to install it unverified", rel.Version, g.current)
+		}
+		if rel.Version.LT(signedSince) {
+			return nil
+		}
+		return fmt.Errorf("release %s is not signed, refusing to install it unverified", rel.Version)
+	}
+
+	url := g.assetURL(rel, sigAsset.ID, sigAsset.BrowserDownloadURL)
+	sig, err := g.downloadWithRetry(url)
+	if err != nil {
+		return fmt.Errorf("failed to download the signature of release %s: %w", rel.Version, err)
+	}
+
+	if err = verifySignature(g.signingKey, asset, sig); err != nil {
+		return fmt.Errorf("failed to verify release %s, refusing to install it: %w", rel.Version, err)
+	}
+	return nil
+}
+
+// verifyChecksum checks asset against the checksums file published with it.
+// A release without one is only installed when verification is skipped.
+func (g *githubUpdater) verifyChecksum(rel *selfupdate.Release, asset []byte) error {
+	checksummed, ok := g.checksums[rel.AssetID]
+	if !ok {
+		if g.signingKey == nil {
+			return nil
+		}
+		return fmt.Errorf("release %s has no checksums file, refusing to install it unverified", rel.Version)
+	}
+
+	url := g.assetURL(rel, checksummed.checksums.ID, checksummed.checksums.BrowserDownloadURL)
+	checksums, err := g.downloadWithRetry(url)
+	if err != nil {
+		return fmt.Errorf("failed to download the checksums of release %s: %w", rel.Version, err)
+	}
+
+	if err = verifyChecksum(string(checksums), checksummed.name, asset); err != nil {
+		return fmt.Errorf("failed to verify release %s, refusing to install it: %w", rel.Version, err)
+	}
+	return nil
+}
+
+// downloadWithRetry downloads a release asset, trying again if the download is
+// cut short.
+func (g *githubUpdater) downloadWithRetry(url string) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	for attempt := 0; attempt < downloadAttempts; attempt++ {
+		body, err = g.downloadAsset(url)
+		if _, truncated := err.(*truncatedDownloadError); !truncated {
+			break
+		}
+	}
+	return body, err
+}
+
+// truncatedDownloadError is returned when a download ends before all the bytes
+// the server promised have arrived, typically because a proxy or flaky
+// connection cut it short.
+type truncatedDownloadError struct {
+	url       string
+	got, want int64
+}
+
+func (e *truncatedDownloadError) Error() string {
+	return fmt.Sprintf("download of %s was truncated: got %d of %d bytes", e.url, e.got, e.want)
+}
+
+// downloadAsset fetches a release asset through the GitHub API, following the
+// redirect to its storage location, and checks the whole body arrived.
+func (g *githubUpdater) downloadAsset(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	resp, err := g.download.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	// The transport reports a body shorter than its Content-Length as an
+	// unexpected EOF; treat that the same as a short count.
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if resp.ContentLength >= 0 && int64(len(body)) != resp.ContentLength {
+		return nil, &truncatedDownloadError{url: url, got: int64(len(body)), want: resp.ContentLength}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// assetSuffixes are the asset name endings we accept for the running platform,
+// mirroring the conventions of the selfupdate package.
+func assetSuffixes() []string {
+	var suffixes []string
+	for _, sep := range []rune{'_', '-'} {
+		for _, ext := range []string{".zip", ".tar.gz", ".gzip", ".gz", ".tar.xz", ".xz", ""} {
+			suffixes = append(suffixes, fmt.Sprintf("%s%c%s%s", runtime.GOOS, sep, runtime.GOARCH, ext))
+			if runtime.GOOS == "windows" {
+				suffixes = append(suffixes, fmt.Sprintf("%s%c%s.exe%s", runtime.GOOS, sep, runtime.GOARCH, ext))
+			}
+		}
+	}
+	return suffixes
+}
+
+// platformAsset returns the asset of rel built for the running platform, if any.
+func platformAsset(rel Release) *ReleaseAsset {
+	for _, asset := range rel.Assets {
+		for _, suffix := range assetSuffixes() {
+			if strings.HasSuffix(asset.Name, suffix) {
+				a := asset
+				return &a
+			}
+		}
+	}
+	return nil
+}
+
+// onChannel reports whether a release is published on channel. Nightly builds
+// are prereleases tagged like v1.2.0-nightly.20210601, and are only followed by
+// the nightly channel.
+func onChannel(channel string, prerelease bool, version semver.Version) bool {
+	nightly := len(version.Pre) > 0 && version.Pre[0].VersionStr == "nightly"
+	switch channel {
+	case ChannelNightly:
+		return true
+	case ChannelBeta:
+		return !nightly
+	}
+	return !prerelease && !nightly
+}
+
+// selectLatest picks the newest release, according to the options' version
+// scheme, that is published on the options' channel, has an asset for the
+// running platform, and is within the allowed range if there is one.
+func selectLatest(opts *Options, releases []Release, allowed semver.Range) *selfupdate.Release {
+	var latest *selfupdate.Release
+	for _, rel := range releases {
+		if rel.Draft {
+			continue
+		}
+
+		version, err := opts.VersionScheme.Parse(rel.TagName)
+		if err != nil {
+			continue
+		}
+
+		// A target version is installed from whichever channel it was released on.
+		if opts.TargetVersion == "" && !onChannel(opts.Channel, rel.Prerelease, version) {
+			continue
+		}
+
+		if allowed != nil && !allowed(version) {
+			continue
+		}
+
+		asset := platformAsset(rel)
+		if asset == nil {
+			continue
+		}
+
+		if latest != nil && opts.VersionScheme.Compare(version, latest.Version) <= 0 {
+			continue
+		}
+
+		latest = &selfupdate.Release{
+			Version:       version,
+			AssetURL:      asset.BrowserDownloadURL,
+			AssetByteSize: asset.Size,
+			AssetID:       asset.ID,
+			URL:           rel.HTMLURL,
+			ReleaseNotes:  rel.Body,
+			Name:          rel.Name,
+			PublishedAt:   rel.PublishedAt,
+		}
+	}
+
+	if latest != nil {
+		repo := strings.SplitN(opts.slug, "/", 2)
+		if len(repo) == 2 {
+			latest.RepoOwner, latest.RepoName = repo[0], repo[1]
+		}
+	}
+	return latest
+}
@@ -0,0 +1,199 @@
+package update_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+// fakeUpdater serves a fixed list of releases and records installs instead of
+// replacing any binary. When writeBinary is set it writes a stand-in binary to
+// the install path, which must then not be the running test binary. onUpdate,
+// if set, is called before each install.
+type fakeUpdater struct {
+	releases    []update.Release
+	err         error
+	installed   []*selfupdate.Release
+	writeBinary bool
+	onUpdate    func()
+}
+
+func (f *fakeUpdater) Releases(_ string) ([]update.Release, error) {
+	return f.releases, f.err
+}
+
+func (f *fakeUpdater) UpdateTo(rel *selfupdate.Release, cmdPath string) error {
+	if f.onUpdate != nil {
+		f.onUpdate()
+	}
+	f.installed = append(f.installed, rel)
+	if f.writeBinary {
+		return ioutil.WriteFile(cmdPath, []byte("circleci "+rel.Version.String()), 0700)
+	}
+	return nil
+}
+
+// platformRelease returns a release with an asset for the running platform.
+func platformRelease(tag string) update.Release {
+	return update.Release{
+		TagName: tag,
+		Assets: []update.ReleaseAsset{
+			{ID: 1, Name: fmt.Sprintf("circleci-cli_%s_%s_%s.tar.gz", tag, runtime.GOOS, runtime.GOARCH)},
+		},
+	}
+}
+
+func releaseWithVersion(v semver.Version) *selfupdate.Release {
+	return &selfupdate.Release{Version: v}
+}
+
+var _ = Describe("Release detection", func() {
+	It("Should pick the newest published release with a platform asset", func() {
+		noAsset := update.Release{TagName: "v0.3.0"}
+		draft := platformRelease("v0.4.0")
+		draft.Draft = true
+		prerelease := platformRelease("v0.5.0-rc1")
+		prerelease.Prerelease = true
+
+		updater := &fakeUpdater{releases: []update.Release{
+			platformRelease("v0.1.0"),
+			platformRelease("v0.2.0"),
+			noAsset,
+			draft,
+			prerelease,
+			{TagName: "not-a-version"},
+		}}
+
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(check.Found).To(BeTrue())
+		Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+		Expect(check.Latest.RepoOwner).To(Equal("CircleCI-Public"))
+		Expect(check.Latest.RepoName).To(Equal("circleci-cli"))
+		Expect(update.IsLatestVersion(check)).To(BeFalse())
+	})
+
+	It("Should detect calendar versioned releases", func() {
+		updater := &fakeUpdater{releases: []update.Release{
+			platformRelease("2024.01.15"),
+			platformRelease("v2024.02.01"),
+			platformRelease("2023.12.31"),
+		}}
+
+		check, err := update.CheckForUpdates("", "some/fork", "2024.01.15", "release",
+			update.WithUpdater(updater), update.WithVersionScheme(update.CalVerScheme{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(check.Latest.Version.String()).To(Equal("2024.2.1"))
+		Expect(update.IsLatestVersion(check)).To(BeFalse())
+	})
+
+	It("Should report nothing found without releases", func() {
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(&fakeUpdater{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(check.Found).To(BeFalse())
+		Expect(update.IsLatestVersion(check)).To(BeTrue())
+	})
+
+	It("Should install the detected release", func() {
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		result, err := update.InstallLatestWithResult(check)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.PreviousVersion.String()).To(Equal("0.1.0"))
+		Expect(result.Version.String()).To(Equal("0.2.0"))
+		Expect(updater.installed).To(HaveLen(1))
+	})
+
+	It("Should skip silently when only installing if outdated and already current", func() {
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.2.0", "release", update.WithUpdater(updater))
+		Expect(err).ShouldNot(HaveOccurred())
+		check.OnlyIfOutdated = true
+
+		message, err := update.InstallLatest(check)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(message).To(BeEmpty())
+		Expect(updater.installed).To(BeEmpty())
+	})
+
+	It("Should install when only installing if outdated and a newer release exists", func() {
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+		check, err := update.CheckForUpdates("", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ShouldNot(HaveOccurred())
+		check.OnlyIfOutdated = true
+
+		message, err := update.InstallLatest(check)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(message).To(Equal("Updated to 0.2.0"))
+		Expect(updater.installed).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("Paging through releases", func() {
+	var server *ghttp.Server
+
+	// page serves releases with the given tags, linking to the next page if
+	// there is one.
+	page := func(n int, last bool, tags ...string) http.HandlerFunc {
+		releases := ""
+		for i, tag := range tags {
+			if i > 0 {
+				releases += ", "
+			}
+			releases += fmt.Sprintf(`{"tag_name": "%s", "assets": [{"id": %d, "name": "circleci-cli_%s_%s"}]}`, tag, n*10+i, runtime.GOOS, runtime.GOARCH)
+		}
+		header := http.Header{}
+		if !last {
+			header.Set("Link", fmt.Sprintf(`<%s/releases?page=%d>; rel="next"`, server.URL(), n+1))
+		}
+		return ghttp.RespondWith(http.StatusOK, "["+releases+"]", header)
+	}
+
+	check := func(current string, options ...update.CheckOption) *update.Options {
+		options = append(options, update.WithoutPreflight(), update.WithReleaseCache(""))
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", current, "release", options...)
+		Expect(err).ToNot(HaveOccurred())
+		return opts
+	}
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.AppendHandlers(
+			page(1, false, "v0.4.0", "v0.3.0"),
+			page(2, false, "v0.2.0", "v0.1.0"),
+			page(3, true, "v0.0.1"),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Should stop at the page with the current version", func() {
+		opts := check("0.3.0")
+		Expect(opts.Latest.Version.String()).To(Equal("0.4.0"))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("Should follow the pages back to the current version", func() {
+		opts := check("0.1.5")
+		Expect(opts.Latest.Version.String()).To(Equal("0.4.0"))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("Should follow the pages back to the target version", func() {
+		opts := check("0.4.0", update.WithTargetVersion("0.0.1"))
+		Expect(opts.Latest.Version.String()).To(Equal("0.0.1"))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,73 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Homebrew on Linux", func() {
+	var dir, path string
+	var restore func()
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("Homebrew on Linux is only looked for on Linux")
+		}
+
+		var err error
+		dir, err = ioutil.TempDir("", "circleci-cli-linuxbrew")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "prefix", "bin"), 0700)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(dir, "empty"), 0700)).To(Succeed())
+
+		// brew is only installed in the prefix, not on the PATH.
+		path = os.Getenv("PATH")
+		Expect(os.Setenv("PATH", filepath.Join(dir, "empty"))).To(Succeed())
+		restore = update.SetLinuxbrewPrefixes(filepath.Join(dir, "missing"), filepath.Join(dir, "prefix"))
+	})
+
+	AfterEach(func() {
+		if runtime.GOOS != "linux" {
+			return
+		}
+		restore()
+		Expect(os.Setenv("PATH", path)).To(Succeed())
+		os.RemoveAll(dir)
+	})
+
+	writeBrew := func(script string) string {
+		brew := filepath.Join(dir, "prefix", "bin", "brew")
+		Expect(ioutil.WriteFile(brew, []byte("#!/bin/sh\n"+script), 0700)).To(Succeed())
+		return brew
+	}
+
+	It("Should find brew in its prefix and name it in the update instructions", func() {
+		brew := writeBrew(`echo '{"formulae": [{"name": "circleci", "installed_versions": ["0.1.1"], "current_version": "0.2.0_1"}]}'` + "\n")
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Found).To(BeTrue())
+		Expect(check.Current.String()).To(Equal("0.1.1"))
+		Expect(check.Latest.Version.String()).To(Equal("0.2.0-1"))
+		Expect(update.HowToUpdate(check)).To(Equal("You can update with `" + brew + " upgrade circleci`"))
+	})
+
+	It("Should fall back to the JSON format of older Linuxbrew installs", func() {
+		writeBrew(`if [ "$2" != --json=v1 ]; then
+  echo "Error: invalid option: $2" >&2
+  exit 1
+fi
+echo '[{"name": "circleci", "installed_versions": ["0.1.1"], "current_version": "0.2.0"}]'
+`)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+	})
+})
@@ -0,0 +1,86 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/pkg/errors"
+)
+
+// ErrUpdateInProgress is returned when another process is already installing an update.
+var ErrUpdateInProgress = errors.New("update already in progress")
+
+const (
+	// defaultLockWait is how long to wait for another update to finish.
+	defaultLockWait = 5 * time.Second
+	// staleLockAfter is how old a lock can get before it is assumed to have
+	// been left behind by a process that died.
+	staleLockAfter   = 10 * time.Minute
+	lockPollInterval = 50 * time.Millisecond
+)
+
+func defaultLockFile() string {
+	return filepath.Join(settings.SettingsPath(), "update.lock")
+}
+
+// lockUpdate takes the lock file at path, waiting up to wait for another
+// process to release it. A negative wait fails at once if the lock is held.
+// The returned function releases the lock.
+func lockUpdate(path string, wait time.Duration) (release func(), err error) {
+	if path == "" {
+		path = defaultLockFile()
+	}
+	if wait == 0 {
+		wait = defaultLockWait
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to lock update")
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "failed to lock update")
+		}
+
+		if info, err := os.Stat(path); err == nil && isStaleLock(info) {
+			removeStaleLock(path)
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w, remove %s if no other update is running", ErrUpdateInProgress, path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func isStaleLock(info os.FileInfo) bool {
+	return nowFunc().Sub(info.ModTime()) > staleLockAfter
+}
+
+// removeStaleLock renames the lock at path aside before removing it, so that a
+// lock taken by another process since it was found to be stale isn't removed
+// along with it: should the lock moved aside no longer be stale, it is put back.
+func removeStaleLock(path string) {
+	aside := fmt.Sprintf("%s.%d-%d.stale", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		return
+	}
+	if info, err := os.Stat(aside); err == nil && !isStaleLock(info) {
+		// Link rather than rename, so that a lock taken in the meantime
+		// isn't replaced.
+		_ = os.Link(aside, path)
+	}
+	_ = os.Remove(aside)
+}
@@ -0,0 +1,117 @@
+package update_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Update lock", func() {
+	var (
+		dir     string
+		updater *fakeUpdater
+		opts    *update.Options
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "update-lock")
+		Expect(err).ToNot(HaveOccurred())
+
+		updater = &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+		opts, err = update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release", update.WithUpdater(updater))
+		Expect(err).ToNot(HaveOccurred())
+		opts.LockFile = filepath.Join(dir, "update.lock")
+		opts.LockWait = 100 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Should release the lock after installing", func() {
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.LockFile).ToNot(BeAnExistingFile())
+	})
+
+	It("Should report an update in progress while the lock is held", func() {
+		Expect(ioutil.WriteFile(opts.LockFile, []byte("12345\n"), 0600)).To(Succeed())
+
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(errors.Is(err, update.ErrUpdateInProgress)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("update already in progress")))
+		Expect(updater.installed).To(BeEmpty())
+		Expect(opts.LockFile).To(BeAnExistingFile())
+	})
+
+	It("Should fail at once for a held lock with a negative wait", func() {
+		Expect(ioutil.WriteFile(opts.LockFile, []byte("12345\n"), 0600)).To(Succeed())
+		opts.LockWait = -1
+
+		start := time.Now()
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(errors.Is(err, update.ErrUpdateInProgress)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("Should wait for a held lock to be released", func() {
+		Expect(ioutil.WriteFile(opts.LockFile, []byte("12345\n"), 0600)).To(Succeed())
+		opts.LockWait = 5 * time.Second
+		lockFile := opts.LockFile
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			os.Remove(lockFile)
+		}()
+
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updater.installed).To(HaveLen(1))
+	})
+
+	It("Should take over a stale lock", func() {
+		Expect(ioutil.WriteFile(opts.LockFile, []byte("12345\n"), 0600)).To(Succeed())
+		old := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(opts.LockFile, old, old)).To(Succeed())
+
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updater.installed).To(HaveLen(1))
+
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+
+	It("Should keep a lock taken again since it was found to be stale", func() {
+		Expect(ioutil.WriteFile(opts.LockFile, []byte("12345\n"), 0600)).To(Succeed())
+
+		update.RemoveStaleLock(opts.LockFile)
+		Expect(opts.LockFile).To(BeAnExistingFile())
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("Should release the lock when the install panics", func() {
+		updater.onUpdate = func() { panic("install failed") }
+
+		Expect(func() { _, _ = update.InstallLatestWithResult(opts) }).To(Panic())
+		Expect(opts.LockFile).ToNot(BeAnExistingFile())
+	})
+
+	It("Should hold the lock while installing", func() {
+		updater.onUpdate = func() {
+			Expect(opts.LockFile).To(BeAnExistingFile())
+		}
+
+		_, err := update.InstallLatestWithResult(opts)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,67 @@
+package update
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mirrorListing is the file, relative to a mirror's URL, listing its releases
+// in the same JSON as the GitHub releases API, such as a saved copy of
+// https://api.github.com/repos/CircleCI-Public/circleci-cli/releases. The
+// browser_download_url of each asset may be relative to the mirror's URL:
+//
+//	[
+//	  {
+//	    "tag_name": "v0.1.29936",
+//	    "assets": [
+//	      {"id": 1, "name": "circleci-cli_0.1.29936_linux_amd64.tar.gz", "browser_download_url": "v0.1.29936/circleci-cli_0.1.29936_linux_amd64.tar.gz"}
+//	    ]
+//	  }
+//	]
+const mirrorListing = "releases.json"
+
+// newReleaseMirror returns an updater that downloads releases from the mirror
+// at mirrorURL instead of GitHub. GitHub credentials are never sent to it.
+func newReleaseMirror(mirrorURL string, transport http.RoundTripper) (*githubUpdater, error) {
+	u, err := url.Parse(mirrorURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid release mirror %q, expected a URL such as https://artifacts.example.com/circleci-cli", mirrorURL)
+	}
+	if !strings.HasSuffix(mirrorURL, "/") {
+		mirrorURL += "/"
+	}
+
+	return &githubUpdater{
+		baseURL:    mirrorURL,
+		client:     &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		download:   &http.Client{Transport: transport},
+		signingKey: signingKey,
+		mirror:     true,
+	}, nil
+}
+
+// resolveMirrorAssets makes the download URL of every asset listed by the
+// mirror at base absolute.
+func resolveMirrorAssets(base string, releases []Release) error {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+
+	for i := range releases {
+		for j, asset := range releases[i].Assets {
+			if asset.BrowserDownloadURL == "" {
+				return fmt.Errorf("asset %s of release %s has no browser_download_url in %s%s", asset.Name, releases[i].TagName, base, mirrorListing)
+			}
+			ref, err := url.Parse(asset.BrowserDownloadURL)
+			if err != nil {
+				return fmt.Errorf("invalid browser_download_url %q for asset %s: %w", asset.BrowserDownloadURL, asset.Name, err)
+			}
+			releases[i].Assets[j].BrowserDownloadURL = baseURL.ResolveReference(ref).String()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package update_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Downloading releases from a mirror", func() {
+	const binary = "circleci 0.2.0 from the mirror"
+
+	var (
+		server   *ghttp.Server
+		cacheDir string
+		token    string
+	)
+
+	noAuthorization := func(_ http.ResponseWriter, r *http.Request) {
+		Expect(r.Header.Get("Authorization")).To(BeEmpty())
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		token = os.Getenv("GITHUB_TOKEN")
+		Expect(os.Setenv("GITHUB_TOKEN", "not-for-the-mirror")).To(Succeed())
+
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+		Expect(os.Setenv("GITHUB_TOKEN", token)).To(Succeed())
+	})
+
+	It("Should list releases and download assets relative to the mirror", func() {
+		asset := fmt.Sprintf("circleci-cli_0.2.0_%s_%s", runtime.GOOS, runtime.GOARCH)
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/circleci-cli/releases.json"),
+				noAuthorization,
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{"tag_name": "v0.2.0", "assets": [
+					{"id": 1, "name": "%s", "size": %d, "browser_download_url": "v0.2.0/%s"}
+				]}]`, asset, len(binary), asset)),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/circleci-cli/v0.2.0/"+asset),
+				noAuthorization,
+				ghttp.RespondWith(http.StatusOK, binary),
+			),
+		)
+
+		opts, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithReleaseMirror(server.URL()+"/circleci-cli"), update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.Found).To(BeTrue())
+		Expect(opts.Latest.Version.String()).To(Equal("0.2.0"))
+
+		fetched, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(fetched.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(binary))
+	})
+
+	It("Should reject a mirror that isn't a URL", func() {
+		_, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithReleaseMirror("artifacts.example.com"))
+		Expect(err).To(MatchError(`invalid release mirror "artifacts.example.com", expected a URL such as https://artifacts.example.com/circleci-cli`))
+	})
+})
@@ -0,0 +1,43 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Release notes", func() {
+	check := func(body string) *update.Options {
+		rel := platformRelease("v1.0.0")
+		rel.Body = body
+		rel.HTMLURL = "https://github.com/CircleCI-Public/circleci-cli/releases/tag/v1.0.0"
+
+		opts, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithUpdater(&fakeUpdater{releases: []update.Release{rel}}))
+		Expect(err).ShouldNot(HaveOccurred())
+		return opts
+	}
+
+	It("Should carry the notes of the latest release", func() {
+		opts := check("\r\n* Faster config validation\r\n* Fewer bugs\r\n")
+		Expect(update.ReleaseNotes(opts)).To(Equal("* Faster config validation\n* Fewer bugs"))
+	})
+
+	It("Should abbreviate long notes in the version report", func() {
+		opts := check("1\n2\n\n3\n4\n5\n6\n7")
+		Expect(update.ReportVersion(opts)).To(Equal(`You are running 0.1.0
+A new release is available (1.0.0)
+What's new:
+  1
+  2
+  3
+  4
+  5
+  ...
+Full release notes: https://github.com/CircleCI-Public/circleci-cli/releases/tag/v1.0.0`))
+	})
+
+	It("Should leave the version report alone without notes", func() {
+		Expect(update.ReportVersion(check(""))).To(Equal("You are running 0.1.0\nA new release is available (1.0.0)"))
+	})
+})
@@ -0,0 +1,83 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Update Notice", func() {
+	var opts *update.Options
+
+	BeforeEach(func() {
+		opts = &update.Options{
+			Current:        semver.MustParse("0.1.0"),
+			Latest:         releaseWithVersion(semver.MustParse("0.2.0")),
+			PackageManager: "release",
+		}
+	})
+
+	It("Should include how to update by default", func() {
+		notice := update.UpdateNotice(opts, update.NoticeConfig{})
+		Expect(notice).To(Equal("You are running 0.1.0\nA new release is available (0.2.0)\nYou can update with `circleci update install`"))
+	})
+
+	It("Should omit how to update when suppressed", func() {
+		notice := update.UpdateNotice(opts, update.NoticeConfig{SuppressInstructions: true})
+		Expect(notice).To(Equal("You are running 0.1.0\nA new release is available (0.2.0)"))
+		Expect(notice).NotTo(ContainSubstring("circleci update install"))
+	})
+
+	It("Should use the custom message instead of how to update", func() {
+		notice := update.UpdateNotice(opts, update.NoticeConfig{CustomMessage: "Ask #it-help to update your CLI"})
+		Expect(notice).To(Equal("You are running 0.1.0\nA new release is available (0.2.0)\nAsk #it-help to update your CLI"))
+	})
+
+	DescribeTable("Update instructions",
+		func(packageManager string, expected bool) {
+			opts.PackageManager = packageManager
+
+			Expect(update.HasUpdateInstructions(opts)).To(Equal(expected))
+			notice := update.UpdateNotice(opts, update.NoticeConfig{})
+			Expect(notice).NotTo(HaveSuffix("\n"))
+			Expect(notice).NotTo(ContainSubstring("\n\n"))
+			if expected {
+				Expect(notice).To(ContainSubstring(update.HowToUpdate(opts)))
+			} else {
+				Expect(notice).To(Equal("You are running 0.1.0\nA new release is available (0.2.0)"))
+			}
+		},
+		Entry("release", "release", true),
+		Entry("source", "source", true),
+		Entry("homebrew", "homebrew", true),
+		Entry("snap", "snap", true),
+		Entry("unknown", "conda", false),
+		Entry("unset", "", false),
+	)
+
+	DescribeTable("Nag level",
+		func(latest, level string, shown bool) {
+			opts.Current = semver.MustParse("1.2.3")
+			opts.Latest = releaseWithVersion(semver.MustParse(latest))
+
+			notice := update.UpdateNotice(opts, update.NoticeConfig{NagLevel: level})
+			if shown {
+				Expect(notice).To(ContainSubstring("A new release is available (" + latest + ")"))
+			} else {
+				Expect(notice).To(BeEmpty())
+			}
+		},
+		Entry("patch bump, default level", "1.2.4", "", true),
+		Entry("patch bump, patch level", "1.2.4", "patch", true),
+		Entry("patch bump, minor level", "1.2.4", "minor", false),
+		Entry("patch bump, major level", "1.2.4", "major", false),
+		Entry("minor bump, patch level", "1.3.0", "patch", true),
+		Entry("minor bump, minor level", "1.3.0", "minor", true),
+		Entry("minor bump, major level", "1.3.0", "major", false),
+		Entry("major bump, patch level", "2.0.0", "patch", true),
+		Entry("major bump, minor level", "2.0.0", "minor", true),
+		Entry("major bump, major level", "2.0.0", "major", true),
+	)
+})
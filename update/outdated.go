@@ -0,0 +1,129 @@
+package update
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+// Where releases found through Chocolatey and Scoop are described, as like
+// homebrew they are installed by the package manager rather than the updater.
+const (
+	chocolateyPackageURL = "https://community.chocolatey.org/packages/circleci-cli"
+	scoopAppURL          = "https://scoop.sh/#/apps?q=circleci"
+)
+
+// OutdatedPackage is a package a package manager reports as having a newer version.
+type OutdatedPackage struct {
+	Name             string
+	InstalledVersion string
+	LatestVersion    string
+}
+
+// ParseChocolateyOutdated parses the output of `choco outdated --limit-output`,
+// which is a line of name|installed|latest|pinned for each outdated package.
+func ParseChocolateyOutdated(out string) []OutdatedPackage {
+	var packages []OutdatedPackage
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 3 {
+			continue
+		}
+		packages = append(packages, OutdatedPackage{Name: fields[0], InstalledVersion: fields[1], LatestVersion: fields[2]})
+	}
+	return packages
+}
+
+// ParseScoopStatus parses the output of `scoop status`. Current versions of
+// Scoop print a table:
+//
+//	Name     Installed Version Latest Version Missing Dependencies Info
+//	----     ----------------- -------------- -------------------- ----
+//	circleci 0.1.0             0.2.0
+//
+// while older ones print a line such as `circleci: 0.1.0 -> 0.2.0`.
+func ParseScoopStatus(out string) []OutdatedPackage {
+	var packages []OutdatedPackage
+	inTable := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			inTable = false
+		case strings.HasPrefix(fields[0], "----"):
+			inTable = true
+		case inTable && len(fields) >= 3:
+			packages = append(packages, OutdatedPackage{Name: fields[0], InstalledVersion: fields[1], LatestVersion: fields[2]})
+		case len(fields) >= 4 && strings.HasSuffix(fields[0], ":") && fields[2] == "->":
+			packages = append(packages, OutdatedPackage{
+				Name:             strings.TrimSuffix(fields[0], ":"),
+				InstalledVersion: fields[1],
+				LatestVersion:    fields[3],
+			})
+		}
+	}
+	return packages
+}
+
+func findOutdated(packages []OutdatedPackage, name string) *OutdatedPackage {
+	for i, p := range packages {
+		if strings.EqualFold(p.Name, name) {
+			return &packages[i]
+		}
+	}
+	return nil
+}
+
+func checkFromChocolatey(check *Options) error {
+	return checkFromOutdated(check, "circleci-cli", chocolateyPackageURL, ParseChocolateyOutdated,
+		"choco", "outdated", "--limit-output")
+}
+
+func checkFromScoop(check *Options) error {
+	return checkFromOutdated(check, "circleci", scoopAppURL, ParseScoopStatus,
+		"scoop", "status")
+}
+
+// checkFromOutdated asks a package manager which of its packages are outdated,
+// the same way checkFromHomebrew does, and records the latest version of the
+// named package if it is one of them.
+func checkFromOutdated(check *Options, name, url string, parse func(string) []OutdatedPackage, command string, args ...string) error {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return errors.Wrapf(err, "Expected to find `%s` in your $PATH but wasn't able to find it", command)
+	}
+
+	commandLine := strings.Join(append([]string{command}, args...), " ")
+	out, err := exec.Command(path, args...).Output() // #nosec
+	if err != nil {
+		return errors.Wrapf(err, "failed to check for updates. `%s` returned an error", commandLine)
+	}
+
+	o := findOutdated(parse(string(out)), name)
+	if o == nil {
+		return nil
+	}
+
+	if o.InstalledVersion != "" {
+		current, err := check.VersionScheme.Parse(o.InstalledVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse installed version %q from `%s`", o.InstalledVersion, commandLine)
+		}
+		check.Current = current
+	}
+
+	latest, err := check.VersionScheme.Parse(o.LatestVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse latest version %q from `%s`", o.LatestVersion, commandLine)
+	}
+	check.Latest = &selfupdate.Release{
+		Version: latest,
+		Name:    o.LatestVersion,
+		URL:     url,
+	}
+	check.Found = true
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chocolatey and Scoop", func() {
+	It("Should parse choco outdated", func() {
+		packages := update.ParseChocolateyOutdated("git|2.30.0|2.31.0|false\r\ncircleci-cli|0.1.0|0.2.0|false\r\n")
+		Expect(packages).To(Equal([]update.OutdatedPackage{
+			{Name: "git", InstalledVersion: "2.30.0", LatestVersion: "2.31.0"},
+			{Name: "circleci-cli", InstalledVersion: "0.1.0", LatestVersion: "0.2.0"},
+		}))
+	})
+
+	It("Should parse the table printed by scoop status", func() {
+		packages := update.ParseScoopStatus(`Scoop is up to date.
+
+Name     Installed Version Latest Version Missing Dependencies Info
+----     ----------------- -------------- -------------------- ----
+circleci 0.1.0             0.2.0
+`)
+		Expect(packages).To(Equal([]update.OutdatedPackage{
+			{Name: "circleci", InstalledVersion: "0.1.0", LatestVersion: "0.2.0"},
+		}))
+	})
+
+	It("Should parse the lines printed by older versions of scoop status", func() {
+		packages := update.ParseScoopStatus("Updates are available for:\n    circleci: 0.1.0 -> 0.2.0\n")
+		Expect(packages).To(ContainElement(update.OutdatedPackage{Name: "circleci", InstalledVersion: "0.1.0", LatestVersion: "0.2.0"}))
+	})
+
+	Describe("Checking for updates", func() {
+		var bin, path string
+
+		// fakeCommand puts a script on the PATH that prints out.
+		fakeCommand := func(name, out string) {
+			script := "#!/bin/sh\ncat <<'EOF'\n" + out + "EOF\n"
+			Expect(ioutil.WriteFile(filepath.Join(bin, name), []byte(script), 0700)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("the fake package managers are shell scripts")
+			}
+
+			var err error
+			bin, err = ioutil.TempDir("", "circleci-cli-bin")
+			Expect(err).ToNot(HaveOccurred())
+
+			path = os.Getenv("PATH")
+			Expect(os.Setenv("PATH", bin+string(os.PathListSeparator)+path)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			if runtime.GOOS == "windows" {
+				return
+			}
+			Expect(os.Setenv("PATH", path)).To(Succeed())
+			os.RemoveAll(bin)
+		})
+
+		It("Should find an outdated chocolatey package", func() {
+			fakeCommand("choco", "circleci-cli|0.1.0|0.2.0|false\n")
+
+			check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "chocolatey")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(check.Found).To(BeTrue())
+			Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+			Expect(update.IsLatestVersion(check)).To(BeFalse())
+			Expect(update.HowToUpdate(check)).To(Equal("You can update with `choco upgrade circleci-cli`"))
+
+			_, err = update.InstallLatestWithResult(check)
+			Expect(err).To(MatchError("failed to install update: not supported for chocolatey installs"))
+		})
+
+		It("Should find an outdated scoop app", func() {
+			fakeCommand("scoop", "Name     Installed Version Latest Version\n----     ----------------- --------------\ncircleci 0.1.0             0.2.0\n")
+
+			check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "scoop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(check.Found).To(BeTrue())
+			Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+			Expect(update.HowToUpdate(check)).To(Equal("You can update with `scoop update circleci`"))
+		})
+
+		It("Should find nothing when the package isn't outdated", func() {
+			fakeCommand("scoop", "Scoop is up to date.\nEverything is ok!\n")
+
+			check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "scoop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(check.Found).To(BeFalse())
+		})
+
+		It("Should explain a missing package manager", func() {
+			Expect(os.Setenv("PATH", bin)).To(Succeed())
+			_, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "chocolatey")
+			Expect(err).To(MatchError(ContainSubstring("Expected to find `choco` in your $PATH")))
+		})
+	})
+})
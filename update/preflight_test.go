@@ -0,0 +1,93 @@
+package update_test
+
+import (
+	"net/http"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Preflight", func() {
+	var server *ghttp.Server
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	check := func(options ...update.CheckOption) (*update.Options, error) {
+		return update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release", options...)
+	}
+
+	It("Should report an unreachable GitHub API when releases can't be listed", func() {
+		closed := ghttp.NewServer()
+		url := closed.URL()
+		closed.Close()
+
+		_, err := update.CheckForUpdates(url, "CircleCI-Public/circleci-cli", "0.1.0", "release")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("cannot reach GitHub API at " + url + "/: "))
+	})
+
+	It("Should report a URL that isn't the root of a GitHub API when it lists no releases", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusNotFound, "Not Found"),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/"),
+				ghttp.RespondWith(http.StatusNotFound, "Not Found"),
+			),
+		)
+
+		_, err := check()
+		Expect(err).To(MatchError("cannot reach GitHub API at " + server.URL() +
+			"/: got 404 Not Found, for GitHub Enterprise the URL should end in /api/v3/"))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("Should not ask the root of the API when releases are listed", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+			ghttp.RespondWith(http.StatusOK, `[{"tag_name": "v0.2.0"}]`),
+		))
+
+		_, err := check()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("Should keep the listing error when the root of the API answers", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusForbidden, "rate limited"),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/"),
+				ghttp.RespondWith(http.StatusForbidden, "rate limited"),
+			),
+		)
+
+		_, err := check()
+		Expect(err).To(MatchError(ContainSubstring("403 Forbidden")))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("Should be skipped when asked", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+			ghttp.RespondWith(http.StatusOK, `[{"tag_name": "v0.2.0"}]`),
+		))
+
+		_, err := check(update.WithoutPreflight())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+})
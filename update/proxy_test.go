@@ -0,0 +1,48 @@
+package update_test
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Checking for updates through a proxy", func() {
+	var proxy *ghttp.Server
+
+	BeforeEach(func() {
+		proxy = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		proxy.Close()
+	})
+
+	It("Should send GitHub API requests through the proxy", func() {
+		proxy.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, `[]`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/"),
+				func(_ http.ResponseWriter, r *http.Request) {
+					Expect(r.Host).To(Equal("github.example.com"))
+				},
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			),
+		)
+
+		proxyURL, err := url.Parse(proxy.URL())
+		Expect(err).ToNot(HaveOccurred())
+
+		check, err := update.CheckForUpdates("http://github.example.com/", "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithProxy(http.ProxyURL(proxyURL)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Found).To(BeFalse())
+		Expect(proxy.ReceivedRequests()).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,62 @@
+package update
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+)
+
+// releasesCache is the last list of releases fetched from GitHub, saved with
+// the ETag of its first page so the next check can ask whether it changed.
+// A 304 Not Modified answer doesn't count against the rate limit.
+type releasesCache struct {
+	URL      string    `json:"url"`
+	ETag     string    `json:"etag"`
+	Releases []Release `json:"releases"`
+	// Partial is set when later pages of releases weren't fetched, as they
+	// only held releases older than the one being looked for.
+	Partial bool `json:"partial,omitempty"`
+}
+
+func defaultReleaseCacheFile() string {
+	return filepath.Join(settings.SettingsPath(), "update-releases.json")
+}
+
+// loadReleasesCache returns the releases cached in path for url, or nil if
+// there are none. A cache that can't be read is ignored, as it only saves a
+// request.
+func loadReleasesCache(path, url string) *releasesCache {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil
+	}
+
+	var cache releasesCache
+	if err = json.Unmarshal(data, &cache); err != nil || cache.URL != url || cache.ETag == "" {
+		return nil
+	}
+	return &cache
+}
+
+// save writes the cache to path, ignoring failures for the same reason.
+func (c *releasesCache) save(path string) {
+	if path == "" {
+		return
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(path, data, 0600)
+}
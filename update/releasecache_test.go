@@ -0,0 +1,116 @@
+package update_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Caching releases", func() {
+	var (
+		server   *ghttp.Server
+		cacheDir string
+		releases string
+	)
+
+	check := func() *update.Options {
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithoutPreflight(), update.WithReleaseCache(filepath.Join(cacheDir, "releases.json")))
+		Expect(err).ToNot(HaveOccurred())
+		return opts
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		releases = fmt.Sprintf(`[{"tag_name": "v0.2.0", "assets": [{"id": 1, "name": "circleci-cli_%s_%s"}]}]`, runtime.GOOS, runtime.GOARCH)
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("Should reuse the cached releases when GitHub says they haven't changed", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				func(_ http.ResponseWriter, r *http.Request) {
+					Expect(r.Header.Get("If-None-Match")).To(BeEmpty())
+				},
+				ghttp.RespondWith(http.StatusOK, releases, http.Header{"ETag": []string{`"v1"`}}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.VerifyHeaderKV("If-None-Match", `"v1"`),
+				ghttp.RespondWith(http.StatusNotModified, nil),
+			),
+		)
+
+		Expect(check().Latest.Version.String()).To(Equal("0.2.0"))
+
+		opts := check()
+		Expect(opts.Found).To(BeTrue())
+		Expect(opts.Latest.Version.String()).To(Equal("0.2.0"))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("Should replace the cache when the releases have changed", func() {
+		newer := fmt.Sprintf(`[{"tag_name": "v0.3.0", "assets": [{"id": 2, "name": "circleci-cli_%s_%s"}]}]`, runtime.GOOS, runtime.GOARCH)
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, releases, http.Header{"ETag": []string{`"v1"`}}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("If-None-Match", `"v1"`),
+				ghttp.RespondWith(http.StatusOK, newer, http.Header{"ETag": []string{`"v2"`}}),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("If-None-Match", `"v2"`),
+				ghttp.RespondWith(http.StatusNotModified, nil),
+			),
+		)
+
+		Expect(check().Latest.Version.String()).To(Equal("0.2.0"))
+		Expect(check().Latest.Version.String()).To(Equal("0.3.0"))
+		Expect(check().Latest.Version.String()).To(Equal("0.3.0"))
+	})
+
+	It("Should not send a cached ETag to another GitHub API", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, releases, http.Header{"ETag": []string{`"v1"`}}),
+			),
+		)
+		check()
+
+		other := ghttp.NewServer()
+		defer other.Close()
+		other.AppendHandlers(
+			ghttp.CombineHandlers(
+				func(_ http.ResponseWriter, r *http.Request) {
+					Expect(r.Header.Get("If-None-Match")).To(BeEmpty())
+				},
+				ghttp.RespondWith(http.StatusOK, `[]`),
+			),
+		)
+
+		opts, err := update.CheckForUpdates(other.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release",
+			update.WithoutPreflight(), update.WithReleaseCache(filepath.Join(cacheDir, "releases.json")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.Found).To(BeFalse())
+	})
+})
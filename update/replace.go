@@ -0,0 +1,101 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// oldBinaryPath is where a binary being replaced is moved aside to. Windows
+// can't delete or overwrite a running executable, but it can rename it, so on
+// Windows the previous binary stays there until the next run removes it.
+func oldBinaryPath(path string) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.old", filepath.Base(path)))
+}
+
+// swapBinary copies src next to dst and renames it into place, so dst is
+// never left partially written.
+func swapBinary(src, dst string) error {
+	in, err := os.Open(src) // #nosec
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return replaceBinary(in, dst)
+}
+
+// replaceBinary writes the binary read from r next to dst and renames it into
+// place. The binary at dst may be the one running, including on Windows.
+func replaceBinary(r io.Reader, dst string) error {
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(dst); err == nil {
+		mode = info.Mode()
+	}
+
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".%s.new", filepath.Base(dst)))
+	if err := writeBinary(r, tmp, mode); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Rename(tmp, dst); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+
+	// Windows can't rename over a running executable, but can move it aside.
+	old := oldBinaryPath(dst)
+	_ = os.Remove(old)
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Rename(old, dst)
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	// This fails while the old binary is still running, in which case
+	// RemoveOldBinary deletes it on the next run.
+	_ = os.Remove(old)
+	return nil
+}
+
+func writeBinary(r io.Reader, path string, mode os.FileMode) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// RemoveOldBinary deletes the binary an update on Windows moved aside while
+// it was still running. It does nothing if there is none, or on other
+// platforms, where the old binary is never left behind.
+func RemoveOldBinary() {
+	if runtime.GOOS != "windows" {
+		return
+	}
+
+	path, err := ExecutablePath()
+	if err != nil {
+		return
+	}
+	_ = os.Remove(oldBinaryPath(path))
+}
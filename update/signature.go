@@ -0,0 +1,78 @@
+package update
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+
+	// Embeds the release signing key.
+	_ "embed"
+)
+
+// signingKey is the public half of the key releases are signed with by
+// `cosign sign-blob` when they are published, overridden in tests. Release
+// builds write cosign.pub from the signing key itself, so they always match.
+//
+//go:embed cosign.pub
+var signingKey []byte
+
+// signedSince is the first release signed when it was published. Older
+// releases have no signature to check, so they are installed without one,
+// unless that would downgrade the CLI.
+var signedSince = semver.MustParse("0.1.30000")
+
+// signatureSuffix is appended to the name of a release asset to find the
+// asset holding its signature, as goreleaser names them.
+const signatureSuffix = ".sig"
+
+// signatureAssets maps the ID of each asset in releases that has a signature
+// to the asset holding it.
+func signatureAssets(releases []Release) map[int64]ReleaseAsset {
+	signatures := map[int64]ReleaseAsset{}
+	for _, rel := range releases {
+		byName := map[string]ReleaseAsset{}
+		for _, asset := range rel.Assets {
+			byName[asset.Name] = asset
+		}
+		for _, asset := range rel.Assets {
+			if sig, ok := byName[asset.Name+signatureSuffix]; ok {
+				signatures[asset.ID] = sig
+			}
+		}
+	}
+	return signatures
+}
+
+// verifySignature checks that sig, a base64 encoded ECDSA signature as written
+// by cosign, was made over blob by the private half of the PEM encoded key.
+func verifySignature(key, blob, sig []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return fmt.Errorf("invalid signing key: no PEM data found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid signing key: %w", err)
+	}
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("invalid signing key: expected an ECDSA key, got %T", pub)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+
+	digest := sha256.Sum256(blob)
+	if !ecdsa.VerifyASN1(ecKey, digest[:], raw) {
+		return fmt.Errorf("signature doesn't match")
+	}
+	return nil
+}
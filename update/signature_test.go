@@ -0,0 +1,166 @@
+package update_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Verifying release signatures", func() {
+	const binary = "circleci 0.2.0 for real"
+
+	var (
+		server   *ghttp.Server
+		cacheDir string
+		key      *ecdsa.PrivateKey
+		restore  func()
+	)
+
+	// sign returns the signature of blob as written by `cosign sign-blob`.
+	sign := func(blob string) string {
+		digest := sha256.Sum256([]byte(blob))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		Expect(err).ToNot(HaveOccurred())
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	// serve publishes a release of binary and its checksums, with signature
+	// as its signature unless it's empty.
+	serve := func(signature string) {
+		name := fmt.Sprintf("circleci-cli_%s_%s", runtime.GOOS, runtime.GOARCH)
+		assets := fmt.Sprintf(`{"id": 1, "name": "%s", "size": %d}, {"id": 3, "name": "circleci-cli_0.2.0_checksums.txt"}`, name, len(binary))
+		if signature != "" {
+			assets += fmt.Sprintf(`, {"id": 2, "name": "%s.sig"}`, name)
+		}
+		sum := sha256.Sum256([]byte(binary))
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[{"tag_name": "v0.2.0", "assets": [%s]}]`, assets)),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
+				ghttp.RespondWith(http.StatusOK, binary),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/3"),
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), name)),
+			),
+		)
+		if signature != "" {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/2"),
+				ghttp.RespondWith(http.StatusOK, signature),
+			))
+		}
+	}
+
+	fetch := func(options ...update.CheckOption) (*update.FetchedRelease, error) {
+		options = append(options, update.WithoutPreflight())
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release", options...)
+		Expect(err).ToNot(HaveOccurred())
+		return update.FetchLatest(opts, cacheDir)
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "circleci-cli-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+		restore = update.SetSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		restore()
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("Should install a release with a valid signature", func() {
+		serve(sign(binary))
+
+		fetched, err := fetch()
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(fetched.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(binary))
+	})
+
+	It("Should refuse a release whose signature doesn't match", func() {
+		serve(sign("something else"))
+
+		_, err := fetch()
+		Expect(err).To(MatchError("failed to fetch update: failed to verify release 0.2.0, refusing to install it: signature doesn't match"))
+	})
+
+	It("Should refuse a release that isn't signed", func() {
+		defer update.SetSignedSince("0.2.0")()
+		serve("")
+
+		_, err := fetch()
+		Expect(err).To(MatchError("failed to fetch update: release 0.2.0 is not signed, refusing to install it unverified"))
+	})
+
+	It("Should install an unsigned release published before releases were signed", func() {
+		defer update.SetSignedSince("0.3.0")()
+		serve("")
+
+		fetched, err := fetch()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Version.String()).To(Equal("0.2.0"))
+	})
+
+	It("Should refuse to downgrade to an unsigned release published before releases were signed", func() {
+		defer update.SetSignedSince("0.3.0")()
+		serve("")
+
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.2.5", "release",
+			update.WithoutPreflight(), update.WithTargetVersion("0.2.0"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = update.FetchLatest(opts, cacheDir)
+		Expect(err).To(MatchError("failed to fetch update: release 0.2.0 is not signed and is older than 0.2.5, refusing to install it unverified"))
+	})
+
+	It("Should downgrade to an unsigned release when verification is skipped", func() {
+		defer update.SetSignedSince("0.3.0")()
+		serve("")
+
+		opts, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.2.5", "release",
+			update.WithoutPreflight(), update.WithTargetVersion("0.2.0"), update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		fetched, err := update.FetchLatest(opts, cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Version.String()).To(Equal("0.2.0"))
+	})
+
+	It("Should install an unsigned release when verification is skipped", func() {
+		defer update.SetSignedSince("0.2.0")()
+		serve("")
+
+		fetched, err := fetch(update.WithoutSignatureVerification())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Version.String()).To(Equal("0.2.0"))
+	})
+})
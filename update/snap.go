@@ -0,0 +1,125 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+// snapStoreURL is the root of the snap store API, overridden in tests.
+var snapStoreURL = "https://api.snapcraft.io/"
+
+// snapURL describes releases found through the snap store, which are
+// installed with `snap refresh` rather than downloaded by the updater.
+const snapURL = "https://snapcraft.io/circleci"
+
+// SnapInfo wraps the response of the snap store's info endpoint. We're
+// specifically looking for the version released to each channel:
+//
+//	{
+//	  "channel-map": [
+//	    {
+//	      "channel": {"architecture": "amd64", "risk": "stable", "track": "latest", "released-at": "..."},
+//	      "revision": 1234,
+//	      "version": "0.1.5"
+//	    }
+//	  ]
+//	}
+type SnapInfo struct {
+	ChannelMap []SnapRelease `json:"channel-map"`
+}
+
+// SnapRelease is the revision of a snap released to a channel.
+type SnapRelease struct {
+	Channel struct {
+		Architecture string    `json:"architecture"`
+		Risk         string    `json:"risk"`
+		Track        string    `json:"track"`
+		ReleasedAt   time.Time `json:"released-at"`
+	} `json:"channel"`
+	Revision int    `json:"revision"`
+	Version  string `json:"version"`
+}
+
+// Release returns the revision released to the latest track at the given
+// risk for the architecture, or nil if there isn't one.
+func (s SnapInfo) Release(architecture, risk string) *SnapRelease {
+	for i, r := range s.ChannelMap {
+		if r.Channel.Architecture == architecture && r.Channel.Risk == risk && r.Channel.Track == "latest" {
+			return &s.ChannelMap[i]
+		}
+	}
+	return nil
+}
+
+// snapArchitecture names the running architecture the way the snap store does.
+func snapArchitecture() string {
+	switch runtime.GOARCH {
+	case "386":
+		return "i386"
+	case "arm":
+		return "armhf"
+	}
+	return runtime.GOARCH
+}
+
+func checkFromSnap(check *Options) error {
+	url := snapStoreURL + "v2/snaps/info/circleci"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Snap-Device-Series", "16")
+
+	client := &http.Client{Timeout: 30 * time.Second, Transport: check.transport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to query the snap store for updates")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query the snap store for updates: GET %s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var info SnapInfo
+	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return errors.Wrap(err, "failed to parse the snap store response")
+	}
+
+	risk := "stable"
+	switch check.Channel {
+	case ChannelBeta:
+		risk = "beta"
+	case ChannelNightly:
+		risk = "edge"
+	}
+	rel := info.Release(snapArchitecture(), risk)
+	if rel == nil {
+		return nil
+	}
+
+	latest, err := check.VersionScheme.Parse(rel.Version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse snap version %q", rel.Version)
+	}
+
+	// Like homebrew releases, snap revisions can't be downloaded by the updater;
+	// `snap refresh` installs them instead.
+	check.Latest = &selfupdate.Release{
+		Version: latest,
+		Name:    fmt.Sprintf("%s (revision %d)", rel.Version, rel.Revision),
+		URL:     snapURL,
+	}
+	if !rel.Channel.ReleasedAt.IsZero() {
+		check.Latest.PublishedAt = &rel.Channel.ReleasedAt
+	}
+	check.Found = true
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package update_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Snap", func() {
+	var (
+		server  *ghttp.Server
+		restore func()
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		restore = update.SetSnapStoreURL(server.URL() + "/")
+	})
+
+	AfterEach(func() {
+		restore()
+		server.Close()
+	})
+
+	respond := func(status int, body string) {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/snaps/info/circleci"),
+			ghttp.VerifyHeaderKV("Snap-Device-Series", "16"),
+			ghttp.RespondWith(status, body),
+		))
+	}
+
+	channelMap := fmt.Sprintf(`{"channel-map": [
+		{"channel": {"architecture": "%[1]s", "risk": "stable", "track": "latest", "released-at": "2021-06-01T12:00:00Z"}, "revision": 42, "version": "0.2.0"},
+		{"channel": {"architecture": "%[1]s", "risk": "beta", "track": "latest"}, "revision": 43, "version": "0.3.0-beta.1"},
+		{"channel": {"architecture": "%[1]s", "risk": "edge", "track": "latest"}, "revision": 45, "version": "0.3.0-nightly.20210601"},
+		{"channel": {"architecture": "%[1]s", "risk": "stable", "track": "legacy"}, "revision": 7, "version": "0.0.9"},
+		{"channel": {"architecture": "s390x-elsewhere", "risk": "stable", "track": "latest"}, "revision": 44, "version": "9.9.9"}
+	]}`, update.SnapArchitecture())
+
+	It("Should find the latest stable revision and explain how to refresh it", func() {
+		respond(http.StatusOK, channelMap)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Found).To(BeTrue())
+		Expect(check.Latest.Version.String()).To(Equal("0.2.0"))
+		Expect(check.Latest.Name).To(Equal("0.2.0 (revision 42)"))
+		Expect(update.IsLatestVersion(check)).To(BeFalse())
+		Expect(update.HowToUpdate(check)).To(Equal("You can update with `sudo snap refresh circleci`"))
+	})
+
+	It("Should follow the beta channel", func() {
+		respond(http.StatusOK, channelMap)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap",
+			update.WithChannel(update.ChannelBeta))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Latest.Version.String()).To(Equal("0.3.0-beta.1"))
+	})
+
+	It("Should follow the edge channel for nightly builds", func() {
+		respond(http.StatusOK, channelMap)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap",
+			update.WithChannel(update.ChannelNightly))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Latest.Version.String()).To(Equal("0.3.0-nightly.20210601"))
+	})
+
+	It("Should be up to date when running the latest revision", func() {
+		respond(http.StatusOK, channelMap)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.2.0", "snap")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(update.IsLatestVersion(check)).To(BeTrue())
+	})
+
+	It("Should find nothing when no revision is released for this architecture", func() {
+		respond(http.StatusOK, `{"channel-map": [
+			{"channel": {"architecture": "s390x-elsewhere", "risk": "stable", "track": "latest"}, "revision": 44, "version": "9.9.9"}
+		]}`)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Found).To(BeFalse())
+	})
+
+	It("Should report a failing snap store", func() {
+		respond(http.StatusServiceUnavailable, "")
+
+		_, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap")
+		Expect(err).To(MatchError(ContainSubstring("failed to query the snap store for updates: GET ")))
+		Expect(err).To(MatchError(ContainSubstring("503 Service Unavailable")))
+	})
+
+	It("Should refuse to install the release itself", func() {
+		respond(http.StatusOK, channelMap)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "snap")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = update.InstallLatestWithResult(check)
+		Expect(err).To(MatchError("failed to install update: not supported for snap installs"))
+	})
+})
@@ -0,0 +1,61 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Target versions", func() {
+	prerelease := platformRelease("v1.5.0-rc1")
+	prerelease.Prerelease = true
+
+	releases := []update.Release{
+		platformRelease("v0.9.0"),
+		platformRelease("v1.0.0"),
+		prerelease,
+		platformRelease("v2.0.0"),
+	}
+
+	check := func(current, target string) (*update.Options, error) {
+		return update.CheckForUpdates("", "CircleCI-Public/circleci-cli", current, "release",
+			update.WithUpdater(&fakeUpdater{releases: releases}), update.WithTargetVersion(target))
+	}
+
+	It("Should pick exactly the target version", func() {
+		opts, err := check("0.1.0", "1.0.0")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(opts.Latest.Version.String()).To(Equal("1.0.0"))
+		Expect(update.IsLatestVersion(opts)).To(BeFalse())
+		Expect(update.ReportVersion(opts)).To(Equal("You are running 0.1.0\nRelease 1.0.0 is available"))
+	})
+
+	It("Should downgrade to an older target version", func() {
+		opts, err := check("2.0.0", "v0.9.0")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(opts.Latest.Version.String()).To(Equal("0.9.0"))
+		Expect(update.IsLatestVersion(opts)).To(BeFalse())
+	})
+
+	It("Should pick a prerelease target version on the stable channel", func() {
+		opts, err := check("1.0.0", "1.5.0-rc1")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(opts.Latest.Version.String()).To(Equal("1.5.0-rc1"))
+	})
+
+	It("Should be up to date when already running the target version", func() {
+		opts, err := check("1.0.0", "1.0.0")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(update.IsLatestVersion(opts)).To(BeTrue())
+	})
+
+	It("Should error when the target version wasn't released", func() {
+		_, err := check("1.0.0", "1.2.3")
+		Expect(err).To(MatchError("no release of version 1.2.3 found for this platform"))
+	})
+
+	It("Should reject an invalid target version", func() {
+		_, err := check("1.0.0", "latest")
+		Expect(err).To(MatchError(ContainSubstring(`Invalid version "latest"`)))
+	})
+})
@@ -0,0 +1,169 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	gitconfig "github.com/tcnksm/go-gitconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// tokenCommandTimeout bounds each command asked for a token, so a credential
+// helper waiting on something never holds up an update check.
+const tokenCommandTimeout = 5 * time.Second
+
+// tokenSources are tried in order for a token for the GitHub host, the first
+// token found being used.
+var tokenSources = []func(host string) string{
+	environmentToken,
+	gitconfigToken,
+	ghHostsToken,
+}
+
+// helperTokenSources run the gh CLI and git's credential helper, which can be
+// slow or even show a prompt, so they are only tried after tokenSources by
+// update commands the user ran, never by the background check.
+var helperTokenSources = []func(host string) string{
+	ghAuthToken,
+	gitCredentialToken,
+}
+
+// githubToken finds a token for the GitHub behind githubAPI, so release
+// queries are authenticated whenever the user has logged in to GitHub in one
+// of the usual ways and rate limits rarely get in the way. The credential
+// helpers are only asked when helpers is set.
+func githubToken(githubAPI string, helpers bool) string {
+	host := githubHost(githubAPI)
+	sources := tokenSources
+	if helpers {
+		sources = append(sources[:len(sources):len(sources)], helperTokenSources...)
+	}
+	for _, source := range sources {
+		if token := source(host); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// githubHost returns the host users log in to for githubAPI, which for
+// github.com isn't the host its API is served from.
+func githubHost(githubAPI string) string {
+	u, err := url.Parse(githubAPI)
+	if err != nil || u.Hostname() == "" || u.Hostname() == "api.github.com" {
+		return "github.com"
+	}
+	return u.Hostname()
+}
+
+func environmentToken(host string) string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if host == "github.com" {
+		return os.Getenv("GH_TOKEN")
+	}
+	return os.Getenv("GH_ENTERPRISE_TOKEN")
+}
+
+func gitconfigToken(_ string) string {
+	token, _ := gitconfig.GithubToken()
+	return token
+}
+
+// ghConfigDir is where the gh CLI keeps its configuration.
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+// ghHostsToken reads the token the gh CLI saved in its hosts.yml, which is
+// where it keeps tokens when it can't use the OS keychain.
+func ghHostsToken(host string) string {
+	dir := ghConfigDir()
+	if dir == "" {
+		return ""
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml")) // #nosec
+	if err != nil {
+		return ""
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err = yaml.Unmarshal(data, &hosts); err != nil {
+		return ""
+	}
+	return hosts[host].OAuthToken
+}
+
+// ghAuthToken asks the gh CLI for its token, which finds tokens it saved in
+// the OS keychain.
+func ghAuthToken(host string) string {
+	out, err := runTokenCommand(nil, "gh", "auth", "token", "--hostname", host)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitCredentialToken asks git's credential helper for the password it saved
+// for the host, which is a token for GitHub. The helpers for macOS, Windows and
+// libsecret keep them in the OS keychain. Git is stopped from prompting for
+// one, as nobody would be there to answer.
+func gitCredentialToken(host string) string {
+	input := strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	out, err := runTokenCommand(input, "git", "credential", "fill")
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password := strings.TrimPrefix(scanner.Text(), "password="); password != scanner.Text() {
+			return password
+		}
+	}
+	return ""
+}
+
+func runTokenCommand(stdin io.Reader, command string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, args...) // #nosec
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "GCM_INTERACTIVE=never", "GH_PROMPT_DISABLED=1")
+	cmd.Stdin = stdin
+	return cmd.Output()
+}
@@ -0,0 +1,113 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Finding a GitHub token", func() {
+	var (
+		server *ghttp.Server
+		dir    string
+		env    map[string]string
+	)
+
+	// expectToken checks that release queries are authenticated with token.
+	expectToken := func(token string, options ...update.CheckOption) {
+		handlers := []http.HandlerFunc{ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases")}
+		if token != "" {
+			handlers = append(handlers, ghttp.VerifyHeaderKV("Authorization", "token "+token))
+		} else {
+			handlers = append(handlers, func(_ http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Authorization")).To(BeEmpty())
+			})
+		}
+		server.AppendHandlers(ghttp.CombineHandlers(append(handlers, ghttp.RespondWith(http.StatusOK, `[]`))...))
+
+		options = append(options, update.WithoutPreflight())
+		_, err := update.CheckForUpdates(server.URL(), "CircleCI-Public/circleci-cli", "0.1.0", "release", options...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	}
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("the fake git is a shell script")
+		}
+
+		var err error
+		dir, err = ioutil.TempDir("", "circleci-cli-token")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "bin"), 0700)).To(Succeed())
+
+		// Only the fake git is on the PATH, so no real gh or credential helper
+		// is asked for a token.
+		env = map[string]string{}
+		for key, value := range map[string]string{
+			"PATH":                filepath.Join(dir, "bin"),
+			"HOME":                dir,
+			"GH_CONFIG_DIR":       filepath.Join(dir, "gh"),
+			"GITHUB_TOKEN":        "",
+			"GH_TOKEN":            "",
+			"GH_ENTERPRISE_TOKEN": "",
+		} {
+			env[key] = os.Getenv(key)
+			Expect(os.Setenv(key, value)).To(Succeed())
+		}
+
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		for key, value := range env {
+			Expect(os.Setenv(key, value)).To(Succeed())
+		}
+		os.RemoveAll(dir)
+	})
+
+	writeGitCredential := func(password string) {
+		script := "#!/bin/sh\nif [ \"$1\" = credential ]; then\n  echo protocol=https\n  echo password=" + password + "\n  exit 0\nfi\nexit 1\n"
+		Expect(ioutil.WriteFile(filepath.Join(dir, "bin", "git"), []byte(script), 0700)).To(Succeed())
+	}
+
+	writeGhHosts := func(hosts string) {
+		Expect(os.MkdirAll(filepath.Join(dir, "gh"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "gh", "hosts.yml"), []byte(hosts), 0600)).To(Succeed())
+	}
+
+	It("Should use the token saved by the gh CLI for the GitHub host", func() {
+		writeGitCredential("from-the-keychain")
+		writeGhHosts("github.com:\n    oauth_token: for-github.com\n127.0.0.1:\n    oauth_token: from-gh\n    user: octocat\n")
+
+		expectToken("from-gh")
+	})
+
+	It("Should use the password saved by git's credential helper", func() {
+		writeGitCredential("from-the-keychain")
+
+		expectToken("from-the-keychain", update.WithCredentialHelpers())
+	})
+
+	It("Should not ask git's credential helper unless asked to", func() {
+		writeGitCredential("from-the-keychain")
+
+		expectToken("")
+	})
+
+	It("Should prefer GITHUB_TOKEN", func() {
+		writeGitCredential("from-the-keychain")
+		writeGhHosts("127.0.0.1:\n    oauth_token: from-gh\n")
+		Expect(os.Setenv("GITHUB_TOKEN", "from-the-environment")).To(Succeed())
+
+		expectToken("from-the-environment")
+	})
+})
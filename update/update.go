@@ -1,10 +1,19 @@
 package update
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/settings"
@@ -13,35 +22,221 @@ import (
 	"github.com/rhysd/go-github-selfupdate/selfupdate"
 )
 
-// hoursBeforeCheck is used to configure the delay between auto-update checks
+// hoursBeforeCheck is the default delay between auto-update checks, used
+// unless the update check settings set another interval.
 var hoursBeforeCheck = 28
 
-// ShouldCheckForUpdates tell us if the last update check was more than a day ago
+// nowFunc returns the current time. Time-based decisions in this package use
+// it rather than time.Now, so tests can pin the clock.
+var nowFunc = time.Now
+
+// ShouldCheckForUpdates tell us if the last update check was longer ago than the check interval
 func ShouldCheckForUpdates(upd *settings.UpdateCheck) bool {
-	diff := time.Since(upd.LastUpdateCheck)
-	return diff.Hours() >= float64(hoursBeforeCheck)
+	diff := nowFunc().Sub(upd.LastUpdateCheck)
+	return diff >= CheckInterval(upd)
+}
+
+// CheckInterval is how long the CLI waits between automatic update checks,
+// the interval set in upd or 28 hours by default.
+func CheckInterval(upd *settings.UpdateCheck) time.Duration {
+	if upd.Interval > 0 {
+		return upd.Interval
+	}
+	return time.Duration(hoursBeforeCheck) * time.Hour
+}
+
+// CheckStatus describes when the CLI last checked for updates, and when it will next.
+type CheckStatus struct {
+	// LastChecked is nil if the CLI has never checked.
+	LastChecked *time.Time
+	// NextCheck is when the next command will check. It is now if a check is already due.
+	NextCheck time.Time
+	Interval  time.Duration
+}
+
+// UpdateCheckStatus works out when the next automatic check is due, given the
+// last one recorded in upd and the interval between checks.
+func UpdateCheckStatus(upd *settings.UpdateCheck, interval time.Duration) CheckStatus {
+	now := nowFunc()
+	status := CheckStatus{NextCheck: now, Interval: interval}
+	if upd.LastUpdateCheck.IsZero() {
+		return status
+	}
+
+	last := upd.LastUpdateCheck
+	status.LastChecked = &last
+	if next := last.Add(interval); next.After(now) {
+		status.NextCheck = next
+	}
+	return status
+}
+
+// CheckOption configures optional behaviour of CheckForUpdates.
+type CheckOption func(*Options)
+
+// WithVersionScheme sets the scheme used to parse and compare versions, the default is semver.
+func WithVersionScheme(scheme VersionScheme) CheckOption {
+	return func(o *Options) {
+		o.VersionScheme = scheme
+	}
+}
+
+// WithVersionConstraint limits updates to releases within a semver range, such as `>=1.0.0 <2.0.0`.
+func WithVersionConstraint(constraint string) CheckOption {
+	return func(o *Options) {
+		o.VersionConstraint = constraint
+	}
+}
+
+// WithTargetVersion installs exactly the given version, such as 0.1.29936,
+// instead of the latest release, even if it is older than the running one.
+func WithTargetVersion(version string) CheckOption {
+	return func(o *Options) {
+		o.TargetVersion = version
+	}
+}
+
+// Release channels that can be followed with WithChannel.
+const (
+	// ChannelStable follows published releases only, and is the default.
+	ChannelStable = "stable"
+	// ChannelBeta also follows releases marked as prereleases.
+	ChannelBeta = "beta"
+	// ChannelNightly also follows prereleases and nightly builds.
+	ChannelNightly = "nightly"
+)
+
+// ValidateChannel returns an error unless channel is one that can be followed.
+func ValidateChannel(channel string) error {
+	switch channel {
+	case ChannelStable, ChannelBeta, ChannelNightly:
+		return nil
+	}
+	return fmt.Errorf("unknown update channel %q, expected one of %s, %s, %s", channel, ChannelStable, ChannelBeta, ChannelNightly)
+}
+
+// WithChannel sets the release channel to follow, an empty channel follows stable releases.
+func WithChannel(channel string) CheckOption {
+	return func(o *Options) {
+		o.Channel = channel
+	}
+}
+
+// WithPrereleases also follows prereleases, such as release candidates, when
+// the channel would otherwise only follow stable releases.
+func WithPrereleases() CheckOption {
+	return func(o *Options) {
+		o.Prereleases = true
+	}
+}
+
+// WithUpstreamCheck makes package manager checks also consult GitHub, so a
+// release the package manager hasn't caught up with yet can be reported.
+func WithUpstreamCheck() CheckOption {
+	return func(o *Options) {
+		o.CheckUpstream = true
+	}
+}
+
+// WithLocation sets where absolute timestamps, such as when a release was
+// published, are shown. The default is local time.
+func WithLocation(loc *time.Location) CheckOption {
+	return func(o *Options) {
+		o.Location = loc
+	}
+}
+
+// WithoutPreflight skips checking that the GitHub API can be reached when no
+// releases could be listed, for tests serving only the endpoints they expect.
+func WithoutPreflight() CheckOption {
+	return func(o *Options) {
+		o.skipPreflight = true
+	}
+}
+
+// WithProxy sets how update checks and downloads find their proxy, such as
+// http.ProxyURL for an explicit proxy. The default is http.ProxyFromEnvironment,
+// which honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) CheckOption {
+	return func(o *Options) {
+		o.proxy = proxy
+	}
+}
+
+// WithReleaseMirror looks for and downloads releases from a mirror, such as
+// https://artifacts.example.com/circleci-cli, instead of GitHub. See
+// mirrorListing for what the mirror must serve.
+func WithReleaseMirror(mirrorURL string) CheckOption {
+	return func(o *Options) {
+		o.releaseMirror = mirrorURL
+	}
+}
+
+// WithReleaseCache caches the releases listed by GitHub in path instead of
+// update-releases.json in the settings directory.
+func WithReleaseCache(path string) CheckOption {
+	return func(o *Options) {
+		o.releaseCache = path
+	}
+}
+
+// WithoutSignatureVerification installs releases without checking they were
+// signed with the release signing key.
+func WithoutSignatureVerification() CheckOption {
+	return func(o *Options) {
+		o.skipVerify = true
+	}
+}
+
+// WithCredentialHelpers also asks the gh CLI and git's credential helper for
+// a GitHub token. They can be slow or prompt for a password, so this is meant
+// for update commands the user ran rather than checks made in the background.
+func WithCredentialHelpers() CheckOption {
+	return func(o *Options) {
+		o.credentialHelpers = true
+	}
+}
+
+// WithUpdater replaces the updater used to discover and install releases.
+func WithUpdater(updater Updater) CheckOption {
+	return func(o *Options) {
+		o.updater = updater
+	}
 }
 
 // CheckForUpdates will check for updates given the proper package manager
-func CheckForUpdates(githubAPI, slug, current, packageManager string) (*Options, error) {
+func CheckForUpdates(githubAPI, slug, current, packageManager string, options ...CheckOption) (*Options, error) {
 	var (
 		err   error
 		check *Options
 	)
 
-	currentVersion, err := semver.Parse(current)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse current version")
-	}
-
 	check = &Options{
-		Current:        currentVersion,
 		PackageManager: packageManager,
+		VersionScheme:  SemverScheme{},
 
 		githubAPI: githubAPI,
 		slug:      slug,
 	}
 
+	for _, option := range options {
+		option(check)
+	}
+
+	if check.Channel != "" {
+		if err = ValidateChannel(check.Channel); err != nil {
+			return nil, err
+		}
+	}
+	if check.Prereleases && (check.Channel == "" || check.Channel == ChannelStable) {
+		check.Channel = ChannelBeta
+	}
+
+	check.Current, err = check.VersionScheme.Parse(current)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse current version")
+	}
+
 	switch check.PackageManager {
 	case "release":
 		err = checkFromSource(check)
@@ -49,26 +244,143 @@ func CheckForUpdates(githubAPI, slug, current, packageManager string) (*Options,
 		err = checkFromSource(check)
 	case "homebrew":
 		err = checkFromHomebrew(check)
+		if err == nil && check.CheckUpstream {
+			checkUpstream(check)
+		}
+	case "snap":
+		err = checkFromSnap(check)
+		if err == nil && check.CheckUpstream {
+			checkUpstream(check)
+		}
+	case "chocolatey":
+		err = checkFromChocolatey(check)
+		if err == nil && check.CheckUpstream {
+			checkUpstream(check)
+		}
+	case "scoop":
+		err = checkFromScoop(check)
+		if err == nil && check.CheckUpstream {
+			checkUpstream(check)
+		}
+	case "apt", "yum", "nix":
+		// Distro packages can't be asked for their latest version the same
+		// way everywhere, so look for the release they will be updated to.
+		err = checkFromSource(check)
 	}
 
 	return check, err
 }
 
 func checkFromSource(check *Options) error {
-	updater, err := selfupdate.NewUpdater(selfupdate.Config{
-		EnterpriseBaseURL: check.githubAPI,
-	})
-	if err != nil {
-		return err
+	var github *githubUpdater
+	if check.updater == nil {
+		updater, err := check.defaultUpdater()
+		if err != nil {
+			return err
+		}
+		if !check.skipPreflight && !updater.mirror {
+			github = updater
+		}
+
+		check.updater = updater
 	}
 
-	check.updater = updater
+	err := latestRelease(check)
 
-	err = latestRelease(check)
+	// A wrong base URL only shows up as a failed or empty listing, so that is
+	// when the root of the API is asked what went wrong. Successful checks
+	// don't pay for the extra request.
+	if github != nil && (err != nil || len(check.releases) == 0) {
+		if perr := github.preflight(); perr != nil {
+			return perr
+		}
+	}
 
 	return err
 }
 
+// defaultUpdater returns the updater used when none was given with
+// WithUpdater: the release mirror if there is one, otherwise GitHub.
+func (o *Options) defaultUpdater() (*githubUpdater, error) {
+	var (
+		updater *githubUpdater
+		err     error
+	)
+	if o.releaseMirror != "" {
+		updater, err = newReleaseMirror(o.releaseMirror, o.transport())
+	} else {
+		updater, err = newGitHubUpdater(o.githubAPI, o.transport(), o.credentialHelpers)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.skipVerify {
+		updater.signingKey = nil
+	}
+	updater.current = o.Current
+	updater.cacheFile = o.releaseCacheFile()
+	updater.enough = o.listedEnough
+	return updater, nil
+}
+
+// listedEnough reports whether page goes back to the current version, or to
+// the target version if there is one. Releases are listed newest first, so
+// the pages after it only hold older releases, which are never installed.
+func (o *Options) listedEnough(page []Release) bool {
+	floor := o.Current
+	if o.TargetVersion != "" {
+		target, err := o.VersionScheme.Parse(o.TargetVersion)
+		if err != nil {
+			return false
+		}
+		floor = target
+	}
+
+	for _, rel := range page {
+		version, err := o.VersionScheme.Parse(rel.TagName)
+		if err == nil && o.VersionScheme.Compare(version, floor) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUpstream records the latest GitHub release if it is newer than anything
+// the package manager offers. It is informational only, so failing to reach
+// GitHub leaves the package manager's result untouched.
+func checkUpstream(check *Options) {
+	if check.updater == nil {
+		updater, err := check.defaultUpdater()
+		if err != nil {
+			return
+		}
+		check.updater = updater
+	}
+
+	allowed, err := check.versionRange()
+	if err != nil {
+		return
+	}
+	releases, err := check.updater.Releases(check.slug)
+	if err != nil {
+		return
+	}
+
+	upstream := selectLatest(check, releases, allowed)
+	if upstream == nil {
+		return
+	}
+
+	newest := check.Current
+	if check.Latest != nil && check.VersionScheme.Compare(check.Latest.Version, newest) > 0 {
+		newest = check.Latest.Version
+	}
+	if check.VersionScheme.Compare(upstream.Version, newest) > 0 {
+		check.Upstream = upstream
+	}
+}
+
 // Homebrew revisions get added to the version with an underscore.
 // So `1.2.3 revision 4` becomes `1.2.3_4`. This fails to parse as valid semver
 // version. We can work around this by replacing underscores with `-` to convert
@@ -87,49 +399,97 @@ func ParseHomebrewVersion(homebrewVesion string) (semver.Version, error) {
 	return version, nil
 }
 
+// homebrewFormulaURL describes releases found through homebrew, which have no
+// GitHub release page of their own.
+const homebrewFormulaURL = "https://formulae.brew.sh/formula/circleci"
+
+// linuxbrewPrefixes are where Homebrew on Linux, formerly Linuxbrew, is
+// installed. Its brew is often left off the PATH of non-login shells.
+var linuxbrewPrefixes = []string{
+	"/home/linuxbrew/.linuxbrew",
+	filepath.Join(os.Getenv("HOME"), ".linuxbrew"),
+}
+
+// findBrew returns the brew to ask about updates. Homebrew on Linux is also
+// looked for where it is installed when it isn't on the PATH, in which case
+// the path it was found at is returned as onPath is false.
+func findBrew() (brew string, onPath bool, err error) {
+	brew, err = exec.LookPath("brew")
+	if err == nil || runtime.GOOS != "linux" {
+		return brew, err == nil, err
+	}
+
+	for _, prefix := range linuxbrewPrefixes {
+		path := filepath.Join(prefix, "bin", "brew")
+		if info, statErr := os.Stat(path); statErr == nil && !info.IsDir() {
+			return path, false, nil
+		}
+	}
+	return "", false, err
+}
+
 func checkFromHomebrew(check *Options) error {
-	brew, err := exec.LookPath("brew")
+	brew, onPath, err := findBrew()
 	if err != nil {
 		return errors.Wrap(err, "Expected to find `brew` in your $PATH but wasn't able to find it")
 	}
+	if !onPath {
+		check.brew = brew
+	}
 
-	command := exec.Command(brew, "outdated", "--json=v2") // #nosec
+	format := "--json=v2"
+	command := exec.Command(brew, "outdated", format) // #nosec
 	out, err := command.Output()
+	if err != nil && runtime.GOOS == "linux" {
+		// Linuxbrew installs that were never migrated to Homebrew on Linux
+		// only know the original JSON format, a list of formulae.
+		format = "--json=v1"
+		out, err = exec.Command(brew, "outdated", format).Output() // #nosec
+	}
 	if err != nil {
-		return errors.Wrap(err, "failed to check for updates. `brew outdated --json=v2` returned an error")
+		return errors.Wrapf(err, "failed to check for updates. `brew outdated %s` returned an error", format)
 	}
 
 	var outdated HomebrewOutdated
 
 	err = json.Unmarshal(out, &outdated)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse output of `brew outdated --json=v2`")
-	}
-
-	for _, o := range outdated.Formulae {
-		if o.Name == "circleci" {
-			if len(o.InstalledVersions) > 0 {
-				current, err := ParseHomebrewVersion(o.InstalledVersions[0])
-				if err != nil {
-					return err
-				}
-				check.Current = current
-			}
-
-			// see above regarding homebrew / revision numbers
-			latest, err := ParseHomebrewVersion(o.CurrentVersion)
-			if err != nil {
-				return err
-			}
-			check.Latest = &selfupdate.Release{
-				Version: latest,
-			}
-
-			// We found a release so update state of updates check
-			check.Found = true
+		return errors.Wrapf(err, "failed to parse output of `brew outdated %s`", format)
+	}
+
+	o, err := outdated.Formula("circleci")
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return nil
+	}
+
+	if len(o.InstalledVersions) > 0 {
+		current, err := ParseHomebrewVersion(o.InstalledVersions[0])
+		if err != nil {
+			return err
 		}
+		check.Current = current
 	}
 
+	// see above regarding homebrew / revision numbers
+	latest, err := ParseHomebrewVersion(o.CurrentVersion)
+	if err != nil {
+		return err
+	}
+	// Homebrew doesn't say when a version was published or where to download
+	// it, so those are left empty and the release can't be installed by the
+	// updater; `brew upgrade` installs it instead.
+	check.Latest = &selfupdate.Release{
+		Version: latest,
+		Name:    o.CurrentVersion,
+		URL:     homebrewFormulaURL,
+	}
+
+	// We found a release so update state of updates check
+	check.Found = true
+
 	return nil
 }
 
@@ -151,13 +511,62 @@ func checkFromHomebrew(check *Options) error {
 //     "casks": []
 //   }
 type HomebrewOutdated struct {
-	Formulae []struct {
-		Name              string   `json:"name"`
-		InstalledVersions []string `json:"installed_versions"`
-		CurrentVersion    string   `json:"current_version"`
-		Pinned            bool     `json:"pinned"`
-		PinnedVersion     string   `json:"pinned_version"`
-	} `json:"formulae"`
+	Formulae []HomebrewFormula `json:"formulae"`
+}
+
+// UnmarshalJSON also accepts the output of `brew outdated --json=v1`, a list
+// of formulae, which Linuxbrew installs still print.
+func (h *HomebrewOutdated) UnmarshalJSON(data []byte) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		h.Formulae = nil
+		return json.Unmarshal(data, &h.Formulae)
+	}
+
+	type v2 HomebrewOutdated
+	return json.Unmarshal(data, (*v2)(h))
+}
+
+// HomebrewFormula is a single outdated formula reported by `brew outdated --json=v2`.
+// Formulae installed from a tap are named with their tap prefix, e.g. `user/tap/circleci`.
+type HomebrewFormula struct {
+	Name              string   `json:"name"`
+	InstalledVersions []string `json:"installed_versions"`
+	CurrentVersion    string   `json:"current_version"`
+	Pinned            bool     `json:"pinned"`
+	PinnedVersion     string   `json:"pinned_version"`
+}
+
+// Formula returns the outdated formula with the given name, or nil if it isn't outdated.
+// An exact name match is preferred over tapped formulae of the same name; when
+// more than one candidate remains we return an error rather than guess.
+func (h HomebrewOutdated) Formula(name string) (*HomebrewFormula, error) {
+	var exact, tapped []HomebrewFormula
+	for _, f := range h.Formulae {
+		switch {
+		case f.Name == name:
+			exact = append(exact, f)
+		case strings.HasSuffix(f.Name, "/"+name):
+			tapped = append(tapped, f)
+		}
+	}
+
+	candidates := exact
+	if len(candidates) == 0 {
+		candidates = tapped
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &candidates[0], nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, f := range candidates {
+		names[i] = fmt.Sprintf("%s (%s)", f.Name, f.CurrentVersion)
+	}
+	return nil, fmt.Errorf("found multiple outdated homebrew formulae matching %q: %s", name, strings.Join(names, ", "))
 }
 
 // Options contains everything we need to check for or perform updates of the CLI.
@@ -166,18 +575,119 @@ type Options struct {
 	Found          bool
 	Latest         *selfupdate.Release
 	PackageManager string
+	VersionScheme  VersionScheme
+	// OnlyIfOutdated makes installing a no-op when the current version is already the latest.
+	OnlyIfOutdated bool
+	// VersionConstraint is a semver range, such as `>=1.0.0 <2.0.0`, that releases must
+	// satisfy to be considered. Empty means any release.
+	VersionConstraint string
+	// TargetVersion is the exact version to install instead of the latest
+	// release, on any channel. Empty means the latest release.
+	TargetVersion string
+	// LockFile is held while installing so concurrent installs don't race to
+	// replace the binary. Defaults to update.lock in the settings directory.
+	LockFile string
+	// LockWait is how long to wait for another install to finish, 5s if zero.
+	// A negative wait fails at once if another install is running.
+	LockWait time.Duration
+	// BackupDir is where the binary being replaced is saved, so Rollback can
+	// restore it. Defaults to update-backup in the settings directory.
+	BackupDir string
+	// Channel is the release channel being followed, one of ChannelStable,
+	// ChannelBeta or ChannelNightly. Empty means stable.
+	Channel string
+	// Prereleases follows prereleases even on the stable channel, as the beta
+	// channel does.
+	Prereleases bool
+	// CheckUpstream makes package manager checks also look up the latest GitHub release.
+	CheckUpstream bool
+	// Upstream is the latest GitHub release when CheckUpstream is set and it is
+	// newer than anything the package manager offers.
+	Upstream *selfupdate.Release
+	// Location is where absolute timestamps are shown, local time if nil.
+	Location *time.Location
 
-	updater   *selfupdate.Updater
-	githubAPI string
-	slug      string
+	updater       Updater
+	githubAPI     string
+	slug          string
+	skipPreflight bool
+	skipVerify    bool
+	proxy         func(*http.Request) (*url.URL, error)
+	releaseCache  string
+	releaseMirror string
+	// releases are those listed by the check, which install picks from again.
+	releases []Release
+	// credentialHelpers asks the gh CLI and git's credential helper for a
+	// GitHub token when none is found otherwise.
+	credentialHelpers bool
+	// brew is the Homebrew on Linux brew that was checked with when it isn't
+	// on the PATH, so the update instructions can name it.
+	brew string
+}
+
+func (o *Options) releaseCacheFile() string {
+	if o.releaseCache == "" {
+		return defaultReleaseCacheFile()
+	}
+	return o.releaseCache
+}
+
+// transport is used for every request made while checking for and installing
+// updates, so they all go through the same proxy.
+func (o *Options) transport() http.RoundTripper {
+	if o.proxy == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = o.proxy
+	return transport
+}
+
+// managedInstalls are the package managers whose installs must be upgraded
+// through the package manager rather than replaced by the updater.
+var managedInstalls = map[string]bool{
+	"homebrew":   true,
+	"snap":       true,
+	"chocolatey": true,
+	"scoop":      true,
+	"apt":        true,
+	"yum":        true,
+	"nix":        true,
+}
+
+// canInstall reports whether the updater can replace the binary itself. Installs
+// from a package manager may have an updater for the upstream check, but must
+// be upgraded through the package manager.
+func (o *Options) canInstall() bool {
+	return o.updater != nil && !managedInstalls[o.PackageManager]
+}
+
+// hasAsset reports whether rel can be downloaded, which releases that didn't
+// come from GitHub can't.
+func hasAsset(rel *selfupdate.Release) bool {
+	return rel.AssetID != 0 || rel.AssetURL != ""
 }
 
 // latestRelease will set the last known release as a member on the Options instance.
 // We also update options if any releases were found or not.
 func latestRelease(opts *Options) error {
-	latest, found, err := opts.updater.DetectLatest(opts.slug)
-	opts.Latest = latest
-	opts.Found = found
+	allowed, err := opts.versionRange()
+	if err != nil {
+		return err
+	}
+
+	releases := opts.releases
+	if releases == nil {
+		releases, err = opts.updater.Releases(opts.slug)
+		opts.releases = releases
+	}
+	if err == nil {
+		opts.Latest = selectLatest(opts, releases, allowed)
+		opts.Found = opts.Latest != nil
+		if opts.TargetVersion != "" && !opts.Found {
+			return fmt.Errorf("no release of version %s found for this platform", opts.TargetVersion)
+		}
+	}
 
 	if err != nil {
 		return errors.Wrap(err, `Failed to query the GitHub API for updates.
@@ -190,6 +700,9 @@ To have the circleci-cli make authenticated requests please:
   2. Set the token by either adding it to your ~/.gitconfig or
      setting the GITHUB_TOKEN environment variable.
 
+Tokens saved by logging in with `+"`gh auth login`"+` or by git's credential
+helper are used as well.
+
 Instructions for generating a token can be found at:
 https://help.github.com/articles/creating-a-personal-access-token-for-the-command-line/
 
@@ -202,31 +715,189 @@ More information about that API can be found here: https://developer.github.com/
 	return nil
 }
 
+// versionRange parses the version constraint and target version, returning
+// nil if there are neither.
+func (opts *Options) versionRange() (semver.Range, error) {
+	if opts.TargetVersion != "" {
+		target, err := opts.VersionScheme.Parse(opts.TargetVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid version %q", opts.TargetVersion)
+		}
+		return func(v semver.Version) bool {
+			return opts.VersionScheme.Compare(v, target) == 0
+		}, nil
+	}
+
+	if opts.VersionConstraint == "" {
+		return nil, nil
+	}
+
+	allowed, err := semver.ParseRange(opts.VersionConstraint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid version constraint %q", opts.VersionConstraint)
+	}
+	return allowed, nil
+}
+
 // IsLatestVersion will tell us if the current version is the latest version available
 func IsLatestVersion(opts *Options) bool {
 	if opts.Current.String() == "" || opts.Latest == nil {
 		return true
 	}
 
-	return opts.Latest.Version.Equals(opts.Current)
+	scheme := opts.VersionScheme
+	if scheme == nil {
+		scheme = SemverScheme{}
+	}
+
+	// A target version is installed whether it is newer or older.
+	if opts.TargetVersion != "" {
+		return scheme.Compare(opts.Current, opts.Latest.Version) == 0
+	}
+	return scheme.Compare(opts.Current, opts.Latest.Version) >= 0
 }
 
 // InstallLatest will execute the updater and replace the current CLI with the latest version available.
 func InstallLatest(opts *Options) (string, error) {
-	release, err := opts.updater.UpdateSelf(opts.Current, opts.slug)
+	result, err := InstallLatestWithResult(opts)
+	if err != nil || result.Skipped {
+		return "", err
+	}
+
+	return fmt.Sprintf("Updated to %s", result.Version), nil
+}
+
+// InstallResult describes the outcome of an install, for callers such as
+// packaging scripts that need more than a human readable message.
+type InstallResult struct {
+	PreviousVersion semver.Version
+	Version         semver.Version
+	Path            string
+	Checksum        string
+	Duration        time.Duration
+	// Skipped is set when OnlyIfOutdated was requested and there was nothing to install.
+	Skipped bool
+}
+
+// InstallLatestWithResult behaves like InstallLatest but returns a structured InstallResult.
+func InstallLatestWithResult(opts *Options) (*InstallResult, error) {
+	start := time.Now()
+
+	if !opts.canInstall() {
+		return nil, fmt.Errorf("failed to install update: not supported for %s installs", opts.PackageManager)
+	}
+
+	path, err := ExecutablePath()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to install update")
+		return nil, errors.Wrap(err, "failed to install update")
 	}
 
-	return fmt.Sprintf("Updated to %s", release.Version), nil
+	release, err := lockUpdate(opts.LockFile, opts.LockWait)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Pick the release again from those the check listed, now that the lock
+	// is held, rather than list them all over again.
+	if err = latestRelease(opts); err != nil {
+		return nil, err
+	}
+	if opts.OnlyIfOutdated && (opts.Latest == nil || IsLatestVersion(opts)) {
+		return &InstallResult{
+			PreviousVersion: opts.Current,
+			Version:         opts.Current,
+			Path:            path,
+			Duration:        time.Since(start),
+			Skipped:         true,
+		}, nil
+	}
+	if opts.Latest == nil {
+		return nil, errors.New("failed to install update: no release found")
+	}
+
+	if err = backupBinary(opts.BackupDir, path, opts.Current); err != nil {
+		return nil, errors.Wrap(err, "failed to back up the current binary")
+	}
+
+	err = opts.updater.UpdateTo(opts.Latest, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to install update")
+	}
+
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to checksum updated binary")
+	}
+
+	return &InstallResult{
+		PreviousVersion: opts.Current,
+		Version:         opts.Latest.Version,
+		Path:            path,
+		Checksum:        checksum,
+		Duration:        time.Since(start),
+	}, nil
+}
+
+// Render executes the given Go template against the result and writes it to w.
+func (r *InstallResult) Render(w io.Writer, text string) error {
+	tmpl, err := template.New("result").Parse(text)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse result template")
+	}
+
+	return tmpl.Execute(w, r)
+}
+
+// executable finds the running binary, overridden in tests.
+var executable = os.Executable
+
+// ExecutablePath returns the path of the running binary with any symlinks resolved,
+// which is the file the updater replaces. It fails with advice on reinstalling
+// if the binary can't be found, such as when it was deleted while running.
+func ExecutablePath() (string, error) {
+	path, err := executable()
+	if err == nil {
+		path, err = filepath.EvalSymlinks(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot find the running circleci binary to replace (%v), "+
+			"install the latest version from https://github.com/CircleCI-Public/circleci-cli/releases instead", err)
+	}
+	return path, nil
+}
+
+// fileChecksum returns the hex encoded SHA256 of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // DebugVersion returns a nicely formatted string representing the state of the current version.
 // Intended to be printed to standard error for developers.
 func DebugVersion(opts *Options) string {
+	published := "unknown"
+	if opts.Latest.PublishedAt != nil {
+		loc := opts.Location
+		if loc == nil {
+			loc = time.Local
+		}
+		published = opts.Latest.PublishedAt.In(loc).Format(time.RFC3339)
+	}
+
 	return strings.Join([]string{
 		fmt.Sprintf("Latest version: %s", opts.Latest.Version),
-		fmt.Sprintf("Published: %s", opts.Latest.PublishedAt),
+		fmt.Sprintf("Published: %s", published),
 		fmt.Sprintf("Current Version: %s", opts.Current),
 	}, "\n")
 }
@@ -234,17 +905,147 @@ func DebugVersion(opts *Options) string {
 // ReportVersion returns a nicely formatted string representing the state of the current version.
 // Intended to be printed to the user.
 func ReportVersion(opts *Options) string {
-	return strings.Join([]string{
+	available := fmt.Sprintf("A new release is available (%s)", opts.Latest.Version)
+	if opts.TargetVersion != "" {
+		available = fmt.Sprintf("Release %s is available", opts.Latest.Version)
+	} else if opts.VersionConstraint != "" {
+		available = fmt.Sprintf("A new release matching %s is available (%s)", opts.VersionConstraint, opts.Latest.Version)
+	}
+
+	lines := []string{
 		fmt.Sprintf("You are running %s", opts.Current),
-		fmt.Sprintf("A new release is available (%s)", opts.Latest.Version),
-	}, "\n")
+		available,
+	}
+	if notes := abbreviateNotes(ReleaseNotes(opts)); len(notes) > 0 {
+		lines = append(lines, "What's new:")
+		for _, line := range notes {
+			lines = append(lines, "  "+line)
+		}
+		if opts.Latest.URL != "" {
+			lines = append(lines, fmt.Sprintf("Full release notes: %s", opts.Latest.URL))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// releaseNotesLines is how many lines of the release notes ReportVersion shows.
+const releaseNotesLines = 5
+
+// ReleaseNotes returns the notes published with the latest release, or an
+// empty string if it has none.
+func ReleaseNotes(opts *Options) string {
+	if opts.Latest == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(opts.Latest.ReleaseNotes, "\r\n", "\n"))
+}
+
+// abbreviateNotes returns the first non-blank lines of notes, ending with an
+// ellipsis when some were left out.
+func abbreviateNotes(notes string) []string {
+	var lines []string
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(lines) == releaseNotesLines {
+			return append(lines, "...")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// NoticeConfig controls what UpdateNotice tells the user about an available update.
+type NoticeConfig struct {
+	// SuppressInstructions omits the HowToUpdate line, for installs that are managed centrally.
+	SuppressInstructions bool
+	// CustomMessage replaces the HowToUpdate line when set.
+	CustomMessage string
+	// NagLevel is the smallest kind of release, one of patch, minor or major, that
+	// should be noticed. Empty or unknown levels notice any newer release.
+	NagLevel string
+}
+
+// meetsNagLevel reports whether latest is newer than current by at least the given semver level.
+func meetsNagLevel(current, latest semver.Version, level string) bool {
+	switch level {
+	case "major":
+		return latest.Major > current.Major
+	case "minor":
+		return latest.Major > current.Major ||
+			(latest.Major == current.Major && latest.Minor > current.Minor)
+	default:
+		return latest.GT(current)
+	}
+}
+
+// UpdateNotice returns the message shown to the user when a new release is
+// available, or an empty string if the release is below the configured NagLevel.
+func UpdateNotice(opts *Options, cfg NoticeConfig) string {
+	if !meetsNagLevel(opts.Current, opts.Latest.Version, cfg.NagLevel) {
+		return ""
+	}
+
+	lines := []string{ReportVersion(opts)}
+	if upstream := UpstreamNotice(opts); upstream != "" {
+		lines = append(lines, upstream)
+	}
+
+	switch {
+	case cfg.CustomMessage != "":
+		lines = append(lines, cfg.CustomMessage)
+	case !cfg.SuppressInstructions && HasUpdateInstructions(opts):
+		lines = append(lines, HowToUpdate(opts))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// UpstreamNotice returns a note that the package manager is behind the latest
+// GitHub release, or an empty string if it isn't known to be.
+func UpstreamNotice(opts *Options) string {
+	if opts.Upstream == nil {
+		return ""
+	}
+	return fmt.Sprintf("Your package manager hasn't caught up yet (latest upstream is %s)", opts.Upstream.Version)
 }
 
 // HowToUpdate returns a message teaching the user how to update to the latest version.
 func HowToUpdate(opts *Options) string {
+	instructions := howToUpdate(opts)
+	if instructions != "" && opts.VersionConstraint != "" {
+		instructions += fmt.Sprintf("\nUpdates are limited to versions matching %s", opts.VersionConstraint)
+	}
+	return instructions
+}
+
+// HasUpdateInstructions reports whether HowToUpdate knows how to update
+// installs from the package manager in opts.
+func HasUpdateInstructions(opts *Options) bool {
+	return howToUpdate(opts) != ""
+}
+
+func howToUpdate(opts *Options) string {
 	switch opts.PackageManager {
 	case "homebrew":
+		if opts.brew != "" {
+			return fmt.Sprintf("You can update with `%s upgrade circleci`", opts.brew)
+		}
 		return "You can update with `brew upgrade circleci`"
+	case "snap":
+		return "You can update with `sudo snap refresh circleci`"
+	case "chocolatey":
+		return "You can update with `choco upgrade circleci-cli`"
+	case "scoop":
+		return "You can update with `scoop update circleci`"
+	case "apt":
+		return "You can update with `sudo apt-get update && sudo apt-get install --only-upgrade circleci`"
+	case "yum":
+		return "You can update with `sudo yum update circleci`"
+	case "nix":
+		return "You can update with `nix-env --upgrade circleci-cli`, or by updating the Nix configuration that installs it"
 	case "release":
 		return "You can update with `circleci update install`"
 	case "source":
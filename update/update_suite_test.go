@@ -1,6 +1,8 @@
 package update_test
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -11,3 +13,18 @@ func TestUpdate(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Update Suite")
 }
+
+// Keep the settings directory, where the update lock lives, out of the real home directory.
+var home string
+
+var _ = BeforeSuite(func() {
+	var err error
+	home, err = ioutil.TempDir("", "circleci-cli-update-test-")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(os.Setenv("HOME", home)).To(Succeed())
+	Expect(os.Setenv("USERPROFILE", home)).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	os.RemoveAll(home)
+})
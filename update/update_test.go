@@ -1,10 +1,15 @@
 package update_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"time"
+
 	"github.com/CircleCI-Public/circleci-cli/update"
 	"github.com/blang/semver"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
 )
 
 var _ = Describe("Homebrew Version Parsing", func() {
@@ -29,3 +34,119 @@ var _ = Describe("Homebrew Version Parsing", func() {
 		Expect(err).To(MatchError(MatchRegexp("asdad.1231.-_")))
 	})
 })
+
+var _ = Describe("Homebrew Outdated Parsing", func() {
+	parse := func(out string) update.HomebrewOutdated {
+		var outdated update.HomebrewOutdated
+		Expect(json.Unmarshal([]byte(out), &outdated)).To(Succeed())
+		return outdated
+	}
+
+	It("Should prefer the exact formula name over a tapped variant", func() {
+		outdated := parse(`{"formulae": [
+			{"name": "circleci-public/tap/circleci", "installed_versions": ["0.1.2"], "current_version": "0.1.5"},
+			{"name": "circleci", "installed_versions": ["0.1.1"], "current_version": "0.1.4"},
+			{"name": "circleci-extra", "installed_versions": ["1.0.0"], "current_version": "2.0.0"}
+		]}`)
+
+		formula, err := outdated.Formula("circleci")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(formula.Name).To(Equal("circleci"))
+		Expect(formula.CurrentVersion).To(Equal("0.1.4"))
+	})
+
+	It("Should fall back to a single tapped formula", func() {
+		outdated := parse(`{"formulae": [
+			{"name": "circleci-public/tap/circleci", "installed_versions": ["0.1.2"], "current_version": "0.1.5"}
+		]}`)
+
+		formula, err := outdated.Formula("circleci")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(formula.Name).To(Equal("circleci-public/tap/circleci"))
+	})
+
+	It("Should return an error listing the candidates when ambiguous", func() {
+		outdated := parse(`{"formulae": [
+			{"name": "circleci-public/tap/circleci", "current_version": "0.1.5"},
+			{"name": "someone/tap/circleci", "current_version": "0.1.6"}
+		]}`)
+
+		_, err := outdated.Formula("circleci")
+		Expect(err).To(MatchError(ContainSubstring(`multiple outdated homebrew formulae matching "circleci"`)))
+		Expect(err).To(MatchError(ContainSubstring("circleci-public/tap/circleci (0.1.5), someone/tap/circleci (0.1.6)")))
+	})
+
+	It("Should return nothing when circleci isn't outdated", func() {
+		outdated := parse(`{"formulae": [{"name": "git", "current_version": "2.30.0"}]}`)
+
+		formula, err := outdated.Formula("circleci")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(formula).To(BeNil())
+	})
+
+	It("Should parse the list printed by Linuxbrew's brew outdated --json=v1", func() {
+		outdated := parse(`[{"name": "circleci", "installed_versions": ["0.1.1"], "current_version": "0.1.4_1"}]`)
+
+		formula, err := outdated.Formula("circleci")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(formula.CurrentVersion).To(Equal("0.1.4_1"))
+	})
+})
+
+var _ = Describe("Install Result", func() {
+	It("Should render a template against the result", func() {
+		result := &update.InstallResult{
+			PreviousVersion: semver.MustParse("0.1.100"),
+			Version:         semver.MustParse("0.1.200"),
+			Path:            "/usr/local/bin/circleci",
+			Checksum:        "abc123",
+			Duration:        1500 * time.Millisecond,
+		}
+
+		out := &bytes.Buffer{}
+		err := result.Render(out, "{{.PreviousVersion}} -> {{.Version}} at {{.Path}} ({{.Checksum}}) in {{.Duration}}")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out.String()).To(Equal("0.1.100 -> 0.1.200 at /usr/local/bin/circleci (abc123) in 1.5s"))
+	})
+
+	It("Should report an invalid template", func() {
+		err := (&update.InstallResult{}).Render(&bytes.Buffer{}, "{{.Version")
+		Expect(err).To(MatchError(ContainSubstring("failed to parse result template")))
+	})
+})
+
+var _ = Describe("Debug Version", func() {
+	published := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	opts := func(loc *time.Location) *update.Options {
+		return &update.Options{
+			Current:  semver.MustParse("0.1.100"),
+			Latest:   &selfupdate.Release{Version: semver.MustParse("0.1.200"), PublishedAt: &published},
+			Location: loc,
+		}
+	}
+
+	var local *time.Location
+	BeforeEach(func() {
+		local = time.Local
+		time.Local = time.FixedZone("EST", -5*60*60)
+	})
+
+	AfterEach(func() {
+		time.Local = local
+	})
+
+	It("Should show when the release was published in UTC", func() {
+		Expect(update.DebugVersion(opts(time.UTC))).To(ContainSubstring("Published: 2021-06-01T12:00:00Z\n"))
+	})
+
+	It("Should show when the release was published in local time by default", func() {
+		Expect(update.DebugVersion(opts(nil))).To(ContainSubstring("Published: 2021-06-01T07:00:00-05:00\n"))
+		Expect(update.DebugVersion(opts(time.Local))).To(ContainSubstring("Published: 2021-06-01T07:00:00-05:00\n"))
+	})
+
+	It("Should cope with an unknown publish time", func() {
+		o := opts(nil)
+		o.Latest.PublishedAt = nil
+		Expect(update.DebugVersion(o)).To(ContainSubstring("Published: unknown\n"))
+	})
+})
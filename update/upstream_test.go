@@ -0,0 +1,117 @@
+package update_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upstream check", func() {
+	var path, dir string
+
+	// fakeBrew puts a `brew` on the PATH that reports the given outdated JSON.
+	fakeBrew := func(outdated string) {
+		var err error
+		dir, err = ioutil.TempDir("", "fake-brew")
+		Expect(err).ToNot(HaveOccurred())
+
+		script := fmt.Sprintf("#!/bin/sh\ncat <<'EOF'\n%s\nEOF\n", outdated)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "brew"), []byte(script), 0700)).To(Succeed())
+		Expect(os.Setenv("PATH", dir+string(os.PathListSeparator)+path)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("fake brew is a shell script")
+		}
+		path = os.Getenv("PATH")
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	})
+
+	upToDate := `{"formulae": [], "casks": []}`
+	outdated := `{"formulae": [{"name": "circleci", "installed_versions": ["0.1.0"], "current_version": "0.2.0"}], "casks": []}`
+
+	It("Should report a newer GitHub release when homebrew is up to date", func() {
+		fakeBrew(upToDate)
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.1.0"), platformRelease("v0.2.0")}}
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew",
+			update.WithUpdater(updater), update.WithUpstreamCheck())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Found).To(BeFalse())
+		Expect(check.Upstream.Version).To(Equal(semver.MustParse("0.2.0")))
+		Expect(update.UpstreamNotice(check)).To(Equal("Your package manager hasn't caught up yet (latest upstream is 0.2.0)"))
+	})
+
+	It("Should note upstream in the notice when homebrew is behind GitHub", func() {
+		fakeBrew(outdated)
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.3.0")}}
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew",
+			update.WithUpdater(updater), update.WithUpstreamCheck())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(update.UpdateNotice(check, update.NoticeConfig{})).To(Equal("You are running 0.1.0\n" +
+			"A new release is available (0.2.0)\n" +
+			"Your package manager hasn't caught up yet (latest upstream is 0.3.0)\n" +
+			"You can update with `brew upgrade circleci`"))
+	})
+
+	It("Should not report upstream when it isn't strictly newer", func() {
+		fakeBrew(outdated)
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew",
+			update.WithUpdater(updater), update.WithUpstreamCheck())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Upstream).To(BeNil())
+		Expect(update.UpstreamNotice(check)).To(BeEmpty())
+	})
+
+	It("Should not consult GitHub unless asked to", func() {
+		fakeBrew(upToDate)
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.2.0")}}
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew",
+			update.WithUpdater(updater))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Upstream).To(BeNil())
+	})
+
+	It("Should describe a homebrew release without a publish date or assets", func() {
+		fakeBrew(outdated)
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(check.Latest.Name).To(Equal("0.2.0"))
+		Expect(check.Latest.URL).To(Equal("https://formulae.brew.sh/formula/circleci"))
+		Expect(update.DebugVersion(check)).To(Equal("Latest version: 0.2.0\nPublished: unknown\nCurrent Version: 0.1.0"))
+	})
+
+	It("Should refuse to install or fetch a homebrew release", func() {
+		fakeBrew(outdated)
+		updater := &fakeUpdater{releases: []update.Release{platformRelease("v0.3.0")}}
+
+		check, err := update.CheckForUpdates("https://api.github.com/", "CircleCI-Public/circleci-cli", "0.1.0", "homebrew",
+			update.WithUpdater(updater), update.WithUpstreamCheck())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = update.InstallLatestWithResult(check)
+		Expect(err).To(MatchError("failed to install update: not supported for homebrew installs"))
+		_, err = update.FetchLatest(check, os.TempDir())
+		Expect(err).To(MatchError("failed to fetch update: not supported for homebrew installs"))
+		Expect(updater.installed).To(BeEmpty())
+	})
+})
@@ -0,0 +1,72 @@
+package update
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// VersionScheme parses release tags and version strings into comparable versions.
+// Schemes other than semver normalise their versions into a semver.Version so
+// the rest of the update machinery only has to deal with one representation.
+type VersionScheme interface {
+	Parse(version string) (semver.Version, error)
+	Compare(a, b semver.Version) int
+}
+
+// SemverScheme is the default scheme, versions are strict semver with an optional leading `v`.
+type SemverScheme struct{}
+
+func (SemverScheme) Parse(version string) (semver.Version, error) {
+	return semver.Parse(strings.TrimPrefix(version, "v"))
+}
+
+func (SemverScheme) Compare(a, b semver.Version) int {
+	return a.Compare(b)
+}
+
+// CalVerScheme parses calendar versions such as `2024.01.15` or `v2024.1.3-rc1`.
+// Components may be zero padded, and are mapped onto major, minor and patch in order.
+type CalVerScheme struct{}
+
+func (CalVerScheme) Parse(version string) (semver.Version, error) {
+	v := strings.TrimPrefix(version, "v")
+
+	var pre string
+	if i := strings.Index(v, "-"); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return semver.Version{}, fmt.Errorf("invalid calendar version %q: expected YYYY.MM or YYYY.MM.DD", version)
+	}
+
+	nums := make([]uint64, 3)
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("invalid calendar version %q: %s", version, err)
+		}
+		nums[i] = n
+	}
+
+	parsed := semver.Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}
+	if pre != "" {
+		for _, p := range strings.Split(pre, ".") {
+			prVersion, err := semver.NewPRVersion(p)
+			if err != nil {
+				return semver.Version{}, fmt.Errorf("invalid calendar version %q: %s", version, err)
+			}
+			parsed.Pre = append(parsed.Pre, prVersion)
+		}
+	}
+
+	return parsed, nil
+}
+
+func (CalVerScheme) Compare(a, b semver.Version) int {
+	return a.Compare(b)
+}
@@ -0,0 +1,88 @@
+package update_test
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/update"
+	"github.com/blang/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version Schemes", func() {
+	Describe("semver", func() {
+		scheme := update.SemverScheme{}
+
+		It("Should treat v-prefixed and bare versions the same", func() {
+			a, err := scheme.Parse("v1.2.3")
+			Expect(err).ShouldNot(HaveOccurred())
+			b, err := scheme.Parse("1.2.3")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(scheme.Compare(a, b)).To(Equal(0))
+		})
+
+		It("Should order mixed prefixed versions", func() {
+			a, err := scheme.Parse("v0.1.9")
+			Expect(err).ShouldNot(HaveOccurred())
+			b, err := scheme.Parse("0.1.10")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(scheme.Compare(a, b)).To(Equal(-1))
+		})
+
+		It("Should reject calendar versions with zero padding", func() {
+			_, err := scheme.Parse("2024.01.15")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("calver", func() {
+		scheme := update.CalVerScheme{}
+
+		It("Should parse zero padded dates", func() {
+			v, err := scheme.Parse("2024.01.15")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal(semver.Version{Major: 2024, Minor: 1, Patch: 15}))
+		})
+
+		It("Should parse v-prefixed dates with a pre-release", func() {
+			v, err := scheme.Parse("v2024.1.3-rc1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v.String()).To(Equal("2024.1.3-rc1"))
+		})
+
+		It("Should parse year and month only", func() {
+			v, err := scheme.Parse("2023.12")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal(semver.Version{Major: 2023, Minor: 12}))
+		})
+
+		It("Should compare dates chronologically", func() {
+			older, err := scheme.Parse("2023.12.31")
+			Expect(err).ShouldNot(HaveOccurred())
+			newer, err := scheme.Parse("v2024.01.02")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(scheme.Compare(older, newer)).To(Equal(-1))
+			Expect(scheme.Compare(newer, older)).To(Equal(1))
+		})
+
+		It("Should reject garbage", func() {
+			_, err := scheme.Parse("2024")
+			Expect(err).To(MatchError(ContainSubstring(`invalid calendar version "2024"`)))
+			_, err = scheme.Parse("2024.jan.01")
+			Expect(err).To(MatchError(ContainSubstring(`invalid calendar version "2024.jan.01"`)))
+		})
+	})
+
+	Describe("IsLatestVersion", func() {
+		It("Should compare using the configured scheme", func() {
+			scheme := update.CalVerScheme{}
+			current, _ := scheme.Parse("2024.01.15")
+			latest, _ := scheme.Parse("2024.02.01")
+
+			opts := &update.Options{Current: current, VersionScheme: scheme}
+			opts.Latest = releaseWithVersion(latest)
+			Expect(update.IsLatestVersion(opts)).To(BeFalse())
+
+			opts.Current = latest
+			Expect(update.IsLatestVersion(opts)).To(BeTrue())
+		})
+	})
+})
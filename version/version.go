@@ -3,6 +3,11 @@ package version
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 )
 
 // These vars set by `goreleaser`:
@@ -24,6 +29,37 @@ func PackageManager() string {
 	if runningInsideSnap() {
 		return "snap"
 	}
+	if runtime.GOOS == "windows" {
+		if exe, err := os.Executable(); err == nil {
+			if pm := windowsPackageManager(exe, os.Getenv("ChocolateyInstall")); pm != "" {
+				return pm
+			}
+		}
+	}
+	if linuxRelease() {
+		if exe, err := executable(); err == nil && strings.HasPrefix(exe, "/nix/store/") {
+			return "nix"
+		}
+	}
+	return packageManager
+}
+
+// InstalledPackageManager is PackageManager, except that it also recognises
+// a binary installed from a distro package by asking dpkg and rpm whether they
+// own it. That runs a command, so it is left to the update code rather than
+// done on every invocation, and only done once.
+func InstalledPackageManager() string {
+	if pm := PackageManager(); pm != packageManager || !linuxRelease() {
+		return pm
+	}
+	linuxPackageManagerOnce.Do(func() {
+		if exe, err := executable(); err == nil {
+			linuxPM = linuxPackageManager(exe)
+		}
+	})
+	if linuxPM != "" {
+		return linuxPM
+	}
 	return packageManager
 }
 
@@ -39,3 +75,69 @@ func runningInsideSnap() bool {
 	// https://snapcraft.io/docs/environment-variables
 	return os.Getenv("SNAP_NAME") == "circleci"
 }
+
+// windowsPackageManager recognises the release binary installed by Chocolatey
+// or Scoop from where it lives, as both ship it unchanged. Chocolatey keeps
+// packages under lib in its install directory, and Scoop keeps apps under
+// scoop\apps in the user's or global Scoop directory.
+func windowsPackageManager(exe, chocolateyInstall string) string {
+	path := strings.ToLower(filepath.Clean(exe))
+	if chocolateyInstall != "" {
+		lib := strings.ToLower(filepath.Join(filepath.Clean(chocolateyInstall), "lib")) + string(filepath.Separator)
+		if strings.HasPrefix(path, lib) {
+			return "chocolatey"
+		}
+	}
+	if strings.Contains(path, string(filepath.Separator)+filepath.Join("scoop", "apps")+string(filepath.Separator)) {
+		return "scoop"
+	}
+	return ""
+}
+
+var (
+	linuxPackageManagerOnce sync.Once
+	linuxPM                 string
+)
+
+// linuxRelease reports whether this is a Linux build that could have been
+// repackaged by a distro, rather than one built for a package manager.
+func linuxRelease() bool {
+	return runtime.GOOS == "linux" && (packageManager == "source" || packageManager == "release")
+}
+
+// executable returns the path of the running binary, with symlinks resolved.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// linuxPackageManager recognises a binary installed from a distro package by
+// asking dpkg and rpm whether they own exe. Packages don't install into
+// /usr/local or home directories, so binaries there aren't asked about.
+func linuxPackageManager(exe string) string {
+	if strings.HasPrefix(exe, "/usr/local/") ||
+		!(strings.HasPrefix(exe, "/usr/") || strings.HasPrefix(exe, "/bin/") || strings.HasPrefix(exe, "/opt/")) {
+		return ""
+	}
+	if ownsFile("dpkg", "-S", exe) {
+		return "apt"
+	}
+	if ownsFile("rpm", "-qf", exe) {
+		return "yum"
+	}
+	return ""
+}
+
+// ownsFile reports whether the package manager command exits successfully
+// when asked which package owns a file, which it only does for files it
+// installed.
+func ownsFile(command string, args ...string) bool {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return false
+	}
+	return exec.Command(path, args...).Run() == nil // #nosec
+}